@@ -15,8 +15,7 @@
 package cmd
 
 import (
-	"log"
-
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
@@ -44,28 +43,37 @@ puts it in IPFS, converting the data as an 'eth-block'`,
 func init() {
 	rootCmd.AddCommand(createIpldForBlockHeaderCmd)
 	createIpldForBlockHeaderCmd.Flags().Int64VarP(&blockNumber, "block-number", "b", 0, "Create IPLD for this block header.")
+	createIpldForBlockHeaderCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	createIpldForBlockHeaderCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
 }
 
 func createIpldForBlockHeader() {
+	ctx := interruptContext()
+
 	// init eth db
 	ethDBConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
 	ethDB, err := db.CreateDatabase(ethDBConfig)
 	if err != nil {
 		log.Fatal("Error connecting to ethereum db: ", err)
 	}
+	selectBlockByHash(ctx, ethDB)
 
 	// init ipfs publisher
 	ipfsNode, err := ipfs.InitIPFSNode(ipfsPath)
 	if err != nil {
 		log.Fatal("Error connecting to IPFS: ", err)
 	}
+	defer ipfsNode.Close()
 	decoder := rlp.RlpDecoder{}
 	dagPutter := eth_block_header.NewBlockHeaderDagPutter(*ipfsNode, decoder)
-	publisher := ipfs.NewIpfsPublisher(dagPutter)
+	publisher := ipfs.NewHeaderPublisher(dagPutter)
 
 	// execute transformer
-	transformer := transformers.NewEthBlockHeaderTransformer(ethDB, publisher)
-	err = transformer.Execute(blockNumber, blockNumber)
+	transformer := transformers.NewEthBlockHeaderTransformer(ethDB, publisher, missingDataPolicy())
+	err = transformer.Execute(ctx, blockNumber, blockNumber)
+	if interrupted(err) {
+		return
+	}
 	if err != nil {
 		log.Fatal("Error executing transformer: ", err.Error())
 	}
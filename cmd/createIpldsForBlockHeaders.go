@@ -15,8 +15,7 @@
 package cmd
 
 import (
-	"log"
-
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
@@ -45,28 +44,40 @@ func init() {
 	rootCmd.AddCommand(createIpldsForBlockHeadersCmd)
 	createIpldsForBlockHeadersCmd.Flags().Int64VarP(&startingBlockNumber, "starting-block-number", "s", 0, "First block number to create IPLD for.")
 	createIpldsForBlockHeadersCmd.Flags().Int64VarP(&endingBlockNumber, "ending-block-number", "e", 5900000, "Last block number to create IPLD for.")
+	createIpldsForBlockHeadersCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	createIpldsForBlockHeadersCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
+	createIpldsForBlockHeadersCmd.Flags().StringVar(&fromTime, "from-time", "", "start from the first block at or after this date or RFC 3339 time")
+	createIpldsForBlockHeadersCmd.Flags().StringVar(&toTime, "to-time", "", "end at the last block at or before this date or RFC 3339 time")
 }
 
 func createIpldsForBlockHeaders() {
+	ctx := interruptContext()
+
 	// init eth db
 	ethDBConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
 	ethDB, err := db.CreateDatabase(ethDBConfig)
 	if err != nil {
 		log.Fatal("Error connecting to ethereum db: ", err)
 	}
+	selectBlockByHash(ctx, ethDB)
+	selectBlocksByTime(ctx, ethDB)
 
 	// init ipfs publisher
 	ipfsNode, err := ipfs.InitIPFSNode(ipfsPath)
 	if err != nil {
 		log.Fatal("Error connecting to IPFS: ", err)
 	}
+	defer ipfsNode.Close()
 	decoder := rlp.RlpDecoder{}
 	dagPutter := eth_block_header.NewBlockHeaderDagPutter(*ipfsNode, decoder)
-	publisher := ipfs.NewIpfsPublisher(dagPutter)
+	publisher := ipfs.NewHeaderPublisher(dagPutter)
 
 	// execute transformer
-	transformer := transformers.NewEthBlockHeaderTransformer(ethDB, publisher)
-	err = transformer.Execute(startingBlockNumber, endingBlockNumber)
+	transformer := transformers.NewEthBlockHeaderTransformer(ethDB, publisher, missingDataPolicy())
+	err = transformer.Execute(ctx, startingBlockNumber, endingBlockNumber)
+	if interrupted(err) {
+		return
+	}
 	if err != nil {
 		log.Fatal("Error executing transformer: ", err.Error())
 	}
@@ -15,12 +15,12 @@
 package cmd
 
 import (
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_receipts"
 	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
-	"log"
 )
 
 // createIpldsForBlockReceiptsCmd represents the createIpldsForBlockReceipts command
@@ -40,27 +40,36 @@ The block number flag specifies the block for which to fetch and persist receipt
 func init() {
 	rootCmd.AddCommand(createIpldsForBlockReceiptsCmd)
 	createIpldsForBlockReceiptsCmd.Flags().Int64VarP(&blockNumber, "block-number", "b", 0, "block for which to create receipt IPLDs")
+	createIpldsForBlockReceiptsCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	createIpldsForBlockReceiptsCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
 }
 
 func createBlockReceipts() {
+	ctx := interruptContext()
+
 	// init eth db
 	ethDBConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
 	ethDB, err := db.CreateDatabase(ethDBConfig)
 	if err != nil {
 		log.Fatal("Error connecting to ethereum db: ", err)
 	}
+	selectBlockByHash(ctx, ethDB)
 
 	// init ipfs publisher
 	ipfsNode, err := ipfs.InitIPFSNode(ipfsPath)
 	if err != nil {
 		log.Fatal("Error connecting to IPFS: ", err)
 	}
+	defer ipfsNode.Close()
 	dagPutter := eth_block_receipts.NewEthBlockReceiptDagPutter(ipfsNode)
-	publisher := ipfs.NewIpfsPublisher(dagPutter)
+	publisher := ipfs.NewReceiptsPublisher(dagPutter)
 
 	// execute transformer
-	transformer := transformers.NewEthBlockReceiptTransformer(ethDB, publisher)
-	err = transformer.Execute(blockNumber, blockNumber)
+	transformer := transformers.NewEthBlockReceiptTransformer(ethDB, publisher, missingDataPolicy())
+	err = transformer.Execute(ctx, blockNumber, blockNumber)
+	if interrupted(err) {
+		return
+	}
 	if err != nil {
 		log.Fatal("Error creating receipt IPLDs for block: ", err)
 	}
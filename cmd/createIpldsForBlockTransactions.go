@@ -15,8 +15,7 @@
 package cmd
 
 import (
-	"log"
-
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
@@ -42,27 +41,36 @@ The block number specifies the block for which to create transaction IPLDs, and
 func init() {
 	rootCmd.AddCommand(createIpldsForBlockTransactionsCmd)
 	createIpldsForBlockTransactionsCmd.Flags().Int64VarP(&blockNumber, "block-number", "b", 0, "Create IPLD for this block.")
+	createIpldsForBlockTransactionsCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	createIpldsForBlockTransactionsCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
 }
 
 func createIpldsForBlockTransactions() {
+	ctx := interruptContext()
+
 	// init eth db
 	ethDBConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
 	ethDB, err := db.CreateDatabase(ethDBConfig)
 	if err != nil {
 		log.Fatal("Error connecting to ethereum db: ", err)
 	}
+	selectBlockByHash(ctx, ethDB)
 
 	// init ipfs publisher
 	ipfsNode, err := ipfs.InitIPFSNode(ipfsPath)
 	if err != nil {
 		log.Fatal("Error connecting to IPFS: ", err)
 	}
+	defer ipfsNode.Close()
 	dagPutter := eth_block_transactions.NewBlockTransactionsDagPutter(*ipfsNode)
-	publisher := ipfs.NewIpfsPublisher(dagPutter)
+	publisher := ipfs.NewBodyPublisher(dagPutter)
 
 	// execute transformer
-	transformer := transformers.NewEthBlockTransactionsTransformer(ethDB, publisher)
-	err = transformer.Execute(blockNumber, blockNumber)
+	transformer := transformers.NewEthBlockTransactionsTransformer(ethDB, publisher, missingDataPolicy())
+	err = transformer.Execute(ctx, blockNumber, blockNumber)
+	if interrupted(err) {
+		return
+	}
 	if err != nil {
 		log.Fatal("Error executing transformer: ", err.Error())
 	}
@@ -0,0 +1,88 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_witness"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// createIpldsForBlockWitnessesCmd represents the createIpldsForBlockWitnesses command
+var createIpldsForBlockWitnessesCmd = &cobra.Command{
+	Use:   "createIpldsForBlockWitnesses",
+	Short: "Create IPLDs for the state needed to execute each block",
+	Long: `Create IPLDs for the state and storage trie nodes read while executing
+each block in a range, plus an IPLD per block listing them. For example:
+
+./eth-block-extractor createIpldsForBlockWitnesses -s 1234567 -e 1234667
+
+Together with the block, a witness is enough to execute it without the rest of
+the state. Each block is executed against its parent's state root, so that
+state must be stored (e.g. on an archive node). Genesis has no parent and is
+skipped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		createIpldsForBlockWitnesses()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(createIpldsForBlockWitnessesCmd)
+	createIpldsForBlockWitnessesCmd.Flags().Int64VarP(&startingBlockNumber, "starting-block-number", "s", 1, "First block number to create witness IPLDs for.")
+	createIpldsForBlockWitnessesCmd.Flags().Int64VarP(&endingBlockNumber, "ending-block-number", "e", 5900000, "Last block number to create witness IPLDs for.")
+	createIpldsForBlockWitnessesCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	createIpldsForBlockWitnessesCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
+	createIpldsForBlockWitnessesCmd.Flags().StringVar(&fromTime, "from-time", "", "start from the first block at or after this date or RFC 3339 time")
+	createIpldsForBlockWitnessesCmd.Flags().StringVar(&toTime, "to-time", "", "end at the last block at or before this date or RFC 3339 time")
+}
+
+func createIpldsForBlockWitnesses() {
+	ctx := interruptContext()
+
+	// init eth db
+	databaseConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
+	database, err := db.CreateDatabase(databaseConfig)
+	if err != nil {
+		log.Fatal("Error connecting to the ethereum db: ", err)
+	}
+	selectBlockByHash(ctx, database)
+	selectBlocksByTime(ctx, database)
+
+	// init ipfs publishers
+	adder, err := ipfs.InitIPFSNode(ipfsPath)
+	if err != nil {
+		log.Fatal("Error connecting to ipfs: ", err)
+	}
+	defer adder.Close()
+	stateTriePublisher := ipfs.NewStateTriePublisher(eth_state_trie.NewStateTrieDagPutter(adder))
+	storageTriePublisher := ipfs.NewStorageTriePublisher(eth_storage_trie.NewStorageTrieDagPutter(adder))
+	witnessPublisher := ipfs.NewWitnessPublisher(eth_block_witness.NewWitnessDagPutter(adder))
+
+	// execute transformer
+	transformer := transformers.NewEthBlockWitnessTransformer(database, stateTriePublisher, storageTriePublisher, witnessPublisher, missingDataPolicy())
+	err = transformer.Execute(ctx, startingBlockNumber, endingBlockNumber)
+	if interrupted(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error executing transformer: ", err)
+	}
+}
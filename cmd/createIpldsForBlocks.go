@@ -0,0 +1,103 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_transactions"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
+)
+
+// createIpldsForBlocksCmd represents the createIpldsForBlocks command
+var createIpldsForBlocksCmd = &cobra.Command{
+	Use:   "createIpldsForBlocks",
+	Short: "Create IPLDs for the headers and transactions of a range of blocks",
+	Long: `Create IPLDs for the header and transactions of every block in a range,
+optionally including side-chain blocks. For example:
+
+./eth-block-extractor createIpldsForBlocks -s 1234567 -e 1234667 --non-canonical --block-index blocks.ndjson
+
+With --non-canonical, every block stored at each height is published, including
+blocks geth stored and later orphaned. A side-chain block whose body was not
+stored only has its header published.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		createIpldsForBlocks()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(createIpldsForBlocksCmd)
+	createIpldsForBlocksCmd.Flags().Int64VarP(&startingBlockNumber, "starting-block-number", "s", 0, "First block number to create IPLDs for.")
+	createIpldsForBlocksCmd.Flags().Int64VarP(&endingBlockNumber, "ending-block-number", "e", 5900000, "Last block number to create IPLDs for.")
+	createIpldsForBlocksCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	createIpldsForBlocksCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
+	createIpldsForBlocksCmd.Flags().StringVar(&fromTime, "from-time", "", "start from the first block at or after this date or RFC 3339 time")
+	createIpldsForBlocksCmd.Flags().StringVar(&toTime, "to-time", "", "end at the last block at or before this date or RFC 3339 time")
+	createIpldsForBlocksCmd.Flags().BoolVar(&nonCanonical, "non-canonical", false, "also create IPLDs for side-chain blocks stored at each height")
+	createIpldsForBlocksCmd.Flags().StringVar(&blockIndexFile, "block-index", "", "file to append the hash, canonical status and CIDs of each published block to as JSON")
+}
+
+func createIpldsForBlocks() {
+	ctx := interruptContext()
+
+	// init eth db
+	ethDBConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
+	ethDB, err := db.CreateDatabase(ethDBConfig)
+	if err != nil {
+		log.Fatal("Error connecting to ethereum db: ", err)
+	}
+	selectBlockByHash(ctx, ethDB)
+	selectBlocksByTime(ctx, ethDB)
+
+	// init ipfs publishers
+	ipfsNode, err := ipfs.InitIPFSNode(ipfsPath)
+	if err != nil {
+		log.Fatal("Error connecting to IPFS: ", err)
+	}
+	defer ipfsNode.Close()
+	headerPublisher := ipfs.NewHeaderPublisher(eth_block_header.NewBlockHeaderDagPutter(*ipfsNode, rlp.RlpDecoder{}))
+	bodyPublisher := ipfs.NewBodyPublisher(eth_block_transactions.NewBlockTransactionsDagPutter(*ipfsNode))
+
+	var index *transformers.BlockIndex
+	if blockIndexFile != "" {
+		file, err := os.OpenFile(blockIndexFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Error opening block index: ", err)
+		}
+		defer file.Close()
+		index = transformers.NewBlockIndex(file)
+	}
+
+	// execute transformer
+	transformer := transformers.NewEthBlocksTransformer(ethDB, headerPublisher, bodyPublisher, missingDataPolicy(), nonCanonical, index)
+	if blockHash != "" {
+		transformer.WithBlockHash(parseHash("--block-hash", blockHash))
+	}
+	err = transformer.Execute(ctx, startingBlockNumber, endingBlockNumber)
+	if interrupted(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error executing transformer: ", err.Error())
+	}
+}
@@ -15,14 +15,24 @@
 package cmd
 
 import (
-	"log"
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
 
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_contract_code"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_trie"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_tx_trace"
 	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
 )
 
@@ -47,39 +57,216 @@ func init() {
 	createIpldsForStateTrieCmd.Flags().BoolVarP(&computeState, "compute-state", "c", false, "Flag indicating state must be computed (non-archive node).")
 	createIpldsForStateTrieCmd.Flags().Int64VarP(&startingBlockNumber, "starting-block-number", "s", 0, "First block number to create IPLD for.")
 	createIpldsForStateTrieCmd.Flags().Int64VarP(&endingBlockNumber, "ending-block-number", "e", 5900000, "Last block number to create IPLD for.")
+	createIpldsForStateTrieCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	createIpldsForStateTrieCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
+	createIpldsForStateTrieCmd.Flags().StringVar(&fromTime, "from-time", "", "start from the first block at or after this date or RFC 3339 time")
+	createIpldsForStateTrieCmd.Flags().StringVar(&toTime, "to-time", "", "end at the last block at or before this date or RFC 3339 time")
+	createIpldsForStateTrieCmd.Flags().StringVar(&onStateRootMismatch, "on-state-root-mismatch", "stop", "action when computed state root differs from block header: stop or continue")
+	createIpldsForStateTrieCmd.Flags().StringVar(&stateRootReport, "state-root-report", "", "file to append JSON state root mismatch reports to")
+	createIpldsForStateTrieCmd.Flags().StringVar(&stateDiffFile, "state-diff-file", "", "file to append each computed block's state diff to as JSON")
+	createIpldsForStateTrieCmd.Flags().BoolVar(&publishStateDiffs, "publish-state-diffs", false, "publish each computed block's state diff as an IPLD")
+	createIpldsForStateTrieCmd.Flags().StringVar(&traceFile, "trace-file", "", "file to append each computed block's transaction call traces to as JSON (default stdout, unless publishing)")
+	createIpldsForStateTrieCmd.Flags().BoolVar(&publishTraces, "publish-traces", false, "publish each computed transaction's call trace as an IPLD")
+	createIpldsForStateTrieCmd.Flags().StringSliceVar(&addresses, "addresses", nil, "only create IPLDs for these accounts' state, storage and code; may be repeated or comma separated")
+	createIpldsForStateTrieCmd.Flags().IntVarP(&workers, "workers", "w", runtime.NumCPU(), "number of key space partitions of the state trie to read concurrently")
+	createIpldsForStateTrieCmd.Flags().StringVar(&triePrefix, "trie-prefix", "", "only create IPLDs for state trie nodes whose path begins with this hex prefix of up to 4 nibbles, e.g. 0x3")
+	createIpldsForStateTrieCmd.Flags().StringVar(&shard, "shard", "", "only create IPLDs for this shard of the state trie, as <index>/<count>, e.g. 3/16")
+	createIpldsForStateTrieCmd.Flags().StringVar(&shardManifest, "shard-manifest", "", "file to append the CIDs of the trie nodes published for each block to as JSON")
+	createIpldsForStateTrieCmd.Flags().StringVar(&storageIndexFile, "storage-index", "", "file to append the account, path and CID of each published storage trie node to as JSON")
+	createIpldsForStateTrieCmd.Flags().StringVar(&addressFile, "address-file", "", "file listing one address per line to restrict IPLD creation to")
 }
 
 func createIpldsForStateTrie() {
+	ctx := interruptContext()
+
 	if computeState && startingBlockNumber != 0 {
-		log.Println("Computing state trie must begin at genesis block. Ignoring passed starting block number.")
+		log.Warn("Computing state trie must begin at genesis block. Ignoring passed starting block number.")
+	}
+	if !computeState && (traceFile != "" || publishTraces) {
+		log.Warn("Transactions are only traced when computing state. Ignoring passed trace flags.")
 	}
 
+	selectedAddresses := readAddresses()
+	keySpace := keySpaceRange()
+
 	// init eth db
 	databaseConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
+	databaseConfig.KeySpace = keySpace
+	databaseConfig.Workers = workers
 	database, err := db.CreateDatabase(databaseConfig)
 	if err != nil {
 		log.Fatal("Error connecting to the ethereum db: ", err)
 	}
+	selectBlockByHash(ctx, database)
+	selectBlocksByTime(ctx, database)
 
 	// init ipfs publishers
 	adder, err := ipfs.InitIPFSNode(ipfsPath)
 	if err != nil {
 		log.Fatal("Error connecting to ipfs: ", err)
 	}
+	defer adder.Close()
 	stateTrieDagPutter := eth_state_trie.NewStateTrieDagPutter(adder)
-	stateTriePublisher := ipfs.NewIpfsPublisher(stateTrieDagPutter)
+	stateTriePublisher := ipfs.NewStateTriePublisher(stateTrieDagPutter)
 	storageTrieDagPutter := eth_storage_trie.NewStorageTrieDagPutter(adder)
-	storageTriePublisher := ipfs.NewIpfsPublisher(storageTrieDagPutter)
+	storageTriePublisher := ipfs.NewStorageTriePublisher(storageTrieDagPutter)
+	var selection *transformers.AccountSelection
+	if len(selectedAddresses) > 0 {
+		codePublisher := ipfs.NewCodePublisher(eth_contract_code.NewCodeDagPutter(adder))
+		selection = transformers.NewAccountSelection(selectedAddresses, codePublisher)
+	}
+
+	var manifest *transformers.ShardManifest
+	if shardManifest != "" {
+		file, err := os.OpenFile(shardManifest, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Error opening shard manifest: ", err)
+		}
+		defer file.Close()
+		manifest = transformers.NewShardManifest(file, keySpace)
+	}
+
+	var storageIndex *transformers.StorageIndex
+	if storageIndexFile != "" {
+		file, err := os.OpenFile(storageIndexFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Error opening storage index: ", err)
+		}
+		defer file.Close()
+		storageIndex = transformers.NewStorageIndex(file)
+	}
 
 	// init and execute transformer
 	if computeState {
-		transformer := transformers.NewComputeEthStateTrieTransformer(database, stateTriePublisher, storageTriePublisher)
-		err = transformer.Execute(endingBlockNumber)
+		validation, closeReport := stateRootValidation()
+		defer closeReport()
+		var diffs *transformers.StateDiffExporter
+		if stateDiffFile != "" || publishStateDiffs {
+			var closeDiffs func()
+			diffs, closeDiffs = stateDiffExporter(adder)
+			defer closeDiffs()
+		}
+		var traces *transformers.TraceExporter
+		if traceFile != "" || publishTraces {
+			var closeTraces func()
+			traces, closeTraces = traceExporter(adder)
+			defer closeTraces()
+		}
+		transformer := transformers.NewComputeEthStateTrieTransformer(database, stateTriePublisher, storageTriePublisher, missingDataPolicy(), validation, diffs, traces, selection, manifest, storageIndex)
+		err = transformer.Execute(ctx, endingBlockNumber)
 	} else {
-		transformer := transformers.NewEthStateTrieTransformer(database, stateTriePublisher, storageTriePublisher)
-		err = transformer.Execute(startingBlockNumber, endingBlockNumber)
+		transformer := transformers.NewEthStateTrieTransformer(database, stateTriePublisher, storageTriePublisher, missingDataPolicy(), selection, manifest, storageIndex)
+		err = transformer.Execute(ctx, startingBlockNumber, endingBlockNumber)
+	}
+	if interrupted(err) {
+		return
 	}
 	if err != nil {
 		log.Fatal("Error executing transformer: ", err)
 	}
 }
+
+// keySpaceRange returns the part of the state trie selected by --trie-prefix
+// or --shard, or the whole trie
+func keySpaceRange() level.KeySpaceRange {
+	switch {
+	case triePrefix != "" && shard != "":
+		log.Fatal("Only one of --trie-prefix and --shard may be passed")
+	case triePrefix != "":
+		keySpace, err := level.NewPrefixRange(triePrefix)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return keySpace
+	case shard != "":
+		parts := strings.Split(shard, "/")
+		if len(parts) != 2 {
+			log.Fatal("Shard must be passed as <index>/<count>: ", shard)
+		}
+		index, indexErr := strconv.Atoi(parts[0])
+		count, countErr := strconv.Atoi(parts[1])
+		if indexErr != nil || countErr != nil {
+			log.Fatal("Shard must be passed as <index>/<count>: ", shard)
+		}
+		keySpace, err := level.NewShardRange(index, count)
+		if err != nil {
+			log.Fatal(err)
+		}
+		return keySpace
+	}
+	return level.FullKeySpace
+}
+
+// readAddresses collects the accounts passed with --addresses and
+// --address-file; no accounts means the whole state is extracted
+func readAddresses() []common.Address {
+	hexAddresses := addresses
+	if addressFile != "" {
+		file, err := os.Open(addressFile)
+		if err != nil {
+			log.Fatal("Error opening address file: ", err)
+		}
+		defer file.Close()
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" {
+				hexAddresses = append(hexAddresses, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			log.Fatal("Error reading address file: ", err)
+		}
+	}
+	var result []common.Address
+	for _, hexAddress := range hexAddresses {
+		if !common.IsHexAddress(hexAddress) {
+			log.Fatal("Invalid address: ", hexAddress)
+		}
+		result = append(result, common.HexToAddress(hexAddress))
+	}
+	return result
+}
+
+func stateRootValidation() (transformers.StateRootValidation, func()) {
+	var action transformers.StateRootMismatchAction
+	switch onStateRootMismatch {
+	case "stop":
+		action = transformers.StopOnStateRootMismatch
+	case "continue":
+		action = transformers.ContinueOnStateRootMismatch
+	default:
+		log.Fatal("Unknown on-state-root-mismatch action: ", onStateRootMismatch)
+	}
+	if stateRootReport == "" {
+		return transformers.NewStateRootValidation(action, nil), func() {}
+	}
+	report, err := os.OpenFile(stateRootReport, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Fatal("Error opening state root report: ", err)
+	}
+	return transformers.NewStateRootValidation(action, report), func() { report.Close() }
+}
+
+// traceExporter writes traces to --trace-file, and publishes them to adder if
+// --publish-traces is set. Traces are written to stdout if neither is set.
+func traceExporter(adder *ipfs.IPFS) (*transformers.TraceExporter, func()) {
+	var publisher ipfs.TracesPublisher
+	if publishTraces {
+		publisher = ipfs.NewTracesPublisher(eth_tx_trace.NewTraceDagPutter(adder))
+	}
+	var writer io.Writer
+	if publisher == nil {
+		writer = os.Stdout
+	}
+	closeWriter := func() {}
+	if traceFile != "" {
+		file, err := os.OpenFile(traceFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Error opening trace file: ", err)
+		}
+		writer = file
+		closeWriter = func() { file.Close() }
+	}
+	return transformers.NewTraceExporter(writer, publisher), closeWriter
+}
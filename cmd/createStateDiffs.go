@@ -0,0 +1,120 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_diff"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// createStateDiffsCmd represents the createStateDiffs command
+var createStateDiffsCmd = &cobra.Command{
+	Use:   "createStateDiffs",
+	Short: "Export the accounts and storage changed by each block",
+	Long: `Export the accounts and storage slots changed by each block in a range,
+with their old and new values. For example:
+
+./eth-block-extractor createStateDiffs -s 1234567 -e 1234667 --state-diff-file diffs.ndjson
+
+Each block's diff is written as one line of JSON to a file, and is optionally
+published as an IPLD linked to the block header. Diffs are written to stdout
+when neither a file nor publishing is given.
+Diffs are computed from the state at consecutive header roots, so the state
+must be stored for the range (e.g. on an archive node); to export diffs while
+computing state instead, pass the same flags to createIpldsForStateTrie with
+--compute-state.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		createStateDiffs()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(createStateDiffsCmd)
+	createStateDiffsCmd.Flags().Int64VarP(&startingBlockNumber, "starting-block-number", "s", 0, "First block number to export state diff for.")
+	createStateDiffsCmd.Flags().Int64VarP(&endingBlockNumber, "ending-block-number", "e", 5900000, "Last block number to export state diff for.")
+	createStateDiffsCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	createStateDiffsCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
+	createStateDiffsCmd.Flags().StringVar(&fromTime, "from-time", "", "start from the first block at or after this date or RFC 3339 time")
+	createStateDiffsCmd.Flags().StringVar(&toTime, "to-time", "", "end at the last block at or before this date or RFC 3339 time")
+	createStateDiffsCmd.Flags().StringVar(&stateDiffFile, "state-diff-file", "", "file to append state diffs to as JSON (default stdout, unless publishing)")
+	createStateDiffsCmd.Flags().BoolVar(&publishStateDiffs, "publish-state-diffs", false, "publish each state diff as an IPLD")
+}
+
+func createStateDiffs() {
+	ctx := interruptContext()
+
+	// init eth db
+	databaseConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
+	database, err := db.CreateDatabase(databaseConfig)
+	if err != nil {
+		log.Fatal("Error connecting to the ethereum db: ", err)
+	}
+	selectBlockByHash(ctx, database)
+	selectBlocksByTime(ctx, database)
+
+	// init ipfs publisher only if diffs are published
+	var adder *ipfs.IPFS
+	if publishStateDiffs {
+		adder, err = ipfs.InitIPFSNode(ipfsPath)
+		if err != nil {
+			log.Fatal("Error connecting to ipfs: ", err)
+		}
+		defer adder.Close()
+	}
+	exporter, closeDiffs := stateDiffExporter(adder)
+	defer closeDiffs()
+
+	// execute transformer
+	transformer := transformers.NewEthStateDiffTransformer(database, exporter, missingDataPolicy())
+	err = transformer.Execute(ctx, startingBlockNumber, endingBlockNumber)
+	if interrupted(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error executing transformer: ", err)
+	}
+}
+
+// stateDiffExporter writes diffs to --state-diff-file, and publishes them to
+// adder if --publish-state-diffs is set. Diffs are written to stdout if
+// neither is set.
+func stateDiffExporter(adder *ipfs.IPFS) (*transformers.StateDiffExporter, func()) {
+	var publisher ipfs.StateDiffPublisher
+	if publishStateDiffs {
+		publisher = ipfs.NewStateDiffPublisher(eth_state_diff.NewStateDiffDagPutter(adder))
+	}
+	var writer io.Writer
+	if publisher == nil {
+		writer = os.Stdout
+	}
+	closeWriter := func() {}
+	if stateDiffFile != "" {
+		file, err := os.OpenFile(stateDiffFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Error opening state diff file: ", err)
+		}
+		writer = file
+		closeWriter = func() { file.Close() }
+	}
+	return transformers.NewStateDiffExporter(writer, publisher), closeWriter
+}
@@ -0,0 +1,81 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// exportPreimagesCmd represents the exportPreimages command
+var exportPreimagesCmd = &cobra.Command{
+	Use:   "exportPreimages",
+	Short: "Export the preimages of hashed state and storage trie keys",
+	Long: `Export the addresses and storage slot keys behind the hashed keys of
+the state and storage tries. For example:
+
+./eth-block-extractor exportPreimages --shard 3/16 --preimage-file preimages.ndjson
+
+Each preimage is written as one line of JSON with its hash, to stdout unless a
+file is given. Geth only stores preimages when configured to record them (e.g.
+with --cache.preimages).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		exportPreimages()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportPreimagesCmd)
+	exportPreimagesCmd.Flags().StringVar(&preimageFile, "preimage-file", "", "file to append preimages to as JSON (default stdout)")
+	exportPreimagesCmd.Flags().StringVar(&triePrefix, "trie-prefix", "", "only export preimages whose hash begins with this hex prefix of up to 4 nibbles, e.g. 0x3")
+	exportPreimagesCmd.Flags().StringVar(&shard, "shard", "", "only export preimages in this shard of the key space, as <index>/<count>, e.g. 3/16")
+}
+
+func exportPreimages() {
+	ctx := interruptContext()
+
+	// init eth db
+	databaseConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
+	database, err := db.CreateDatabase(databaseConfig)
+	if err != nil {
+		log.Fatal("Error connecting to the ethereum db: ", err)
+	}
+
+	var writer io.Writer = os.Stdout
+	if preimageFile != "" {
+		file, err := os.OpenFile(preimageFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Error opening preimage file: ", err)
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	// execute transformer
+	transformer := transformers.NewPreimageExportTransformer(database, writer)
+	err = transformer.Execute(ctx, keySpaceRange())
+	if interrupted(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error executing transformer: ", err)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_receipts"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_transactions"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/jobs"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
+)
+
+// extractCmd represents the extract command
+var extractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "Create IPLDs for several types of data in a range of blocks in one pass",
+	Long: `Read each block in a range once and persist the selected types of data to IPFS as IPLDs. For example:
+
+./eth-block-extractor extract --types header,txs,receipts,state --starting-block-number 5000000 --ending-block-number 5000123
+
+Types are header, txs, receipts and state. Extracting state requires the state
+to be stored for the range (e.g. on an archive node).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		extract()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(extractCmd)
+	extractCmd.Flags().StringSliceVar(&extractTypes, "types", []string{"header", "txs", "receipts", "state"}, "types of data to extract: header, txs, receipts and/or state")
+	extractCmd.Flags().Int64VarP(&startingBlockNumber, "starting-block-number", "s", 0, "First block number to create IPLD for.")
+	extractCmd.Flags().Int64VarP(&endingBlockNumber, "ending-block-number", "e", 5900000, "Last block number to create IPLD for.")
+	extractCmd.Flags().StringVar(&blockHash, "block-hash", "", "extract the block with this hash instead of by number")
+	extractCmd.Flags().StringVar(&txHash, "tx-hash", "", "extract the block containing this transaction instead of by number")
+	extractCmd.Flags().StringVar(&fromTime, "from-time", "", "start from the first block at or after this date or RFC 3339 time")
+	extractCmd.Flags().StringVar(&toTime, "to-time", "", "end at the last block at or before this date or RFC 3339 time")
+	extractCmd.Flags().StringVar(&blockIndexFile, "block-index", "", "file to append the hash and the header, transaction and receipt CIDs of each block whose header is extracted to as JSON")
+	extractCmd.Flags().DurationVar(&progressInterval, "progress-interval", 30*time.Second, "how often to log progress through the range")
+}
+
+func extract() {
+	ctx := interruptContext()
+
+	err := jobs.ValidateTypes(extractTypes)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(extractTypes) == 0 {
+		log.Fatal("No types to extract")
+	}
+
+	// init eth db
+	ethDBConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
+	ethDB, err := db.CreateDatabase(ethDBConfig)
+	if err != nil {
+		log.Fatal("Error connecting to ethereum db: ", err)
+	}
+	selectBlockByHash(ctx, ethDB)
+	selectBlocksByTime(ctx, ethDB)
+
+	// init ipfs publishers, sharing one node
+	ipfsNode, err := ipfs.InitIPFSNode(ipfsPath)
+	if err != nil {
+		log.Fatal("Error connecting to IPFS: ", err)
+	}
+	defer ipfsNode.Close()
+	publishers := extractPublishers(extractTypes, ipfsNode)
+
+	// execute transformer
+	progress := transformers.NewProgressTracker(startingBlockNumber, endingBlockNumber, progressInterval)
+	transformer := transformers.NewEthExtractTransformer(ethDB, publishers, missingDataPolicy(), progress)
+	if blockIndexFile != "" {
+		file, err := os.OpenFile(blockIndexFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Error opening block index: ", err)
+		}
+		defer file.Close()
+		transformer.WithBlockIndex(transformers.NewBlockIndex(file))
+	}
+	err = transformer.Execute(ctx, startingBlockNumber, endingBlockNumber)
+	if interrupted(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error extracting blocks: ", err)
+	}
+}
+
+// extractPublishers returns publishers to ipfsNode for the passed types
+func extractPublishers(types []string, ipfsNode *ipfs.IPFS) transformers.ExtractPublishers {
+	var publishers transformers.ExtractPublishers
+	for _, extractType := range types {
+		switch extractType {
+		case "header":
+			publishers.Header = ipfs.NewHeaderPublisher(eth_block_header.NewBlockHeaderDagPutter(*ipfsNode, rlp.RlpDecoder{}))
+		case "txs":
+			publishers.Body = ipfs.NewBodyPublisher(eth_block_transactions.NewBlockTransactionsDagPutter(*ipfsNode))
+		case "receipts":
+			publishers.Receipts = ipfs.NewReceiptsPublisher(eth_block_receipts.NewEthBlockReceiptDagPutter(ipfsNode))
+		case "state":
+			publishers.StateTrie = ipfs.NewStateTriePublisher(eth_state_trie.NewStateTrieDagPutter(ipfsNode))
+			publishers.StorageTrie = ipfs.NewStorageTriePublisher(eth_storage_trie.NewStorageTrieDagPutter(ipfsNode))
+		}
+	}
+	return publishers
+}
@@ -0,0 +1,106 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/gateway"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// gatewayCmd represents the gateway command
+var gatewayCmd = &cobra.Command{
+	Use:   "gateway",
+	Short: "Serve published Ethereum IPLDs over HTTP",
+	Long: `Serve a read-only HTTP gateway to the Ethereum IPLDs published to an IPFS
+repo. For example:
+
+./eth-block-extractor gateway --addr localhost:8081 --block-index blocks.ndjson
+
+curl localhost:8081/block/1234567
+curl localhost:8081/header/<cid>
+curl localhost:8081/tx/<hash>
+curl localhost:8081/receipt/<transaction hash>
+curl localhost:8081/state/<state root>/<address>?format=rlp
+
+It also serves eth_getBlockByNumber, eth_getTransactionByHash,
+eth_getTransactionReceipt, eth_getBalance, eth_getStorageAt and eth_getCode
+over JSON-RPC at /rpc:
+
+curl localhost:8081/rpc -d '{"jsonrpc": "2.0", "id": 1, "method": "eth_getBalance", "params": ["<address>", "latest"]}'
+
+Blocks are found by number and receipts by transaction hash in the block
+indexes written by the extract and createIpldsForBlocks commands, which may
+be passed more than once. Responses are decoded JSON, or the raw RLP with
+?format=rlp.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serveGateway()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gatewayCmd)
+	gatewayCmd.Flags().StringVar(&gatewayAddr, "addr", "localhost:8081", "address to serve the gateway on")
+	gatewayCmd.Flags().StringArrayVar(&blockIndexFiles, "block-index", nil, "block index to resolve block numbers and transaction hashes with (repeatable)")
+}
+
+func serveGateway() {
+	ctx := interruptContext()
+
+	lookup := loadBlockIndexes()
+	ipfsNode, err := ipfs.InitIPFSNode(ipfsPath)
+	if err != nil {
+		log.Fatal("Error connecting to IPFS: ", err)
+	}
+	defer ipfsNode.Close()
+
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", gateway.NewRPCHandler(ipfsNode, lookup))
+	mux.Handle("/", gateway.NewGateway(ipfsNode, lookup))
+	server := &http.Server{Addr: gatewayAddr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	log.WithFields(log.Fields{"url": "http://" + gatewayAddr, "rpc": "http://" + gatewayAddr + "/rpc"}).Info("Serving gateway")
+	err = server.ListenAndServe()
+	if err != http.ErrServerClosed {
+		log.Fatal("Error serving gateway: ", err)
+	}
+	interrupted(ctx.Err())
+}
+
+// loadBlockIndexes returns a lookup of the blocks in the --block-index files
+func loadBlockIndexes() *gateway.Lookup {
+	lookup := gateway.NewLookup()
+	for _, path := range blockIndexFiles {
+		file, err := os.Open(path)
+		if err != nil {
+			log.Fatal("Error opening block index: ", err)
+		}
+		err = lookup.Load(file)
+		file.Close()
+		if err != nil {
+			log.Fatalf("Error loading block index %s: %s", path, err)
+		}
+	}
+	return lookup
+}
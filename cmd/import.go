@@ -0,0 +1,102 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/gateway"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Write blocks published to IPFS back into geth chaindata",
+	Long: `Read the headers, transactions and receipts of a range of blocks back out of
+IPFS, and write them into a LevelDB with geth's schema. For example:
+
+./eth-block-extractor import -s 0 -e 1000000 --block-index blocks.ndjson --chaindata /data/geth/chaindata
+
+Blocks are found with the block indexes written by the extract and
+createIpldsForBlocks commands. Each block is written after its parent, so a
+fresh database must be imported from block 0, and a later range continues an
+earlier import. geth starts from the database as if fast syncing, with the
+blocks and receipts but only the genesis block's state.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		importBlocks()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().Int64VarP(&startingBlockNumber, "starting-block-number", "s", 0, "First block number to import.")
+	importCmd.Flags().Int64VarP(&endingBlockNumber, "ending-block-number", "e", 5900000, "Last block number to import.")
+	importCmd.Flags().StringArrayVar(&blockIndexFiles, "block-index", nil, "block index to resolve block numbers with (repeatable)")
+	importCmd.Flags().StringVar(&chaindataPath, "chaindata", "", "LevelDB directory to write the blocks to")
+	importCmd.Flags().StringVar(&genesisFile, "genesis", "", "genesis file of the network, needed unless it is mainnet, Ropsten, Rinkeby or Görli")
+	importCmd.Flags().DurationVar(&progressInterval, "progress-interval", 30*time.Second, "how often to log progress through the range")
+	importCmd.MarkFlagRequired("chaindata")
+}
+
+func importBlocks() {
+	ctx := interruptContext()
+
+	var genesis *core.Genesis
+	if genesisFile != "" {
+		file, err := os.Open(genesisFile)
+		if err != nil {
+			log.Fatal("Error opening genesis file: ", err)
+		}
+		genesis = new(core.Genesis)
+		err = json.NewDecoder(file).Decode(genesis)
+		file.Close()
+		if err != nil {
+			log.Fatal("Error reading genesis file: ", err)
+		}
+	}
+	lookup := loadBlockIndexes()
+
+	ipfsNode, err := ipfs.InitIPFSNode(ipfsPath)
+	if err != nil {
+		log.Fatal("Error connecting to IPFS: ", err)
+	}
+	defer ipfsNode.Close()
+
+	chaindata, err := rawdb.NewLevelDBDatabase(chaindataPath, 128, 1024, "eth-block-extractor")
+	if err != nil {
+		log.Fatal("Error opening chaindata: ", err)
+	}
+	defer chaindata.Close()
+
+	progress := transformers.NewProgressTracker(startingBlockNumber, endingBlockNumber, progressInterval)
+	transformer := transformers.NewEthImportTransformer(gateway.NewPublishedBlocks(ipfsNode, lookup), level.NewChainWriter(chaindata, genesis), progress)
+	err = transformer.Execute(ctx, startingBlockNumber, endingBlockNumber)
+	if interrupted(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error importing blocks: ", err)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// proveCmd represents the prove command
+var proveCmd = &cobra.Command{
+	Use:   "prove",
+	Short: "Prove an account and its storage slots at a block",
+	Long: `Prove an account and, optionally, some of its storage slots against a
+block's state root. For example:
+
+./eth-block-extractor prove -b 1234567 -a 0xde0b295669a9fd93d5f28d9ec85e40f4cb697bae -k 0x0 -k 0x1
+
+The proof is printed as JSON in the format returned by eth_getProof, and its
+nodes are published as state and storage trie IPLDs. The block's state must be
+stored (e.g. on an archive node).`,
+	Run: func(cmd *cobra.Command, args []string) {
+		prove()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(proveCmd)
+	proveCmd.Flags().Int64VarP(&blockNumber, "block-number", "b", 0, "Prove against this block's state root.")
+	proveCmd.Flags().StringVarP(&address, "address", "a", "", "Address of the account to prove.")
+	proveCmd.Flags().StringSliceVarP(&storageKeys, "storage-key", "k", nil, "Storage slot to prove; may be repeated.")
+}
+
+func prove() {
+	ctx := interruptContext()
+
+	if !common.IsHexAddress(address) {
+		log.Fatal("Invalid address: ", address)
+	}
+	var keys []common.Hash
+	for _, key := range storageKeys {
+		keys = append(keys, common.HexToHash(key))
+	}
+
+	// init eth db
+	databaseConfig := db.CreateDatabaseConfig(db.Level, levelDbPath)
+	database, err := db.CreateDatabase(databaseConfig)
+	if err != nil {
+		log.Fatal("Error connecting to the ethereum db: ", err)
+	}
+
+	// init ipfs publishers
+	adder, err := ipfs.InitIPFSNode(ipfsPath)
+	if err != nil {
+		log.Fatal("Error connecting to ipfs: ", err)
+	}
+	defer adder.Close()
+	stateTriePublisher := ipfs.NewStateTriePublisher(eth_state_trie.NewStateTrieDagPutter(adder))
+	storageTriePublisher := ipfs.NewStorageTriePublisher(eth_storage_trie.NewStorageTrieDagPutter(adder))
+
+	// execute transformer
+	transformer := transformers.NewEthProofTransformer(database, stateTriePublisher, storageTriePublisher, missingDataPolicy())
+	proof, err := transformer.Execute(ctx, blockNumber, common.HexToAddress(address), keys)
+	if interrupted(err) {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error executing transformer: ", err)
+	}
+	err = json.NewEncoder(os.Stdout).Encode(proof)
+	if err != nil {
+		log.Fatal("Error writing proof: ", err)
+	}
+}
@@ -15,30 +15,94 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/mitchellh/go-homedir"
+	"github.com/opentracing/opentracing-go"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/vulcanize/vulcanizedb/pkg/config"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
+	"github.com/vulcanize/eth-block-extractor/pkg/tracing"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
 )
 
 var (
+	address             string
+	apiAddr             string
+	addressFile         string
+	addresses           []string
+	blockIndexFile      string
+	blockIndexFiles     []string
+	blockHash           string
 	blockNumber         int64
 	cfgFile             string
+	chaindataPath       string
 	computeState        bool
 	databaseConfig      config.Database
 	endingBlockNumber   int64
+	extractTypes        []string
+	fromTime            string
+	gatewayAddr         string
+	genesisFile         string
 	ipc                 string
 	ipfsPath            string
+	jobsFile            string
 	levelDbPath         string
+	logFormat           string
+	logLevel            string
+	metricsAddr         string
+	nonCanonical        bool
+	onMissingData       string
+	onStateRootMismatch string
+	parallel            int
+	preimageFile        string
+	progressInterval    time.Duration
+	publishStateDiffs   bool
+	publishTraces       bool
+	retryDelay          time.Duration
+	retries             int
+	shard               string
+	shardManifest       string
+	spansCollector      string
+	spansFile           string
 	startingBlockNumber int64
+	stateDiffFile       string
+	stateRootReport     string
+	storageIndexFile    string
+	storageKeys         []string
+	toTime              string
+	traceFile           string
+	txHash              string
+	triePrefix          string
+	workers             int
+
+	// spanRecorders receive the spans traced while a command runs
+	spanRecorders []tracing.Recorder
 )
 
 var rootCmd = &cobra.Command{
-	Use:              "blockWatcher",
-	PersistentPreRun: database,
+	Use: "blockWatcher",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		configureLogging()
+		database(cmd, args)
+		serveMetrics()
+		configureTracing()
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		closeTracing()
+	},
 }
 
 func Execute() {
@@ -60,6 +124,65 @@ func database(cmd *cobra.Command, args []string) {
 	viper.Set("database.config", databaseConfig)
 }
 
+// configureLogging applies --log-level and --log-format. IPLDs are logged one
+// by one at debug level, and summarized per block at info level.
+func configureLogging() {
+	level, err := log.ParseLevel(logLevel)
+	if err != nil {
+		log.Fatal("Unknown log level: ", logLevel)
+	}
+	log.SetLevel(level)
+	switch logFormat {
+	case "logfmt":
+		log.SetFormatter(&log.TextFormatter{DisableColors: true, FullTimestamp: true})
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{})
+	default:
+		log.Fatal("Unknown log format: ", logFormat)
+	}
+}
+
+// serveMetrics exposes Prometheus metrics at /metrics on --metrics-addr, if set
+func serveMetrics() {
+	if metricsAddr == "" {
+		return
+	}
+	err := metrics.Serve(metricsAddr)
+	if err != nil {
+		log.Fatal("Error serving metrics: ", err)
+	}
+	log.WithField("url", "http://"+metricsAddr+"/metrics").Info("Serving metrics")
+}
+
+// configureTracing traces each block's extraction to --spans-file and
+// --spans-collector, if either is set
+func configureTracing() {
+	if spansFile != "" {
+		file, err := os.OpenFile(spansFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Fatal("Error opening spans file: ", err)
+		}
+		spanRecorders = append(spanRecorders, tracing.NewFileRecorder(file))
+	}
+	if spansCollector != "" {
+		client := &http.Client{Timeout: 10 * time.Second}
+		spanRecorders = append(spanRecorders, tracing.NewCollectorRecorder(spansCollector, client, 100))
+	}
+	if len(spanRecorders) > 0 {
+		opentracing.SetGlobalTracer(tracing.NewTracer("eth-block-extractor", spanRecorders...))
+	}
+}
+
+// closeTracing writes the spans not yet written when a command finishes
+func closeTracing() {
+	for _, recorder := range spanRecorders {
+		err := recorder.Close()
+		if err != nil {
+			log.WithError(err).Warn("Error writing spans")
+		}
+	}
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
@@ -70,6 +193,14 @@ func init() {
 	rootCmd.PersistentFlags().String("client-ipcPath", "", "location of geth.ipc file")
 	rootCmd.PersistentFlags().String("client-ipfsPath", "", "location of ipfs directory")
 	rootCmd.PersistentFlags().String("client-levelDbPath", "", "location of levelDb chaindata")
+	rootCmd.PersistentFlags().StringVar(&onMissingData, "on-missing-data", "fail", "action when block data is missing, pruned or corrupt: fail, skip or retry")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", 3, "number of retries when on-missing-data is retry")
+	rootCmd.PersistentFlags().DurationVar(&retryDelay, "retry-delay", 10*time.Second, "delay between retries when on-missing-data is retry")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "info", "log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "logfmt", "log format: logfmt or json")
+	rootCmd.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "", "address to serve Prometheus metrics on, e.g. localhost:9100 (disabled if empty)")
+	rootCmd.PersistentFlags().StringVar(&spansFile, "spans-file", "", "file to append each block's trace spans to as Zipkin JSON, one span per line")
+	rootCmd.PersistentFlags().StringVar(&spansCollector, "spans-collector", "", "Zipkin v2 spans endpoint to post trace spans to, e.g. http://localhost:9411/api/v2/spans")
 
 	viper.BindPFlag("database.name", rootCmd.PersistentFlags().Lookup("database-name"))
 	viper.BindPFlag("database.port", rootCmd.PersistentFlags().Lookup("database-port"))
@@ -80,6 +211,131 @@ func init() {
 
 }
 
+// interruptContext returns a context cancelled by SIGINT or SIGTERM, which
+// stops the transformers after the block in flight. A second signal exits
+// immediately.
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		log.WithField("signal", sig.String()).Warn("Finishing the block in flight. Signal again to exit immediately.")
+		cancel()
+		<-signals
+		log.Warn("Exiting without finishing the block in flight")
+		os.Exit(1)
+	}()
+	return ctx
+}
+
+// interrupted reports whether a transformer stopped because of an interrupt,
+// in which case the command returns so its outputs are flushed and closed.
+// The cancellation may be wrapped, e.g. in a transformers.ExecuteError.
+func interrupted(err error) bool {
+	for err != context.Canceled {
+		wrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = wrapper.Unwrap()
+	}
+	log.Info("Stopped after interrupt")
+	return true
+}
+
+func missingDataPolicy() transformers.MissingDataPolicy {
+	switch onMissingData {
+	case "fail":
+		return transformers.NewMissingDataPolicy(transformers.FailOnMissingData, 0, 0)
+	case "skip":
+		return transformers.NewMissingDataPolicy(transformers.SkipMissingData, 0, 0)
+	case "retry":
+		return transformers.NewMissingDataPolicy(transformers.RetryMissingData, retries, retryDelay)
+	default:
+		log.Fatal("Unknown on-missing-data action: ", onMissingData)
+	}
+	return transformers.DefaultMissingDataPolicy
+}
+
+// selectBlockByHash replaces the block number and range flags with the block
+// passed by --block-hash, or containing the transaction passed by --tx-hash
+func selectBlockByHash(ctx context.Context, database db.Database) {
+	var n int64
+	switch {
+	case blockHash != "" && txHash != "":
+		log.Fatal("Only one of --block-hash and --tx-hash may be passed")
+	case blockHash != "":
+		var canonical bool
+		var err error
+		n, canonical, err = database.GetBlockNumberByBlockHash(ctx, parseHash("--block-hash", blockHash))
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !canonical && !nonCanonical {
+			log.Fatal("Block ", blockHash, " is not canonical; pass it to createIpldsForBlocks with --non-canonical to extract it")
+		}
+	case txHash != "":
+		var err error
+		n, err = database.GetBlockNumberByTransactionHash(ctx, parseHash("--tx-hash", txHash))
+		if err != nil {
+			log.Fatal(err)
+		}
+	default:
+		return
+	}
+	blockNumber, startingBlockNumber, endingBlockNumber = n, n, n
+}
+
+// selectBlocksByTime replaces the block range flags with the blocks whose
+// timestamps are between --from-time and --to-time, if either was passed
+func selectBlocksByTime(ctx context.Context, database db.Database) {
+	if fromTime == "" && toTime == "" {
+		return
+	}
+	if blockHash != "" || txHash != "" {
+		log.Fatal("--from-time and --to-time cannot be passed with --block-hash or --tx-hash")
+	}
+	from, to := time.Unix(0, 0), time.Now()
+	if fromTime != "" {
+		from = parseTime("--from-time", fromTime, false)
+	}
+	if toTime != "" {
+		to = parseTime("--to-time", toTime, true)
+	}
+	var err error
+	startingBlockNumber, endingBlockNumber, err = transformers.BlockRangeForTimes(ctx, database, from, to)
+	if err != nil {
+		log.Fatal("Error finding blocks in time range: ", err)
+	}
+	log.WithFields(log.Fields{"start": startingBlockNumber, "end": endingBlockNumber}).Info("Selected blocks in time range")
+}
+
+// parseTime accepts an RFC 3339 time or a date, which ends the day if it is
+// the end of a range
+func parseTime(flag, value string, endOfRange bool) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t
+	}
+	t, err = time.Parse("2006-01-02", value)
+	if err != nil {
+		log.Fatal("Invalid ", flag, ", expected a date or RFC 3339 time: ", value)
+	}
+	if endOfRange {
+		return t.Add(24*time.Hour - time.Second)
+	}
+	return t
+}
+
+func parseHash(flag, value string) common.Hash {
+	raw, err := hexutil.Decode(value)
+	if err != nil || len(raw) != common.HashLength {
+		log.Fatal("Invalid ", flag, ": ", value)
+	}
+	return common.BytesToHash(raw)
+}
+
 func initConfig() {
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
@@ -0,0 +1,140 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/jobs"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the extraction jobs listed in a job spec file",
+	Long: `Run the extraction jobs listed in a TOML, YAML or JSON job spec file, in
+sequence or in parallel, and print a summary of their results. For example:
+
+./eth-block-extractor run --jobs jobs.toml
+
+Each job extracts some types of data for a range of blocks, as the extract
+command does. Jobs without a levelDbPath or ipfsPath use those of the config file.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runJobs()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().StringVar(&jobsFile, "jobs", "", "job spec file to run")
+	runCmd.Flags().DurationVar(&progressInterval, "progress-interval", 30*time.Second, "how often to log each job's progress through its range")
+}
+
+func runJobs() {
+	if jobsFile == "" {
+		log.Fatal("No job spec file passed with --jobs")
+	}
+	spec, err := jobs.LoadSpec(jobsFile, jobs.Job{LevelDbPath: levelDbPath, IpfsPath: ipfsPath})
+	if err != nil {
+		log.Fatal("Error loading job spec: ", err)
+	}
+
+	ctx := interruptContext()
+	sources := newJobSources(spec.Workers)
+	defer sources.close()
+	results := jobs.Run(spec.Jobs, spec.Parallel, func(job jobs.Job) error {
+		database, ipfsNode, err := sources.get(job)
+		if err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{"job": job.Name, "start": job.StartingBlockNumber, "end": job.EndingBlockNumber, "types": strings.Join(job.Types, ",")}).Info("Starting job")
+		progress := transformers.NewProgressTracker(job.StartingBlockNumber, job.EndingBlockNumber, progressInterval)
+		transformer := transformers.NewEthExtractTransformer(database, extractPublishers(job.Types, ipfsNode), missingDataPolicy(), progress)
+		return transformer.Execute(ctx, job.StartingBlockNumber, job.EndingBlockNumber)
+	})
+	failed := jobs.WriteSummary(os.Stdout, results)
+	if interrupted(ctx.Err()) {
+		return
+	}
+	if failed > 0 {
+		sources.close()
+		os.Exit(1)
+	}
+}
+
+// jobSources opens each chaindata directory and IPFS repo once, since both
+// are locked while open, and shares them between the jobs that use them
+type jobSources struct {
+	sync.Mutex
+	databases map[string]db.Database
+	ipfsNodes map[string]*ipfs.IPFS
+	workers   int
+}
+
+func newJobSources(workers int) *jobSources {
+	return &jobSources{
+		databases: make(map[string]db.Database),
+		ipfsNodes: make(map[string]*ipfs.IPFS),
+		workers:   workers,
+	}
+}
+
+// close flushes and releases the IPFS repos opened for the jobs
+func (s *jobSources) close() {
+	s.Lock()
+	defer s.Unlock()
+	for path, ipfsNode := range s.ipfsNodes {
+		if err := ipfsNode.Close(); err != nil {
+			log.WithField("ipfs", path).WithError(err).Error("Error closing IPFS")
+		}
+		delete(s.ipfsNodes, path)
+	}
+}
+
+func (s *jobSources) get(job jobs.Job) (db.Database, *ipfs.IPFS, error) {
+	s.Lock()
+	defer s.Unlock()
+	database, ok := s.databases[job.LevelDbPath]
+	if !ok {
+		databaseConfig := db.CreateDatabaseConfig(db.Level, job.LevelDbPath)
+		databaseConfig.Workers = s.workers
+		var err error
+		database, err = db.CreateDatabase(databaseConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error connecting to ethereum db %s: %s", job.LevelDbPath, err)
+		}
+		s.databases[job.LevelDbPath] = database
+	}
+	ipfsNode, ok := s.ipfsNodes[job.IpfsPath]
+	if !ok {
+		var err error
+		ipfsNode, err = ipfs.InitIPFSNode(job.IpfsPath)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error connecting to IPFS %s: %s", job.IpfsPath, err)
+		}
+		s.ipfsNodes[job.IpfsPath] = ipfsNode
+	}
+	return database, ipfsNode, nil
+}
@@ -0,0 +1,98 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/jobs"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Run extraction jobs submitted over HTTP",
+	Long: `Serve an HTTP API for submitting extraction jobs, querying their status and
+progress, and cancelling them. For example:
+
+./eth-block-extractor serve --addr localhost:8080 --parallel 2
+
+curl -X POST localhost:8080/jobs -d '{"types": ["header"], "startingBlockNumber": 1, "endingBlockNumber": 1000}'
+curl localhost:8080/jobs/1
+curl -X DELETE localhost:8080/jobs/1
+
+Jobs are described as in the run command's job spec files, but always use the
+levelDbPath and ipfsPath of the config file: jobs setting either are rejected.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		serve()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().StringVar(&apiAddr, "addr", "localhost:8080", "address to serve the job API on")
+	serveCmd.Flags().IntVar(&parallel, "parallel", 1, "number of jobs to run at once")
+	serveCmd.Flags().IntVarP(&workers, "workers", "w", runtime.NumCPU(), "number of key space partitions of each state trie to read concurrently")
+	serveCmd.Flags().DurationVar(&progressInterval, "progress-interval", 30*time.Second, "how often to log each job's progress through its range")
+}
+
+func serve() {
+	ctx := interruptContext()
+
+	sources := newJobSources(workers)
+	defer sources.close()
+	manager := jobs.NewManager(ctx, parallel, jobs.Job{LevelDbPath: levelDbPath, IpfsPath: ipfsPath}, func(ctx context.Context, job jobs.Job, report func(blockNumber, done, total int64)) error {
+		err := executeJob(ctx, sources, job, report)
+		if err != nil && err != context.Canceled {
+			log.WithField("job", job.Name).WithError(err).Error("Job failed")
+		}
+		return err
+	})
+
+	// stop accepting requests on interrupt, once running jobs finish their blocks in flight
+	server := &http.Server{Addr: apiAddr, Handler: jobs.NewHandler(manager)}
+	go func() {
+		<-ctx.Done()
+		manager.Wait()
+		server.Shutdown(context.Background())
+	}()
+	log.WithField("url", "http://"+apiAddr+"/jobs").Info("Serving job API")
+	err := server.ListenAndServe()
+	if err != http.ErrServerClosed {
+		log.Fatal("Error serving job API: ", err)
+	}
+	interrupted(ctx.Err())
+}
+
+// executeJob extracts the job's types of data for its range, reporting each
+// block done
+func executeJob(ctx context.Context, sources *jobSources, job jobs.Job, report func(blockNumber, done, total int64)) error {
+	database, ipfsNode, err := sources.get(job)
+	if err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"job": job.Name, "start": job.StartingBlockNumber, "end": job.EndingBlockNumber, "types": strings.Join(job.Types, ",")}).Info("Starting job")
+	progress := transformers.NewProgressTracker(job.StartingBlockNumber, job.EndingBlockNumber, progressInterval).OnBlockDone(report)
+	transformer := transformers.NewEthExtractTransformer(database, extractPublishers(job.Types, ipfsNode), missingDataPolicy(), progress)
+	return transformer.Execute(ctx, job.StartingBlockNumber, job.EndingBlockNumber)
+}
@@ -1,5 +1,7 @@
 package db
 
+import "github.com/vulcanize/eth-block-extractor/pkg/db/level"
+
 type DatabaseType int
 
 const (
@@ -9,11 +11,17 @@ const (
 type DatabaseConfig struct {
 	Type DatabaseType
 	Path string
+	// KeySpace is the part of the state trie read for full state extraction
+	KeySpace level.KeySpaceRange
+	// Workers is the number of goroutines reading the state trie concurrently
+	Workers int
 }
 
 func CreateDatabaseConfig(dbType DatabaseType, path string) DatabaseConfig {
 	return DatabaseConfig{
-		Type: dbType,
-		Path: path,
+		Type:     dbType,
+		Path:     path,
+		KeySpace: level.FullKeySpace,
+		Workers:  1,
 	}
 }
@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
@@ -28,13 +29,22 @@ func (re ReadError) Error() string {
 }
 
 type Database interface {
-	ComputeBlockStateTrie(currentBlock *types.Block, parentBlock *types.Block) (common.Hash, error)
-	GetBlockByBlockNumber(blockNumber int64) *types.Block
-	GetBlockBodyByBlockNumber(blockNumber int64) *types.Body
-	GetBlockHeaderByBlockNumber(blockNumber int64) *types.Header
-	GetRawBlockHeaderByBlockNumber(blockNumber int64) []byte
-	GetBlockReceipts(blockNumber int64) types.Receipts
-	GetStateAndStorageTrieNodes(root common.Hash) (stateTrieNodes, storageTrieNodes [][]byte, err error)
+	ComputeBlockStateTrie(ctx context.Context, block *types.Block, parentRoot common.Hash) (common.Hash, error)
+	ComputeBlockStateTrieWithTraces(ctx context.Context, block *types.Block, parentRoot common.Hash) (common.Hash, []level.TransactionTrace, error)
+	ComputeBlockWitness(ctx context.Context, block *types.Block, parentRoot common.Hash) (level.Witness, error)
+	DiffStateTries(ctx context.Context, fromRoot, toRoot common.Hash) ([]level.AccountDiff, error)
+	ExportPreimages(ctx context.Context, keySpace level.KeySpaceRange, visit func(preimage level.Preimage) error) error
+	GetAllBlocksByBlockNumber(ctx context.Context, blockNumber int64) ([]level.StoredBlock, error)
+	GetBlockByBlockNumber(ctx context.Context, blockNumber int64) (*types.Block, error)
+	GetBlockBodyByBlockNumber(ctx context.Context, blockNumber int64) (*types.Body, error)
+	GetBlockNumberByBlockHash(ctx context.Context, hash common.Hash) (blockNumber int64, canonical bool, err error)
+	GetBlockNumberByTransactionHash(ctx context.Context, txHash common.Hash) (int64, error)
+	GetBlockHeaderByBlockNumber(ctx context.Context, blockNumber int64) (*types.Header, error)
+	GetRawBlockHeaderByBlockNumber(ctx context.Context, blockNumber int64) ([]byte, error)
+	GetBlockReceipts(ctx context.Context, blockNumber int64) (types.Receipts, error)
+	GetAccountTrieNodes(ctx context.Context, root common.Hash, addresses []common.Address) (stateTrieNodes [][]byte, storageTrieNodes []level.StorageTrieNode, codes [][]byte, err error)
+	GetStateAndStorageTrieNodes(ctx context.Context, root common.Hash) (stateTrieNodes [][]byte, storageTrieNodes []level.StorageTrieNode, err error)
+	ProveAccount(ctx context.Context, root common.Hash, address common.Address, storageKeys []common.Hash) (level.AccountProof, error)
 }
 
 func CreateDatabase(config DatabaseConfig) (Database, error) {
@@ -45,23 +55,26 @@ func CreateDatabase(config DatabaseConfig) (Database, error) {
 			return nil, ReadError{msg: "Failed to connect to LevelDB", err: err}
 		}
 		stateDatabase := state.NewDatabase(levelDBConnection)
-		stateTrieReader := createStateTrieReader(stateDatabase)
+		preimageResolver := level.NewPreimageResolver(stateDatabase, rawdb.NewPreimages(levelDBConnection))
+		stateTrieReader := createStateTrieReader(stateDatabase, preimageResolver, config.KeySpace, config.Workers)
 		levelDBReader := rawdb.NewAccessorsChain(levelDBConnection)
 		stateComputer, err := createStateComputer(levelDBConnection, stateDatabase)
 		if err != nil {
 			return nil, err
 		}
-		levelDB := level.NewLevelDatabase(levelDBReader, stateComputer, stateTrieReader)
+		stateDiffer := level.NewStateDiffer(stateDatabase, rlp.RlpDecoder{}, preimageResolver)
+		stateProver := level.NewStateProver(stateDatabase, rlp.RlpDecoder{})
+		levelDB := level.NewLevelDatabase(levelDBReader, preimageResolver, stateComputer, stateDiffer, stateProver, stateTrieReader)
 		return levelDB, nil
 	default:
 		return nil, ReadError{msg: "Unknown database not implemented", err: ErrNoSuchDb}
 	}
 }
 
-func createStateTrieReader(stateDatabase state.GethStateDatabase) level.IStateTrieReader {
+func createStateTrieReader(stateDatabase state.GethStateDatabase, preimageResolver level.IPreimageResolver, keySpace level.KeySpaceRange, workers int) level.IStateTrieReader {
 	decoder := rlp.RlpDecoder{}
-	storageTrieReader := level.NewStorageTrieReader(stateDatabase, decoder)
-	return level.NewStateTrieReader(stateDatabase, storageTrieReader)
+	storageTrieReader := level.NewStorageTrieReader(stateDatabase, decoder, preimageResolver)
+	return level.NewStateTrieReader(stateDatabase, storageTrieReader, decoder, preimageResolver, keySpace, workers)
 }
 
 func createStateComputer(databaseConnection ethdb.Database, stateDatabase state.GethStateDatabase) (level.IStateComputer, error) {
@@ -71,7 +84,7 @@ func createStateComputer(databaseConnection ethdb.Database, stateDatabase state.
 	}
 	processor := core.NewStateProcessor(*blockChain)
 	trieFactory := state.NewStateDBFactory()
-	validator := core.NewBlockValidator(*blockChain)
+	validator := core.NewBlockValidator()
 	computer := level.NewStateComputer(blockChain, stateDatabase, processor, trieFactory, validator)
 	return computer, nil
 }
@@ -0,0 +1,93 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package level
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// defaultGeneses are the genesis blocks of the networks geth knows
+var defaultGeneses = []func() *core.Genesis{
+	core.DefaultGenesisBlock,
+	core.DefaultTestnetGenesisBlock,
+	core.DefaultRinkebyGenesisBlock,
+	core.DefaultGoerliGenesisBlock,
+}
+
+// ChainWriter writes blocks and their receipts into geth chaindata with the
+// rawdb accessors. As on a node fast syncing, each block advances the head
+// header and head fast block, while the head block stays at the genesis
+// block, the only one whose state is written.
+type ChainWriter struct {
+	ethDB   ethdb.Database
+	genesis *core.Genesis
+}
+
+// NewChainWriter returns a writer to ethDB. Block 0 is written from genesis,
+// whose allocations are needed for its state, or if genesis is nil from the
+// default genesis of the network the block belongs to.
+func NewChainWriter(ethDB ethdb.Database, genesis *core.Genesis) *ChainWriter {
+	return &ChainWriter{ethDB: ethDB, genesis: genesis}
+}
+
+func (w *ChainWriter) WriteBlock(block *types.Block, receipts types.Receipts) error {
+	number := block.NumberU64()
+	if number == 0 {
+		return w.writeGenesis(block)
+	}
+	parentTd := rawdb.ReadTd(w.ethDB, block.ParentHash(), number-1)
+	if parentTd == nil {
+		return NewBlockDataError(block.Number().Int64(), BlockData, ErrMissingParent)
+	}
+	batch := w.ethDB.NewBatch()
+	rawdb.WriteTd(batch, block.Hash(), number, new(big.Int).Add(parentTd, block.Difficulty()))
+	rawdb.WriteBlock(batch, block)
+	rawdb.WriteReceipts(batch, block.Hash(), number, receipts)
+	rawdb.WriteCanonicalHash(batch, block.Hash(), number)
+	rawdb.WriteTxLookupEntries(batch, block)
+	rawdb.WriteHeadHeaderHash(batch, block.Hash())
+	rawdb.WriteHeadFastBlockHash(batch, block.Hash())
+	return batch.Write()
+}
+
+func (w *ChainWriter) writeGenesis(block *types.Block) error {
+	genesis := w.genesis
+	if genesis == nil {
+		for _, defaultGenesis := range defaultGeneses {
+			if candidate := defaultGenesis(); candidate.ToBlock(nil).Hash() == block.Hash() {
+				genesis = candidate
+				break
+			}
+		}
+		if genesis == nil {
+			return fmt.Errorf("Unknown genesis block %s: pass the network's genesis file", block.Hash().Hex())
+		}
+	}
+	if hash := genesis.ToBlock(nil).Hash(); hash != block.Hash() {
+		return fmt.Errorf("Genesis file's block %s does not match genesis block %s", hash.Hex(), block.Hash().Hex())
+	}
+	committed, err := genesis.Commit(w.ethDB)
+	if err != nil {
+		return err
+	}
+	rawdb.WriteHeadFastBlockHash(w.ethDB, committed.Hash())
+	return nil
+}
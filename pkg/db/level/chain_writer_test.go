@@ -0,0 +1,118 @@
+package level_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+var _ = Describe("Chain writer", func() {
+	var (
+		ethDB        ethdb.Database
+		genesis      *core.Genesis
+		genesisBlock *types.Block
+		block        *types.Block
+		receipts     types.Receipts
+		writer       *level.ChainWriter
+	)
+
+	BeforeEach(func() {
+		ethDB = rawdb.NewMemoryDatabase()
+		genesis = &core.Genesis{
+			Config:     params.TestChainConfig,
+			Difficulty: big.NewInt(131072),
+			GasLimit:   8000000,
+			Alloc:      core.GenesisAlloc{common.HexToAddress("0xabc"): {Balance: big.NewInt(100)}},
+		}
+		genesisBlock = genesis.ToBlock(nil)
+
+		key, err := crypto.GenerateKey()
+		Expect(err).NotTo(HaveOccurred())
+		transaction, err := types.SignTx(types.NewTransaction(0, common.HexToAddress("0xdef"), big.NewInt(1), 21000, big.NewInt(1), nil), types.HomesteadSigner{}, key)
+		Expect(err).NotTo(HaveOccurred())
+		receipt := types.NewReceipt(nil, false, 21000)
+		receipt.TxHash = transaction.Hash()
+		receipt.GasUsed = 21000
+		receipts = types.Receipts{receipt}
+		header := &types.Header{ParentHash: genesisBlock.Hash(), Number: big.NewInt(1), Difficulty: big.NewInt(131072), GasLimit: 8000000, Time: big.NewInt(10)}
+		block = types.NewBlock(header, types.Transactions{transaction}, nil, receipts)
+
+		writer = level.NewChainWriter(ethDB, genesis)
+	})
+
+	It("writes the genesis block with its state", func() {
+		err := writer.WriteBlock(genesisBlock, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rawdb.ReadCanonicalHash(ethDB, 0)).To(Equal(genesisBlock.Hash()))
+		Expect(rawdb.ReadChainConfig(ethDB, genesisBlock.Hash())).To(Equal(params.TestChainConfig))
+		Expect(rawdb.ReadHeadFastBlockHash(ethDB)).To(Equal(genesisBlock.Hash()))
+	})
+
+	It("rejects a genesis block that does not match the genesis file", func() {
+		genesis.GasLimit = 1
+
+		err := writer.WriteBlock(genesisBlock, nil)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("does not match"))
+	})
+
+	It("rejects unknown genesis blocks without a genesis file", func() {
+		writer = level.NewChainWriter(ethDB, nil)
+
+		err := writer.WriteBlock(genesisBlock, nil)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unknown genesis block"))
+	})
+
+	It("writes blocks with their receipts, total difficulty and transaction lookups", func() {
+		Expect(writer.WriteBlock(genesisBlock, nil)).To(Succeed())
+
+		err := writer.WriteBlock(block, receipts)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(rawdb.ReadCanonicalHash(ethDB, 1)).To(Equal(block.Hash()))
+		Expect(rawdb.ReadTd(ethDB, block.Hash(), 1)).To(Equal(big.NewInt(262144)))
+		Expect(rawdb.ReadReceipts(ethDB, block.Hash(), 1)).To(HaveLen(1))
+		Expect(rawdb.ReadTxLookupEntry(ethDB, block.Transactions()[0].Hash())).To(Equal(block.Hash()))
+		Expect(rawdb.ReadHeadHeaderHash(ethDB)).To(Equal(block.Hash()))
+		Expect(rawdb.ReadHeadFastBlockHash(ethDB)).To(Equal(block.Hash()))
+		Expect(rawdb.ReadHeadBlockHash(ethDB)).To(Equal(genesisBlock.Hash()))
+	})
+
+	It("returns an error for blocks whose parent was not written", func() {
+		err := writer.WriteBlock(block, receipts)
+
+		Expect(err).To(HaveOccurred())
+		Expect(level.IsBlockDataError(err)).To(BeTrue())
+		Expect(err.(*level.BlockDataError).Err).To(Equal(level.ErrMissingParent))
+	})
+
+	It("leaves a database a node starts from as if fast syncing", func() {
+		Expect(writer.WriteBlock(genesisBlock, nil)).To(Succeed())
+		Expect(writer.WriteBlock(block, receipts)).To(Succeed())
+
+		blockChain, err := core.NewBlockChain(ethDB, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{}, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		defer blockChain.Stop()
+		Expect(blockChain.CurrentBlock().Hash()).To(Equal(genesisBlock.Hash()))
+		Expect(blockChain.CurrentFastBlock().Hash()).To(Equal(block.Hash()))
+		Expect(blockChain.CurrentHeader().Hash()).To(Equal(block.Hash()))
+		Expect(blockChain.GetReceiptsByHash(block.Hash())).To(HaveLen(1))
+	})
+})
@@ -1,59 +1,247 @@
 package level
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/opentracing/opentracing-go"
 	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/rawdb"
 )
 
 type Database struct {
-	accessorsChain  rawdb.IAccessorsChain
-	stateComputer   IStateComputer
-	stateTrieReader IStateTrieReader
+	accessorsChain   rawdb.IAccessorsChain
+	preimageResolver IPreimageResolver
+	stateComputer    IStateComputer
+	stateDiffer      IStateDiffer
+	stateProver      IStateProver
+	stateTrieReader  IStateTrieReader
 }
 
-func NewLevelDatabase(accessorsChain rawdb.IAccessorsChain, stateComputer IStateComputer, stateTrieReader IStateTrieReader) *Database {
+func NewLevelDatabase(accessorsChain rawdb.IAccessorsChain, preimageResolver IPreimageResolver, stateComputer IStateComputer, stateDiffer IStateDiffer, stateProver IStateProver, stateTrieReader IStateTrieReader) *Database {
 	return &Database{
-		accessorsChain:  accessorsChain,
-		stateComputer:   stateComputer,
-		stateTrieReader: stateTrieReader,
+		accessorsChain:   accessorsChain,
+		preimageResolver: preimageResolver,
+		stateComputer:    stateComputer,
+		stateDiffer:      stateDiffer,
+		stateProver:      stateProver,
+		stateTrieReader:  stateTrieReader,
 	}
 }
 
-func (db Database) ComputeBlockStateTrie(currentBlock *types.Block, parentBlock *types.Block) (common.Hash, error) {
-	return db.stateComputer.ComputeBlockStateTrie(currentBlock, parentBlock)
+func (db Database) ComputeBlockStateTrie(ctx context.Context, block *types.Block, parentRoot common.Hash) (common.Hash, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "state.compute")
+	defer span.Finish()
+	if err := ctx.Err(); err != nil {
+		return common.Hash{}, err
+	}
+	return db.stateComputer.ComputeBlockStateTrie(block, parentRoot)
 }
 
-func (db Database) GetBlockBodyByBlockNumber(blockNumber int64) *types.Body {
-	n := uint64(blockNumber)
-	h := db.accessorsChain.GetCanonicalHash(n)
-	return db.accessorsChain.GetBody(h, n)
+func (db Database) ComputeBlockStateTrieWithTraces(ctx context.Context, block *types.Block, parentRoot common.Hash) (common.Hash, []TransactionTrace, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "state.compute")
+	defer span.Finish()
+	if err := ctx.Err(); err != nil {
+		return common.Hash{}, nil, err
+	}
+	return db.stateComputer.ComputeBlockStateTrieWithTraces(block, parentRoot)
 }
 
-func (db Database) GetBlockByBlockNumber(blockNumber int64) *types.Block {
-	n := uint64(blockNumber)
-	h := db.accessorsChain.GetCanonicalHash(n)
-	return db.accessorsChain.GetBlock(h, n)
+func (db Database) ComputeBlockWitness(ctx context.Context, block *types.Block, parentRoot common.Hash) (Witness, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "state.witness")
+	defer span.Finish()
+	if err := ctx.Err(); err != nil {
+		return Witness{}, err
+	}
+	return db.stateComputer.ComputeBlockWitness(block, parentRoot)
 }
 
-func (db Database) GetBlockHeaderByBlockNumber(blockNumber int64) *types.Header {
-	n := uint64(blockNumber)
-	h := db.accessorsChain.GetCanonicalHash(n)
-	return db.accessorsChain.GetHeader(h, n)
+func (db Database) DiffStateTries(ctx context.Context, fromRoot, toRoot common.Hash) ([]AccountDiff, error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "trie.diff")
+	defer span.Finish()
+	return db.stateDiffer.DiffStateTries(ctx, fromRoot, toRoot)
+}
+
+func (db Database) ExportPreimages(ctx context.Context, keySpace KeySpaceRange, visit func(preimage Preimage) error) error {
+	return db.preimageResolver.ExportPreimages(ctx, keySpace, visit)
+}
+
+func (db Database) ProveAccount(ctx context.Context, root common.Hash, address common.Address, storageKeys []common.Hash) (AccountProof, error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "trie.prove")
+	defer span.Finish()
+	if err := ctx.Err(); err != nil {
+		return AccountProof{}, err
+	}
+	return db.stateProver.ProveAccount(root, address, storageKeys)
 }
 
-func (db Database) GetRawBlockHeaderByBlockNumber(blockNumber int64) []byte {
+func (db Database) GetBlockBodyByBlockNumber(ctx context.Context, blockNumber int64) (*types.Body, error) {
+	defer startReadSpan(ctx, "GetBlockBodyByBlockNumber", blockNumber).Finish()
+	h, n, err := db.getCanonicalHash(ctx, blockNumber, BlockBody)
+	if err != nil {
+		return nil, err
+	}
+	body := db.accessorsChain.GetBody(h, n)
+	if body == nil {
+		return nil, missingDataError(blockNumber, BlockBody, db.accessorsChain.GetBodyRLP(h, n))
+	}
+	return body, nil
+}
+
+// GetAllBlocksByBlockNumber returns every block stored at blockNumber, including
+// side-chain blocks that were stored and later orphaned. A side-chain block's
+// body may not have been stored, in which case it is nil.
+func (db Database) GetAllBlocksByBlockNumber(ctx context.Context, blockNumber int64) ([]StoredBlock, error) {
+	defer startReadSpan(ctx, "GetAllBlocksByBlockNumber", blockNumber).Finish()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	n := uint64(blockNumber)
-	h := db.accessorsChain.GetCanonicalHash(n)
-	return db.accessorsChain.GetHeaderRLP(h, n)
+	hashes, err := db.accessorsChain.GetAllHashes(n)
+	if err != nil {
+		return nil, err
+	}
+	if len(hashes) == 0 {
+		return nil, NewBlockDataError(blockNumber, BlockHeader, ErrNotFound)
+	}
+	canonicalHash := db.accessorsChain.GetCanonicalHash(n)
+	blocks := make([]StoredBlock, 0, len(hashes))
+	for _, h := range hashes {
+		canonical := h == canonicalHash
+		header := db.accessorsChain.GetHeaderRLP(h, n)
+		if len(header) == 0 {
+			return nil, NewBlockDataError(blockNumber, BlockHeader, ErrPruned)
+		}
+		body := db.accessorsChain.GetBody(h, n)
+		if body == nil && canonical {
+			return nil, missingDataError(blockNumber, BlockBody, db.accessorsChain.GetBodyRLP(h, n))
+		}
+		blocks = append(blocks, StoredBlock{Hash: h, Canonical: canonical, Header: header, Body: body})
+	}
+	return blocks, nil
+}
+
+// GetBlockNumberByBlockHash returns the number of the stored block with hash,
+// and whether it is on the canonical chain
+func (db Database) GetBlockNumberByBlockHash(ctx context.Context, hash common.Hash) (int64, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, false, err
+	}
+	n := db.accessorsChain.GetHeaderNumber(hash)
+	if n == nil {
+		return 0, false, NewHashLookupError(BlockHash, hash, ErrUnknownHash)
+	}
+	return int64(*n), db.accessorsChain.GetCanonicalHash(*n) == hash, nil
+}
+
+// GetBlockNumberByTransactionHash returns the number of the canonical block
+// containing the transaction, from geth's transaction lookup index
+func (db Database) GetBlockNumberByTransactionHash(ctx context.Context, txHash common.Hash) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	blockHash := db.accessorsChain.GetTxLookupEntry(txHash)
+	if blockHash == (common.Hash{}) {
+		return 0, NewHashLookupError(TransactionHash, txHash, ErrUnknownHash)
+	}
+	n := db.accessorsChain.GetHeaderNumber(blockHash)
+	if n == nil {
+		return 0, NewHashLookupError(TransactionHash, txHash, ErrUnknownHash)
+	}
+	return int64(*n), nil
+}
+
+func (db Database) GetBlockByBlockNumber(ctx context.Context, blockNumber int64) (*types.Block, error) {
+	defer startReadSpan(ctx, "GetBlockByBlockNumber", blockNumber).Finish()
+	h, n, err := db.getCanonicalHash(ctx, blockNumber, BlockData)
+	if err != nil {
+		return nil, err
+	}
+	block := db.accessorsChain.GetBlock(h, n)
+	if block == nil {
+		// a block is assembled from its header and body, so report whichever is missing
+		if db.accessorsChain.GetHeader(h, n) == nil {
+			return nil, missingDataError(blockNumber, BlockHeader, db.accessorsChain.GetHeaderRLP(h, n))
+		}
+		return nil, missingDataError(blockNumber, BlockBody, db.accessorsChain.GetBodyRLP(h, n))
+	}
+	return block, nil
 }
 
-func (db Database) GetBlockReceipts(blockNumber int64) types.Receipts {
+func (db Database) GetBlockHeaderByBlockNumber(ctx context.Context, blockNumber int64) (*types.Header, error) {
+	defer startReadSpan(ctx, "GetBlockHeaderByBlockNumber", blockNumber).Finish()
+	h, n, err := db.getCanonicalHash(ctx, blockNumber, BlockHeader)
+	if err != nil {
+		return nil, err
+	}
+	header := db.accessorsChain.GetHeader(h, n)
+	if header == nil {
+		return nil, missingDataError(blockNumber, BlockHeader, db.accessorsChain.GetHeaderRLP(h, n))
+	}
+	return header, nil
+}
+
+func (db Database) GetRawBlockHeaderByBlockNumber(ctx context.Context, blockNumber int64) ([]byte, error) {
+	defer startReadSpan(ctx, "GetRawBlockHeaderByBlockNumber", blockNumber).Finish()
+	h, n, err := db.getCanonicalHash(ctx, blockNumber, BlockHeader)
+	if err != nil {
+		return nil, err
+	}
+	raw := db.accessorsChain.GetHeaderRLP(h, n)
+	if len(raw) == 0 {
+		return nil, NewBlockDataError(blockNumber, BlockHeader, ErrPruned)
+	}
+	return raw, nil
+}
+
+func (db Database) GetBlockReceipts(ctx context.Context, blockNumber int64) (types.Receipts, error) {
+	defer startReadSpan(ctx, "GetBlockReceipts", blockNumber).Finish()
+	h, n, err := db.getCanonicalHash(ctx, blockNumber, BlockReceipts)
+	if err != nil {
+		return nil, err
+	}
+	receipts := db.accessorsChain.GetBlockReceipts(h, n)
+	if receipts == nil {
+		return nil, missingDataError(blockNumber, BlockReceipts, db.accessorsChain.GetReceiptsRLP(h, n))
+	}
+	return receipts, nil
+}
+
+func (db Database) GetAccountTrieNodes(ctx context.Context, root common.Hash, addresses []common.Address) (stateTrieNodes [][]byte, storageTrieNodes []StorageTrieNode, codes [][]byte, err error) {
+	return db.stateTrieReader.GetAccountTrieNodes(ctx, root, addresses)
+}
+
+func (db Database) GetStateAndStorageTrieNodes(ctx context.Context, root common.Hash) (stateTrieNodes [][]byte, storageTrieNodes []StorageTrieNode, err error) {
+	return db.stateTrieReader.GetStateAndStorageTrieNodes(ctx, root)
+}
+
+// getCanonicalHash returns the hash of the canonical block at blockNumber,
+// unless ctx is already done
+func (db Database) getCanonicalHash(ctx context.Context, blockNumber int64, data string) (common.Hash, uint64, error) {
 	n := uint64(blockNumber)
+	if err := ctx.Err(); err != nil {
+		return common.Hash{}, n, err
+	}
 	h := db.accessorsChain.GetCanonicalHash(n)
-	return db.accessorsChain.GetBlockReceipts(h, n)
+	if h == (common.Hash{}) {
+		return h, n, NewBlockDataError(blockNumber, data, ErrNotFound)
+	}
+	return h, n, nil
 }
 
-func (db Database) GetStateAndStorageTrieNodes(root common.Hash) (stateTrieNodes, storageTrieNodes [][]byte, err error) {
-	return db.stateTrieReader.GetStateAndStorageTrieNodes(root)
+// startReadSpan starts a span for reading a block's data, under the span of
+// the block in ctx
+func startReadSpan(ctx context.Context, method string, blockNumber int64) opentracing.Span {
+	span, _ := opentracing.StartSpanFromContext(ctx, "leveldb."+method, opentracing.Tag{Key: "block.number", Value: blockNumber})
+	return span
+}
+
+// missingDataError distinguishes data that was never stored from data that is
+// stored but failed to decode, based on whether raw bytes exist for the key.
+func missingDataError(blockNumber int64, data string, raw rlp.RawValue) error {
+	if len(raw) > 0 {
+		return NewBlockDataError(blockNumber, data, ErrCorrupt)
+	}
+	return NewBlockDataError(blockNumber, data, ErrPruned)
 }
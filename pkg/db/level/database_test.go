@@ -1,6 +1,8 @@
 package level_test
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -16,35 +18,286 @@ var _ = Describe("Database", func() {
 	Describe("Computing state trie nodes", func() {
 		It("invokes state computer to build historical state", func() {
 			mockStateComputer := level_wrapper.NewMockStateComputer()
-			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), mockStateComputer, level_wrapper.NewMockStateTrieReader())
-			currentBlock := &types.Block{}
-			parentBlock := &types.Block{}
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), mockStateComputer, level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+			block := &types.Block{}
 
-			_, err := db.ComputeBlockStateTrie(currentBlock, parentBlock)
+			_, err := db.ComputeBlockStateTrie(context.Background(), block, test_helpers.FakeHash)
 
 			Expect(err).NotTo(HaveOccurred())
-			mockStateComputer.AssertComputeBlockStateTrieCalledWith(currentBlock, parentBlock)
+			mockStateComputer.AssertComputeBlockStateTrieCalledWith(block, test_helpers.FakeHash)
+		})
+
+		It("returns the context's error without computing if it is done", func() {
+			mockStateComputer := level_wrapper.NewMockStateComputer()
+			mockStateComputer.SetComputeBlockStateTrieReturnErr(test_helpers.FakeError)
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), mockStateComputer, level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := db.ComputeBlockStateTrie(ctx, &types.Block{}, common.Hash{})
+
+			Expect(err).To(MatchError(context.Canceled))
 		})
 
 		It("returns err if state computer returns err", func() {
 			mockStateComputer := level_wrapper.NewMockStateComputer()
 			mockStateComputer.SetComputeBlockStateTrieReturnErr(test_helpers.FakeError)
-			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), mockStateComputer, level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), mockStateComputer, level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 
-			_, err := db.ComputeBlockStateTrie(&types.Block{}, &types.Block{})
+			_, err := db.ComputeBlockStateTrie(context.Background(), &types.Block{}, common.Hash{})
 
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(test_helpers.FakeError))
 		})
 	})
 
+	Describe("Computing block state tries with traces", func() {
+		It("invokes state computer to trace the block", func() {
+			mockStateComputer := level_wrapper.NewMockStateComputer()
+			fakeTraces := []level.TransactionTrace{{TxHash: test_helpers.FakeHash}}
+			mockStateComputer.SetComputeBlockStateTrieWithTracesReturnTraces(fakeTraces)
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), mockStateComputer, level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+			block := &types.Block{}
+
+			_, traces, err := db.ComputeBlockStateTrieWithTraces(context.Background(), block, test_helpers.FakeHash)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(traces).To(Equal(fakeTraces))
+			mockStateComputer.AssertComputeBlockStateTrieWithTracesCalledWith(block, test_helpers.FakeHash)
+		})
+	})
+
+	Describe("Computing block witnesses", func() {
+		It("invokes state computer to record witness", func() {
+			mockStateComputer := level_wrapper.NewMockStateComputer()
+			fakeWitness := level.Witness{StateTrieNodes: test_helpers.FakeTrieNodes}
+			mockStateComputer.SetComputeBlockWitnessReturnWitness(fakeWitness)
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), mockStateComputer, level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+			block := &types.Block{}
+
+			witness, err := db.ComputeBlockWitness(context.Background(), block, test_helpers.FakeHash)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(witness).To(Equal(fakeWitness))
+			mockStateComputer.AssertComputeBlockWitnessCalledWith(block, test_helpers.FakeHash)
+		})
+	})
+
+	Describe("Proving accounts", func() {
+		It("invokes state prover with the passed root, address and keys", func() {
+			mockStateProver := level_wrapper.NewMockStateProver()
+			fakeProof := level.AccountProof{Address: common.HexToAddress("0xabc")}
+			mockStateProver.SetReturnProof(fakeProof)
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), mockStateProver, level_wrapper.NewMockStateTrieReader())
+			storageKeys := []common.Hash{common.HexToHash("0x1")}
+
+			proof, err := db.ProveAccount(context.Background(), test_helpers.FakeHash, common.HexToAddress("0xabc"), storageKeys)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proof).To(Equal(fakeProof))
+			mockStateProver.AssertProveAccountCalledWith(test_helpers.FakeHash, common.HexToAddress("0xabc"), storageKeys)
+		})
+
+		It("returns err if state prover returns err", func() {
+			mockStateProver := level_wrapper.NewMockStateProver()
+			mockStateProver.SetReturnErr(test_helpers.FakeError)
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), mockStateProver, level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.ProveAccount(context.Background(), test_helpers.FakeHash, common.Address{}, nil)
+
+			Expect(err).To(MatchError(test_helpers.FakeError))
+		})
+	})
+
+	Describe("Reading selected accounts' trie nodes", func() {
+		It("invokes state trie reader with the passed root and addresses", func() {
+			mockStateTrieReader := level_wrapper.NewMockStateTrieReader()
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), mockStateTrieReader)
+			addresses := []common.Address{common.HexToAddress("0xabc")}
+
+			_, _, _, err := db.GetAccountTrieNodes(context.Background(), test_helpers.FakeHash, addresses)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockStateTrieReader.AssertGetAccountTrieNodesCalledWith(test_helpers.FakeHash, addresses)
+		})
+	})
+
+	Describe("Exporting preimages", func() {
+		It("invokes preimage resolver with the passed key space", func() {
+			mockPreimageResolver := level_wrapper.NewMockPreimageResolver()
+			fakePreimages := []level.Preimage{{Hash: test_helpers.FakeHash, Preimage: []byte{1, 2, 3}}}
+			mockPreimageResolver.SetReturnPreimages(fakePreimages)
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), mockPreimageResolver, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+			keySpace := level.KeySpaceRange{Start: 0x100, End: 0x200}
+			var preimages []level.Preimage
+
+			err := db.ExportPreimages(context.Background(), keySpace, func(preimage level.Preimage) error {
+				preimages = append(preimages, preimage)
+				return nil
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(preimages).To(Equal(fakePreimages))
+			mockPreimageResolver.AssertExportPreimagesCalledWith(keySpace)
+		})
+	})
+
+	Describe("Diffing state tries", func() {
+		It("invokes state differ with the passed roots", func() {
+			mockStateDiffer := level_wrapper.NewMockStateDiffer()
+			fakeDiffs := []level.AccountDiff{{AddressHash: test_helpers.FakeHash}}
+			mockStateDiffer.SetReturnDiffs(fakeDiffs)
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), mockStateDiffer, level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+			fromRoot := common.HexToHash("0x123")
+
+			diffs, err := db.DiffStateTries(context.Background(), fromRoot, test_helpers.FakeHash)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diffs).To(Equal(fakeDiffs))
+			mockStateDiffer.AssertDiffStateTriesCalledWith(fromRoot, test_helpers.FakeHash)
+		})
+	})
+
+	Describe("Getting every block stored at a height", func() {
+		var (
+			sideHash = common.HexToHash("0x456")
+			header   = []byte{1, 2, 3}
+		)
+
+		It("returns canonical and side-chain blocks with whether each is canonical", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetAllHashesReturnHashes([]common.Hash{sideHash, test_helpers.FakeHash})
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			mockAccessorsChain.SetGetHeaderRLPReturnBytes(header)
+			body := &types.Body{}
+			mockAccessorsChain.SetGetBodyReturnBody(body)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+			num := int64(123456)
+
+			blocks, err := db.GetAllBlocksByBlockNumber(context.Background(), num)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockAccessorsChain.AssertGetAllHashesCalledWith(uint64(num))
+			Expect(blocks).To(Equal([]level.StoredBlock{
+				{Hash: sideHash, Canonical: false, Header: header, Body: body},
+				{Hash: test_helpers.FakeHash, Canonical: true, Header: header, Body: body},
+			}))
+		})
+
+		It("returns side-chain blocks whose bodies were not stored", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetAllHashesReturnHashes([]common.Hash{sideHash})
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			mockAccessorsChain.SetGetHeaderRLPReturnBytes(header)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			blocks, err := db.GetAllBlocksByBlockNumber(context.Background(), 123456)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blocks).To(Equal([]level.StoredBlock{{Hash: sideHash, Canonical: false, Header: header}}))
+		})
+
+		It("returns error if a canonical block's body is missing", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetAllHashesReturnHashes([]common.Hash{test_helpers.FakeHash})
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			mockAccessorsChain.SetGetHeaderRLPReturnBytes(header)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.GetAllBlocksByBlockNumber(context.Background(), 123456)
+
+			Expect(level.IsPruned(err)).To(BeTrue())
+		})
+
+		It("returns error if the stored hashes cannot be read", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetAllHashesReturnHashes([]common.Hash{sideHash})
+			mockAccessorsChain.SetGetAllHashesReturnErr(test_helpers.FakeError)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.GetAllBlocksByBlockNumber(context.Background(), 123456)
+
+			Expect(err).To(MatchError(test_helpers.FakeError))
+		})
+
+		It("returns not found error if no blocks are stored at the height", func() {
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.GetAllBlocksByBlockNumber(context.Background(), 123456)
+
+			Expect(level.IsNotFound(err)).To(BeTrue())
+		})
+	})
+
+	Describe("Looking up block numbers by hash", func() {
+		It("returns the number of a canonical block by its hash", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetHeaderNumberReturnNumber(123)
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			blockNumber, canonical, err := db.GetBlockNumberByBlockHash(context.Background(), test_helpers.FakeHash)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blockNumber).To(Equal(int64(123)))
+			Expect(canonical).To(BeTrue())
+			mockAccessorsChain.AssertGetHeaderNumberCalledWith(test_helpers.FakeHash)
+			mockAccessorsChain.AssertGetCanonicalHashCalledWith(123)
+		})
+
+		It("reports whether a block is on a side chain", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetHeaderNumberReturnNumber(123)
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(common.HexToHash("0x456"))
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			blockNumber, canonical, err := db.GetBlockNumberByBlockHash(context.Background(), test_helpers.FakeHash)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blockNumber).To(Equal(int64(123)))
+			Expect(canonical).To(BeFalse())
+		})
+
+		It("returns error if no block is stored with the hash", func() {
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, _, err := db.GetBlockNumberByBlockHash(context.Background(), test_helpers.FakeHash)
+
+			Expect(err).To(MatchError(level.NewHashLookupError(level.BlockHash, test_helpers.FakeHash, level.ErrUnknownHash)))
+		})
+
+		It("returns the number of the block containing a transaction", func() {
+			txHash := common.HexToHash("0x789")
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetTxLookupEntryReturnHash(test_helpers.FakeHash)
+			mockAccessorsChain.SetGetHeaderNumberReturnNumber(123)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			blockNumber, err := db.GetBlockNumberByTransactionHash(context.Background(), txHash)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(blockNumber).To(Equal(int64(123)))
+			mockAccessorsChain.AssertGetTxLookupEntryCalledWith(txHash)
+			mockAccessorsChain.AssertGetHeaderNumberCalledWith(test_helpers.FakeHash)
+		})
+
+		It("returns error if the transaction is not indexed", func() {
+			txHash := common.HexToHash("0x789")
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.GetBlockNumberByTransactionHash(context.Background(), txHash)
+
+			Expect(err).To(MatchError(level.NewHashLookupError(level.TransactionHash, txHash, level.ErrUnknownHash)))
+		})
+	})
+
 	Describe("Getting block body data", func() {
 		It("invokes the chain accessor to query for block hash by block number", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetBlockBodyByBlockNumber(num)
+			db.GetBlockBodyByBlockNumber(context.Background(), num)
 
 			mockAccessorsChain.AssertGetCanonicalHashCalledWith(uint64(num))
 		})
@@ -52,22 +305,33 @@ var _ = Describe("Database", func() {
 		It("invokes the chain accessor to query for block body data", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
 			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetBlockBodyByBlockNumber(num)
+			db.GetBlockBodyByBlockNumber(context.Background(), num)
 
 			mockAccessorsChain.AssertGetBodyRLPCalledWith(test_helpers.FakeHash, uint64(num))
 		})
+		It("returns the context's error without reading if it is done", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_, err := db.GetBlockBodyByBlockNumber(ctx, 123456)
+
+			Expect(err).To(MatchError(context.Canceled))
+		})
 	})
 
 	Describe("Getting block", func() {
 		It("invokes the chain accessor to query for block hash by block number", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetBlockByBlockNumber(num)
+			db.GetBlockByBlockNumber(context.Background(), num)
 
 			mockAccessorsChain.AssertGetCanonicalHashCalledWith(uint64(num))
 		})
@@ -75,10 +339,10 @@ var _ = Describe("Database", func() {
 		It("invokes the chain accessor to query for block", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
 			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetBlockByBlockNumber(num)
+			db.GetBlockByBlockNumber(context.Background(), num)
 
 			mockAccessorsChain.AssertGetBlockCalledWith(test_helpers.FakeHash, uint64(num))
 		})
@@ -87,10 +351,10 @@ var _ = Describe("Database", func() {
 	Describe("Getting block header", func() {
 		It("invokes the chain accessor to query for block hash by block number", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetBlockHeaderByBlockNumber(num)
+			db.GetBlockHeaderByBlockNumber(context.Background(), num)
 
 			mockAccessorsChain.AssertGetCanonicalHashCalledWith(uint64(num))
 		})
@@ -98,10 +362,10 @@ var _ = Describe("Database", func() {
 		It("invokes the chain accessor to query for block header", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
 			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetBlockHeaderByBlockNumber(num)
+			db.GetBlockHeaderByBlockNumber(context.Background(), num)
 
 			mockAccessorsChain.AssertGetHeaderCalledWith(test_helpers.FakeHash, uint64(num))
 		})
@@ -110,10 +374,10 @@ var _ = Describe("Database", func() {
 	Describe("Getting raw block header data", func() {
 		It("invokes the chain accessor to query for block hash by block number", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetRawBlockHeaderByBlockNumber(num)
+			db.GetRawBlockHeaderByBlockNumber(context.Background(), num)
 
 			mockAccessorsChain.AssertGetCanonicalHashCalledWith(uint64(num))
 		})
@@ -121,10 +385,10 @@ var _ = Describe("Database", func() {
 		It("invokes the chain accessor to query for block header data", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
 			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetRawBlockHeaderByBlockNumber(num)
+			db.GetRawBlockHeaderByBlockNumber(context.Background(), num)
 
 			mockAccessorsChain.AssertGetHeaderRLPCalledWith(test_helpers.FakeHash, uint64(num))
 		})
@@ -133,10 +397,10 @@ var _ = Describe("Database", func() {
 	Describe("Getting block receipts", func() {
 		It("invokes the chain accessor to query for block hash by block number", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetBlockReceipts(num)
+			db.GetBlockReceipts(context.Background(), num)
 
 			mockAccessorsChain.AssertGetCanonicalHashCalledWith(uint64(num))
 		})
@@ -144,10 +408,10 @@ var _ = Describe("Database", func() {
 		It("invokes the chain accessor to query for block receipts", func() {
 			mockAccessorsChain := rawdb.NewMockAccessorsChain()
 			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
-			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateTrieReader())
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
 			num := int64(123456)
 
-			db.GetBlockReceipts(num)
+			db.GetBlockReceipts(context.Background(), num)
 
 			mockAccessorsChain.AssertGetBlockReceiptsCalledWith(test_helpers.FakeHash, uint64(num))
 		})
@@ -156,13 +420,70 @@ var _ = Describe("Database", func() {
 	Describe("Getting state trie nodes", func() {
 		It("invokes the chain accessor to query for state trie data", func() {
 			mockStateTrieReader := level_wrapper.NewMockStateTrieReader()
-			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockStateComputer(), mockStateTrieReader)
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), mockStateTrieReader)
 			root := common.HexToHash("abcde")
 
-			_, _, err := db.GetStateAndStorageTrieNodes(root)
+			_, _, err := db.GetStateAndStorageTrieNodes(context.Background(), root)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockStateTrieReader.AssertGetStateAndStorageTrieNodesCalledWith(root)
 		})
 	})
+
+	Describe("Reporting missing data", func() {
+		It("returns not found error if there is no canonical block at height", func() {
+			db := level.NewLevelDatabase(rawdb.NewMockAccessorsChain(), level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.GetBlockHeaderByBlockNumber(context.Background(), 123456)
+
+			Expect(err).To(HaveOccurred())
+			Expect(level.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("returns pruned error if canonical block data is not stored", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.GetBlockReceipts(context.Background(), 123456)
+
+			Expect(err).To(HaveOccurred())
+			Expect(level.IsPruned(err)).To(BeTrue())
+		})
+
+		It("returns corrupt error if stored block data cannot be decoded", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			mockAccessorsChain.SetGetBodyRLPReturnBytes([]byte{1, 2, 3})
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.GetBlockBodyByBlockNumber(context.Background(), 123456)
+
+			Expect(err).To(HaveOccurred())
+			Expect(level.IsCorrupt(err)).To(BeTrue())
+		})
+
+		It("reports the missing header when a block cannot be assembled", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			_, err := db.GetBlockByBlockNumber(context.Background(), 123456)
+
+			Expect(err).To(MatchError(level.NewBlockDataError(123456, level.BlockHeader, level.ErrPruned)))
+		})
+
+		It("returns block data when it is stored", func() {
+			mockAccessorsChain := rawdb.NewMockAccessorsChain()
+			mockAccessorsChain.SetGetCanonicalHashReturnHash(test_helpers.FakeHash)
+			fakeReceipts := types.Receipts{}
+			mockAccessorsChain.SetGetBlockReceiptsReturnReceipts(fakeReceipts)
+			db := level.NewLevelDatabase(mockAccessorsChain, level_wrapper.NewMockPreimageResolver(), level_wrapper.NewMockStateComputer(), level_wrapper.NewMockStateDiffer(), level_wrapper.NewMockStateProver(), level_wrapper.NewMockStateTrieReader())
+
+			receipts, err := db.GetBlockReceipts(context.Background(), 123456)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(receipts).To(Equal(fakeReceipts))
+		})
+	})
 })
@@ -0,0 +1,105 @@
+package level
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	BlockData     = "block"
+	BlockBody     = "block body"
+	BlockHeader   = "block header"
+	BlockReceipts = "block receipts"
+)
+
+const (
+	BlockHash       = "block hash"
+	TransactionHash = "transaction hash"
+)
+
+var (
+	// ErrNotFound indicates there is no canonical block at the requested height,
+	// e.g. because the node has not synced that far.
+	ErrNotFound = errors.New("no canonical block at height")
+	// ErrPruned indicates the block is canonical but the requested data is not
+	// stored, e.g. on a pruned or partially synced node.
+	ErrPruned = errors.New("data missing for canonical block")
+	// ErrCorrupt indicates the requested data is stored but cannot be decoded.
+	ErrCorrupt = errors.New("stored data cannot be decoded")
+	// ErrUnknownHash indicates no block is stored with the hash, or no
+	// canonical block's transactions include it.
+	ErrUnknownHash = errors.New("hash not found")
+	// ErrMissingParent indicates a block's parent, whose total difficulty it
+	// adds to, has not been written to the database.
+	ErrMissingParent = errors.New("parent block not written")
+)
+
+type BlockDataError struct {
+	BlockNumber int64
+	Data        string
+	Err         error
+}
+
+func NewBlockDataError(blockNumber int64, data string, err error) *BlockDataError {
+	return &BlockDataError{BlockNumber: blockNumber, Data: data, Err: err}
+}
+
+func (bde BlockDataError) Error() string {
+	return fmt.Sprintf("error reading %s for block %d: %s", bde.Data, bde.BlockNumber, bde.Err.Error())
+}
+
+// HashLookupError reports a block or transaction hash that could not be
+// resolved to a block number
+type HashLookupError struct {
+	Hash common.Hash
+	Kind string
+	Err  error
+}
+
+func NewHashLookupError(kind string, hash common.Hash, err error) *HashLookupError {
+	return &HashLookupError{Hash: hash, Kind: kind, Err: err}
+}
+
+func (hle HashLookupError) Error() string {
+	return fmt.Sprintf("error looking up %s %s: %s", hle.Kind, hle.Hash.Hex(), hle.Err.Error())
+}
+
+func IsNotFound(err error) bool {
+	return hasCause(err, ErrNotFound)
+}
+
+func IsPruned(err error) bool {
+	return hasCause(err, ErrPruned)
+}
+
+func IsCorrupt(err error) bool {
+	return hasCause(err, ErrCorrupt)
+}
+
+func IsBlockDataError(err error) bool {
+	_, ok := findBlockDataError(err)
+	return ok
+}
+
+func hasCause(err error, cause error) bool {
+	blockDataErr, ok := findBlockDataError(err)
+	return ok && blockDataErr.Err == cause
+}
+
+// findBlockDataError returns the BlockDataError that err is or wraps, following
+// errors with an Unwrap method
+func findBlockDataError(err error) (*BlockDataError, bool) {
+	for err != nil {
+		if blockDataErr, ok := err.(*BlockDataError); ok {
+			return blockDataErr, true
+		}
+		wrapper, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return nil, false
+		}
+		err = wrapper.Unwrap()
+	}
+	return nil, false
+}
@@ -0,0 +1,125 @@
+package level
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/trie"
+)
+
+// keySpaceNibbles is the number of leading path nibbles the key space is
+// partitioned by
+const keySpaceNibbles = 4
+
+const keySpaceSize = 1 << (4 * keySpaceNibbles)
+
+// KeySpaceRange is a range of a trie's key space. A node belongs to the range
+// if the first four nibbles of its path, padded with zeros, are at least Start
+// and less than End, so adjacent ranges hold disjoint sets of nodes that
+// together make up the whole trie.
+type KeySpaceRange struct {
+	Start uint32
+	End   uint32
+}
+
+var FullKeySpace = KeySpaceRange{Start: 0, End: keySpaceSize}
+
+// NewPrefixRange returns the range of nodes whose paths begin with prefix, a
+// hex string of up to four nibbles such as 0x3
+func NewPrefixRange(prefix string) (KeySpaceRange, error) {
+	nibbles := strings.TrimPrefix(prefix, "0x")
+	if len(nibbles) == 0 || len(nibbles) > keySpaceNibbles {
+		return KeySpaceRange{}, fmt.Errorf("trie prefix must be 1 to %d nibbles: %s", keySpaceNibbles, prefix)
+	}
+	value, err := strconv.ParseUint(nibbles, 16, 32)
+	if err != nil {
+		return KeySpaceRange{}, fmt.Errorf("invalid trie prefix %s: %s", prefix, err)
+	}
+	shift := uint(4 * (keySpaceNibbles - len(nibbles)))
+	return KeySpaceRange{Start: uint32(value) << shift, End: uint32(value+1) << shift}, nil
+}
+
+// NewShardRange returns the index'th of count equal ranges of the key space
+func NewShardRange(index, count int) (KeySpaceRange, error) {
+	if count < 1 || count > keySpaceSize {
+		return KeySpaceRange{}, fmt.Errorf("shard count must be between 1 and %d: %d", keySpaceSize, count)
+	}
+	if index < 0 || index >= count {
+		return KeySpaceRange{}, fmt.Errorf("shard index must be between 0 and %d: %d", count-1, index)
+	}
+	return FullKeySpace.split(count)[index], nil
+}
+
+func (r KeySpaceRange) String() string {
+	return fmt.Sprintf("0x%04x-0x%04x", r.Start, r.End)
+}
+
+// split divides the range into at most parts adjacent ranges of equal size
+func (r KeySpaceRange) split(parts int) []KeySpaceRange {
+	size := int(r.End - r.Start)
+	if parts > size {
+		parts = size
+	}
+	if parts < 1 {
+		parts = 1
+	}
+	ranges := make([]KeySpaceRange, parts)
+	for i := range ranges {
+		ranges[i] = KeySpaceRange{
+			Start: r.Start + uint32(i*size/parts),
+			End:   r.Start + uint32((i+1)*size/parts),
+		}
+	}
+	return ranges
+}
+
+// walk visits the nodes of t in r in iteration order. Subtrees entirely before
+// the range are skipped, and since iteration order is also key space order
+// the walk stops at the first node after it, or when ctx is done. A node
+// missing from the database stops the walk with the iterator's error.
+func (r KeySpaceRange) walk(ctx context.Context, t state.GethTrie, visit func(iterator trie.GethTrieNodeIterator) error) error {
+	iterator := t.NodeIterator(nil)
+	descend := true
+	for iterator.Next(descend) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		lowest, highest := keySpacePosition(iterator.Path())
+		descend = highest >= r.Start
+		if !descend {
+			continue
+		}
+		if lowest >= r.End {
+			break
+		}
+		// the node is above the range, and belongs to an earlier one
+		if lowest < r.Start {
+			continue
+		}
+		err := visit(iterator)
+		if err != nil {
+			return err
+		}
+	}
+	return iterator.Error()
+}
+
+// keySpacePosition returns the position of the node at path in the key space,
+// and the highest position of any node beneath it
+func keySpacePosition(path []byte) (lowest, highest uint32) {
+	for i := 0; i < keySpaceNibbles; i++ {
+		lowest <<= 4
+		highest <<= 4
+		// past the end of the path, or at a leaf's terminator
+		if i >= len(path) || path[i] > 0x0f {
+			highest |= 0x0f
+			continue
+		}
+		lowest |= uint32(path[i])
+		highest |= uint32(path[i])
+	}
+	return lowest, highest
+}
@@ -0,0 +1,54 @@
+package level_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+var _ = Describe("Key space ranges", func() {
+	It("covers the nodes under a trie path prefix", func() {
+		keySpace, err := level.NewPrefixRange("0x3")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keySpace).To(Equal(level.KeySpaceRange{Start: 0x3000, End: 0x4000}))
+	})
+
+	It("accepts prefixes of up to four nibbles", func() {
+		keySpace, err := level.NewPrefixRange("3afe")
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(keySpace).To(Equal(level.KeySpaceRange{Start: 0x3afe, End: 0x3aff}))
+	})
+
+	It("rejects empty, long or non-hex prefixes", func() {
+		for _, prefix := range []string{"", "0x", "0x12345", "0xg"} {
+			_, err := level.NewPrefixRange(prefix)
+
+			Expect(err).To(HaveOccurred())
+		}
+	})
+
+	It("divides the key space into shards", func() {
+		first, err := level.NewShardRange(0, 3)
+		Expect(err).NotTo(HaveOccurred())
+		last, err := level.NewShardRange(2, 3)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(first.Start).To(BeZero())
+		Expect(last.End).To(Equal(level.FullKeySpace.End))
+	})
+
+	It("rejects shard indexes outside the shard count", func() {
+		_, err := level.NewShardRange(3, 3)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("rejects more shards than the key space has positions", func() {
+		_, err := level.NewShardRange(0, int(level.FullKeySpace.End)+1)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
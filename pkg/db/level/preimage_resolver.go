@@ -0,0 +1,92 @@
+package level
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/rawdb"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+)
+
+// Preimage is a secure trie key with the hash it is stored under
+type Preimage struct {
+	Hash     common.Hash   `json:"hash"`
+	Preimage hexutil.Bytes `json:"preimage"`
+}
+
+type IPreimageResolver interface {
+	ExportPreimages(ctx context.Context, keySpace KeySpaceRange, visit func(preimage Preimage) error) error
+	ResolveAddress(addressHash common.Hash) *common.Address
+	ResolveSlotKey(keyHash common.Hash) *common.Hash
+}
+
+// PreimageResolver looks up the addresses and storage slot keys behind the
+// hashed keys of state and storage tries. Geth only stores preimages for keys
+// it was configured to record them for (e.g. with --cache.preimages).
+type PreimageResolver struct {
+	db        state.GethStateDatabase
+	preimages rawdb.IPreimages
+}
+
+func NewPreimageResolver(db state.GethStateDatabase, preimages rawdb.IPreimages) *PreimageResolver {
+	return &PreimageResolver{
+		db:        db,
+		preimages: preimages,
+	}
+}
+
+// ExportPreimages visits every stored preimage whose hash is in keySpace, in
+// hash order, until ctx is done
+func (pr *PreimageResolver) ExportPreimages(ctx context.Context, keySpace KeySpaceRange, visit func(preimage Preimage) error) error {
+	// preimages are stored by hash, so iterate those beginning with each byte
+	// the range touches
+	for firstByte := keySpace.Start >> 8; firstByte <= (keySpace.End-1)>>8; firstByte++ {
+		err := pr.preimages.IteratePreimages([]byte{byte(firstByte)}, func(hash common.Hash, preimage []byte) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			position := uint32(hash[0])<<8 | uint32(hash[1])
+			if position < keySpace.Start || position >= keySpace.End {
+				return nil
+			}
+			return visit(Preimage{Hash: hash, Preimage: preimage})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveAddress returns the address hashed to addressHash, or nil if its
+// preimage is unknown
+func (pr *PreimageResolver) ResolveAddress(addressHash common.Hash) *common.Address {
+	preimage := pr.getPreimage(addressHash)
+	if len(preimage) != common.AddressLength {
+		return nil
+	}
+	address := common.BytesToAddress(preimage)
+	return &address
+}
+
+// ResolveSlotKey returns the storage slot key hashed to keyHash, or nil if its
+// preimage is unknown
+func (pr *PreimageResolver) ResolveSlotKey(keyHash common.Hash) *common.Hash {
+	preimage := pr.getPreimage(keyHash)
+	if len(preimage) != common.HashLength {
+		return nil
+	}
+	key := common.BytesToHash(preimage)
+	return &key
+}
+
+// getPreimage reads a preimage through the trie database, which also holds
+// the preimages of computed state that has not been written to disk
+func (pr *PreimageResolver) getPreimage(hash common.Hash) []byte {
+	emptyTrie, err := pr.db.OpenTrie(common.Hash{})
+	if err != nil {
+		return nil
+	}
+	return emptyTrie.GetKey(hash.Bytes())
+}
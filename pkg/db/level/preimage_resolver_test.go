@@ -0,0 +1,83 @@
+package level_test
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	rawdb_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/rawdb"
+	state_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+)
+
+var _ = Describe("Preimage resolver", func() {
+	var (
+		address     = common.HexToAddress("0xabc")
+		addressHash = crypto.Keccak256Hash(address.Bytes())
+		key         = common.HexToHash("0x1")
+		keyHash     = crypto.Keccak256Hash(key.Bytes())
+		resolver    *level.PreimageResolver
+	)
+
+	BeforeEach(func() {
+		ethDB := rawdb.NewMemoryDatabase()
+		rawdb.WritePreimages(ethDB, map[common.Hash][]byte{
+			addressHash: address.Bytes(),
+			keyHash:     key.Bytes(),
+		})
+		resolver = level.NewPreimageResolver(state_wrapper.NewDatabase(ethDB), rawdb_wrapper.NewPreimages(ethDB))
+	})
+
+	It("resolves an address hash to its address", func() {
+		Expect(resolver.ResolveAddress(addressHash)).To(Equal(&address))
+	})
+
+	It("resolves a slot key hash to its key", func() {
+		Expect(resolver.ResolveSlotKey(keyHash)).To(Equal(&key))
+	})
+
+	It("returns nil for unknown preimages", func() {
+		Expect(resolver.ResolveAddress(test_helpers.FakeHash)).To(BeNil())
+		Expect(resolver.ResolveSlotKey(test_helpers.FakeHash)).To(BeNil())
+	})
+
+	It("returns nil for preimages of the wrong length", func() {
+		Expect(resolver.ResolveAddress(keyHash)).To(BeNil())
+		Expect(resolver.ResolveSlotKey(addressHash)).To(BeNil())
+	})
+
+	It("exports the preimages in a key space range in hash order", func() {
+		var all []level.Preimage
+		err := resolver.ExportPreimages(context.Background(), level.FullKeySpace, func(preimage level.Preimage) error {
+			all = append(all, preimage)
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(all).To(HaveLen(2))
+		Expect(all[0].Hash.Big().Cmp(all[1].Hash.Big())).To(Equal(-1))
+
+		first := all[0].Hash
+		position := uint32(first[0])<<8 | uint32(first[1])
+		var inRange []level.Preimage
+		err = resolver.ExportPreimages(context.Background(), level.KeySpaceRange{Start: position, End: position + 1}, func(preimage level.Preimage) error {
+			inRange = append(inRange, preimage)
+			return nil
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inRange).To(Equal(all[:1]))
+	})
+
+	It("returns error if visiting a preimage fails", func() {
+		err := resolver.ExportPreimages(context.Background(), level.FullKeySpace, func(preimage level.Preimage) error {
+			return test_helpers.FakeError
+		})
+
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+})
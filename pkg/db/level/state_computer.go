@@ -1,14 +1,36 @@
 package level
 
 import (
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/core"
 	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/vm"
 )
 
 type IStateComputer interface {
-	ComputeBlockStateTrie(currentBlock *types.Block, parentBlock *types.Block) (root common.Hash, err error)
+	ComputeBlockStateTrie(block *types.Block, parentRoot common.Hash) (root common.Hash, err error)
+	ComputeBlockStateTrieWithTraces(block *types.Block, parentRoot common.Hash) (root common.Hash, traces []TransactionTrace, err error)
+	ComputeBlockWitness(block *types.Block, parentRoot common.Hash) (witness Witness, err error)
+}
+
+// Witness holds the state and storage trie nodes read while executing a block,
+// which are sufficient to re-execute it without the rest of the state
+type Witness struct {
+	BlockHash        common.Hash
+	StateTrieNodes   [][]byte
+	StorageTrieNodes [][]byte
+}
+
+// CallFrame is a call made while executing a transaction, with its nested calls
+type CallFrame = vm.CallFrame
+
+// TransactionTrace is the call tree of one transaction in a block
+type TransactionTrace struct {
+	TxHash common.Hash `json:"txHash"`
+	*CallFrame
 }
 
 type StateComputer struct {
@@ -29,8 +51,11 @@ func NewStateComputer(blockChain core.GethCoreBlockChain, db state.GethStateData
 	}
 }
 
-func (sc *StateComputer) ComputeBlockStateTrie(block, parent *types.Block) (root common.Hash, err error) {
-	stateTrie, err := sc.stateDBFactory.NewStateDB(parent.Root(), sc.db.Database())
+// ComputeBlockStateTrie applies the block to the state at parentRoot and returns the
+// committed root. The root is not checked against the block header, so callers can
+// compare them and decide how to handle a mismatch.
+func (sc *StateComputer) ComputeBlockStateTrie(block *types.Block, parentRoot common.Hash) (root common.Hash, err error) {
+	stateTrie, err := sc.stateDBFactory.NewStateDB(parentRoot, sc.db.Database())
 	if err != nil {
 		return root, err
 	}
@@ -42,9 +67,70 @@ func (sc *StateComputer) createStateTrieForBlock(block *types.Block, stateTrie s
 	if err != nil {
 		return root, err
 	}
-	err = sc.validator.ValidateState(block, stateTrie.StateDB(), receipts, usedGas)
+	return sc.commitStateTrieForBlock(block, stateTrie, receipts, usedGas)
+}
+
+// ComputeBlockStateTrieWithTraces is ComputeBlockStateTrie, also returning the
+// call tree of each of the block's transactions
+func (sc *StateComputer) ComputeBlockStateTrieWithTraces(block *types.Block, parentRoot common.Hash) (root common.Hash, traces []TransactionTrace, err error) {
+	stateTrie, err := sc.stateDBFactory.NewStateDB(parentRoot, sc.db.Database())
+	if err != nil {
+		return root, nil, err
+	}
+	tracer := vm.NewCallTracer()
+	receipts, _, usedGas, err := sc.processor.ProcessWithTracer(block, stateTrie.StateDB(), tracer)
+	if err != nil {
+		return root, nil, err
+	}
+	traces, err = transactionTraces(block, tracer.Calls())
+	if err != nil {
+		return root, nil, err
+	}
+	root, err = sc.commitStateTrieForBlock(block, stateTrie, receipts, usedGas)
+	return root, traces, err
+}
+
+// transactionTraces pairs each transaction with its call tree. The tracer sees
+// one outermost call per transaction, in order.
+func transactionTraces(block *types.Block, calls []*CallFrame) ([]TransactionTrace, error) {
+	transactions := block.Transactions()
+	if len(calls) != len(transactions) {
+		return nil, fmt.Errorf("traced %d calls for %d transactions in block %d", len(calls), len(transactions), block.NumberU64())
+	}
+	traces := make([]TransactionTrace, 0, len(calls))
+	for i, call := range calls {
+		traces = append(traces, TransactionTrace{TxHash: transactions[i].Hash(), CallFrame: call})
+	}
+	return traces, nil
+}
+
+func (sc *StateComputer) commitStateTrieForBlock(block *types.Block, stateTrie state.GethStateDB, receipts types.Receipts, usedGas uint64) (root common.Hash, err error) {
+	err = sc.validator.ValidateReceipts(block, receipts, usedGas)
 	if err != nil {
 		return root, err
 	}
 	return stateTrie.Commit(sc.blockChain.Config().IsEIP158(block.Number()))
 }
+
+// ComputeBlockWitness applies the block to the state at parentRoot, recording
+// every trie node read along the way. The resulting state is not committed.
+func (sc *StateComputer) ComputeBlockWitness(block *types.Block, parentRoot common.Hash) (witness Witness, err error) {
+	recorder := state.NewRecordingDatabase(sc.db.Database())
+	stateTrie, err := sc.stateDBFactory.NewStateDB(parentRoot, recorder)
+	if err != nil {
+		return witness, err
+	}
+	receipts, _, usedGas, err := sc.processor.Process(block, stateTrie.StateDB())
+	if err != nil {
+		return witness, err
+	}
+	err = sc.validator.ValidateReceipts(block, receipts, usedGas)
+	if err != nil {
+		return witness, err
+	}
+	return Witness{
+		BlockHash:        block.Hash(),
+		StateTrieNodes:   recorder.StateTrieNodes(),
+		StorageTrieNodes: recorder.StorageTrieNodes(),
+	}, nil
+}
@@ -24,7 +24,7 @@ var _ = Describe("", func() {
 		computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
 		currentBlock, parentBlock := getFakeBlocks()
 
-		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).NotTo(HaveOccurred())
 		trieFactory.AssertNewStateTrieCalledWith(parentBlock.Root(), fakeDB)
@@ -36,7 +36,7 @@ var _ = Describe("", func() {
 		computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
 		currentBlock, parentBlock := getFakeBlocks()
 
-		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
@@ -51,7 +51,7 @@ var _ = Describe("", func() {
 		trieFactory.SetStateDB(stateTrie)
 		currentBlock, parentBlock := getFakeBlocks()
 
-		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).NotTo(HaveOccurred())
 		processor.AssertProcessCalledWith(currentBlock, fakeStateDB)
@@ -63,51 +63,47 @@ var _ = Describe("", func() {
 		computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
 		currentBlock, parentBlock := getFakeBlocks()
 
-		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
 	})
 
-	It("validates state computed by processing blocks", func() {
+	It("validates receipts computed by processing blocks", func() {
 		chain, db, processor, trieFactory, validator := getMocks()
 		fakeReceipts := types.Receipts{}
 		processor.SetReturnReceipts(fakeReceipts)
 		fakeUsedGas := uint64(1234)
 		processor.SetReturnUsedGas(fakeUsedGas)
 		computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
-		stateTrie := state_wrapper.NewMockStateDB()
-		fakeStateDB := &state.StateDB{}
-		stateTrie.SetStateDB(fakeStateDB)
-		trieFactory.SetStateDB(stateTrie)
 		currentBlock, parentBlock := getFakeBlocks()
 
-		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).NotTo(HaveOccurred())
-		validator.AssertValidateStateCalledWith(currentBlock, fakeStateDB, fakeReceipts, fakeUsedGas)
+		validator.AssertValidateReceiptsCalledWith(currentBlock, fakeReceipts, fakeUsedGas)
 	})
 
-	It("returns error if validating state fails", func() {
+	It("returns error if validating receipts fails", func() {
 		chain, db, processor, trieFactory, validator := getMocks()
 		validator.SetReturnErr(test_helpers.FakeError)
 		computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
 		currentBlock, parentBlock := getFakeBlocks()
 
-		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
 	})
 
-	It("commits validated state to memory database", func() {
+	It("commits state to memory database", func() {
 		chain, db, processor, trieFactory, validator := getMocks()
 		computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
 		stateTrie := state_wrapper.NewMockStateDB()
 		trieFactory.SetStateDB(stateTrie)
 		currentBlock, parentBlock := getFakeBlocks()
 
-		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).NotTo(HaveOccurred())
 		stateTrie.AssertCommitCalled()
@@ -121,7 +117,7 @@ var _ = Describe("", func() {
 		trieFactory.SetStateDB(stateTrie)
 		currentBlock, parentBlock := getFakeBlocks()
 
-		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		_, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
@@ -137,11 +133,145 @@ var _ = Describe("", func() {
 		db.ReturnTrie = fakeTrie
 		currentBlock, parentBlock := getFakeBlocks()
 
-		stateRoot, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock)
+		stateRoot, err := computer.ComputeBlockStateTrie(currentBlock, parentBlock.Root())
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(stateRoot).To(Equal(test_helpers.FakeHash))
 	})
+
+	It("does not validate computed root against block header", func() {
+		chain, db, processor, trieFactory, validator := getMocks()
+		computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+		currentBlock := types.NewBlockWithHeader(&types.Header{Root: common.HexToHash("0x456"), Number: big.NewInt(456)})
+
+		stateRoot, err := computer.ComputeBlockStateTrie(currentBlock, common.HexToHash("0x789"))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stateRoot).To(Equal(test_helpers.FakeHash))
+	})
+
+	Describe("computing block state trie with traces", func() {
+		It("processes the block with a tracer", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			stateTrie := state_wrapper.NewMockStateDB()
+			fakeStateDB := &state.StateDB{}
+			stateTrie.SetStateDB(fakeStateDB)
+			trieFactory.SetStateDB(stateTrie)
+			currentBlock, parentBlock := getFakeBlocks()
+
+			_, _, err := computer.ComputeBlockStateTrieWithTraces(currentBlock, parentBlock.Root())
+
+			Expect(err).NotTo(HaveOccurred())
+			trieFactory.AssertNewStateTrieCalledWith(parentBlock.Root(), db.ReturnDB)
+			processor.AssertProcessWithTracerCalledWith(currentBlock, fakeStateDB)
+		})
+
+		It("returns each transaction's trace with the committed root", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			processor.SetTracedCalls(2)
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			transactions := types.Transactions{
+				types.NewTransaction(0, common.HexToAddress("0x1"), big.NewInt(1), 21000, big.NewInt(1), nil),
+				types.NewTransaction(1, common.HexToAddress("0x2"), big.NewInt(2), 21000, big.NewInt(1), nil),
+			}
+			currentBlock := types.NewBlock(&types.Header{Number: big.NewInt(456)}, transactions, nil, nil)
+
+			stateRoot, traces, err := computer.ComputeBlockStateTrieWithTraces(currentBlock, common.HexToHash("0x789"))
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stateRoot).To(Equal(test_helpers.FakeHash))
+			Expect(len(traces)).To(Equal(2))
+			Expect(traces[0].TxHash).To(Equal(transactions[0].Hash()))
+			Expect(traces[1].TxHash).To(Equal(transactions[1].Hash()))
+			Expect(traces[0].CallFrame).NotTo(BeNil())
+		})
+
+		It("returns error if traces do not match the block's transactions", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			processor.SetTracedCalls(1)
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			currentBlock, parentBlock := getFakeBlocks()
+
+			_, _, err := computer.ComputeBlockStateTrieWithTraces(currentBlock, parentBlock.Root())
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns error if validating receipts fails", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			validator.SetReturnErr(test_helpers.FakeError)
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			currentBlock, parentBlock := getFakeBlocks()
+
+			_, _, err := computer.ComputeBlockStateTrieWithTraces(currentBlock, parentBlock.Root())
+
+			Expect(err).To(MatchError(test_helpers.FakeError))
+		})
+	})
+
+	Describe("computing block witness", func() {
+		It("initializes state at parent root over a recording database", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			currentBlock, parentBlock := getFakeBlocks()
+
+			_, err := computer.ComputeBlockWitness(currentBlock, parentBlock.Root())
+
+			Expect(err).NotTo(HaveOccurred())
+			trieFactory.AssertNewStateTrieCalledWithRecordingDatabase(parentBlock.Root(), db.ReturnDB)
+		})
+
+		It("processes and validates the block", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			fakeReceipts := types.Receipts{}
+			processor.SetReturnReceipts(fakeReceipts)
+			processor.SetReturnUsedGas(1234)
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			currentBlock, parentBlock := getFakeBlocks()
+
+			_, err := computer.ComputeBlockWitness(currentBlock, parentBlock.Root())
+
+			Expect(err).NotTo(HaveOccurred())
+			validator.AssertValidateReceiptsCalledWith(currentBlock, fakeReceipts, 1234)
+		})
+
+		It("does not commit state", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			stateTrie := state_wrapper.NewMockStateDB()
+			trieFactory.SetStateDB(stateTrie)
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			currentBlock, parentBlock := getFakeBlocks()
+
+			witness, err := computer.ComputeBlockWitness(currentBlock, parentBlock.Root())
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(witness.BlockHash).To(Equal(currentBlock.Hash()))
+			stateTrie.AssertCommitNotCalled()
+		})
+
+		It("returns error if processing block fails", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			processor.SetReturnErr(test_helpers.FakeError)
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			currentBlock, parentBlock := getFakeBlocks()
+
+			_, err := computer.ComputeBlockWitness(currentBlock, parentBlock.Root())
+
+			Expect(err).To(MatchError(test_helpers.FakeError))
+		})
+
+		It("returns error if validating receipts fails", func() {
+			chain, db, processor, trieFactory, validator := getMocks()
+			validator.SetReturnErr(test_helpers.FakeError)
+			computer := level.NewStateComputer(chain, db, processor, trieFactory, validator)
+			currentBlock, parentBlock := getFakeBlocks()
+
+			_, err := computer.ComputeBlockWitness(currentBlock, parentBlock.Root())
+
+			Expect(err).To(MatchError(test_helpers.FakeError))
+		})
+	})
 })
 
 func getMocks() (*core.MockBlockChain, *state_wrapper.MockStateDatabase, *core.MockProcessor, *state_wrapper.MockStateDBFactory, *core.MockValidator) {
@@ -0,0 +1,240 @@
+package level
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	state_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/trie"
+)
+
+// Account is a decoded state trie leaf
+type Account struct {
+	Nonce       uint64      `json:"nonce"`
+	Balance     *big.Int    `json:"balance"`
+	StorageRoot common.Hash `json:"storageRoot"`
+	CodeHash    common.Hash `json:"codeHash"`
+}
+
+// AccountDiff describes an account whose leaf differs between two state tries.
+// From or To is nil when the account only exists in the other trie. Storage
+// lists the slots that differ when the account's storage root changed.
+// Address is only set if the preimage of AddressHash is known.
+type AccountDiff struct {
+	AddressHash common.Hash     `json:"addressHash"`
+	Address     *common.Address `json:"address,omitempty"`
+	From        *Account        `json:"from"`
+	To          *Account        `json:"to"`
+	Storage     []StorageDiff   `json:"storage,omitempty"`
+}
+
+// StorageDiff describes a storage slot whose value differs between two storage
+// tries. An absent slot has the zero value. Key is only set if the preimage of
+// KeyHash is known.
+type StorageDiff struct {
+	KeyHash common.Hash  `json:"keyHash"`
+	Key     *common.Hash `json:"key,omitempty"`
+	From    common.Hash  `json:"from"`
+	To      common.Hash  `json:"to"`
+}
+
+type IStateDiffer interface {
+	DiffStateTries(ctx context.Context, fromRoot, toRoot common.Hash) ([]AccountDiff, error)
+}
+
+type StateDiffer struct {
+	db       state_wrapper.GethStateDatabase
+	decoder  rlp.Decoder
+	resolver IPreimageResolver
+}
+
+func NewStateDiffer(db state_wrapper.GethStateDatabase, decoder rlp.Decoder, resolver IPreimageResolver) *StateDiffer {
+	return &StateDiffer{
+		db:       db,
+		decoder:  decoder,
+		resolver: resolver,
+	}
+}
+
+// DiffStateTries returns every account that was added, removed or changed
+// between fromRoot and toRoot, along with the storage slots that changed.
+func (sd *StateDiffer) DiffStateTries(ctx context.Context, fromRoot, toRoot common.Hash) ([]AccountDiff, error) {
+	var diffs []AccountDiff
+	err := sd.diffLeaves(ctx, fromRoot, toRoot, func(key, fromBlob, toBlob []byte) error {
+		diff, err := sd.newAccountDiff(ctx, key, fromBlob, toBlob)
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, diff)
+		return nil
+	})
+	return diffs, err
+}
+
+func (sd *StateDiffer) newAccountDiff(ctx context.Context, key, fromBlob, toBlob []byte) (diff AccountDiff, err error) {
+	diff.AddressHash = common.BytesToHash(key)
+	diff.Address = sd.resolver.ResolveAddress(diff.AddressHash)
+	diff.From, err = decodeAccount(sd.decoder, fromBlob)
+	if err != nil {
+		return diff, err
+	}
+	diff.To, err = decodeAccount(sd.decoder, toBlob)
+	if err != nil {
+		return diff, err
+	}
+	fromStorageRoot, toStorageRoot := storageRoot(diff.From), storageRoot(diff.To)
+	if fromStorageRoot == toStorageRoot {
+		return diff, nil
+	}
+	diff.Storage, err = sd.diffStorageTries(ctx, fromStorageRoot, toStorageRoot)
+	return diff, err
+}
+
+func (sd *StateDiffer) diffStorageTries(ctx context.Context, fromRoot, toRoot common.Hash) ([]StorageDiff, error) {
+	var diffs []StorageDiff
+	err := sd.diffLeaves(ctx, fromRoot, toRoot, func(key, fromBlob, toBlob []byte) error {
+		keyHash := common.BytesToHash(key)
+		diff := StorageDiff{KeyHash: keyHash, Key: sd.resolver.ResolveSlotKey(keyHash)}
+		var err error
+		diff.From, err = decodeStorageValue(sd.decoder, fromBlob)
+		if err != nil {
+			return err
+		}
+		diff.To, err = decodeStorageValue(sd.decoder, toBlob)
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, diff)
+		return nil
+	})
+	return diffs, err
+}
+
+// diffLeaves walks the leaves of both tries in key order, calling onDiff for each
+// key whose value differs, until ctx is done. A blob is nil when the key is
+// absent from that trie.
+func (sd *StateDiffer) diffLeaves(ctx context.Context, fromRoot, toRoot common.Hash, onDiff func(key, fromBlob, toBlob []byte) error) error {
+	fromTrie, err := sd.db.OpenTrie(fromRoot)
+	if err != nil {
+		return err
+	}
+	toTrie, err := sd.db.OpenTrie(toRoot)
+	if err != nil {
+		return err
+	}
+	from := newLeafIterator(fromTrie.NodeIterator(nil))
+	to := newLeafIterator(toTrie.NodeIterator(nil))
+	for from.ok || to.ok {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// an iterator stopped by a missing node must not read as the end of its trie
+		if err := iterationError(from, to); err != nil {
+			return err
+		}
+		var fromBlob, toBlob []byte
+		var key []byte
+		switch {
+		case !to.ok || (from.ok && bytes.Compare(from.key, to.key) < 0):
+			key, fromBlob = from.key, from.blob
+			from.next()
+		case !from.ok || bytes.Compare(from.key, to.key) > 0:
+			key, toBlob = to.key, to.blob
+			to.next()
+		default:
+			key, fromBlob, toBlob = from.key, from.blob, to.blob
+			from.next()
+			to.next()
+			if bytes.Equal(fromBlob, toBlob) {
+				continue
+			}
+		}
+		err = onDiff(key, fromBlob, toBlob)
+		if err != nil {
+			return err
+		}
+	}
+	return iterationError(from, to)
+}
+
+// decodeAccount decodes a state trie leaf, returning nil for an absent leaf
+func decodeAccount(decoder rlp.Decoder, blob []byte) (*Account, error) {
+	if blob == nil {
+		return nil, nil
+	}
+	var account state.Account
+	err := decoder.Decode(blob, &account)
+	if err != nil {
+		return nil, err
+	}
+	return &Account{
+		Nonce:       account.Nonce,
+		Balance:     account.Balance,
+		StorageRoot: account.Root,
+		CodeHash:    common.BytesToHash(account.CodeHash),
+	}, nil
+}
+
+// decodeStorageValue decodes a storage trie leaf, returning zero for an absent leaf
+func decodeStorageValue(decoder rlp.Decoder, blob []byte) (common.Hash, error) {
+	if blob == nil {
+		return common.Hash{}, nil
+	}
+	var value []byte
+	err := decoder.Decode(blob, &value)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(value), nil
+}
+
+// storageRoot returns the account's storage root, or the empty root if the account is absent
+func storageRoot(account *Account) common.Hash {
+	if account == nil {
+		return common.BytesToHash(EmptyStorageTrieRoot)
+	}
+	return account.StorageRoot
+}
+
+// leafIterator advances a node iterator from leaf to leaf, copying the current
+// leaf's key and value since the underlying iterator may reuse them
+type leafIterator struct {
+	iterator trie.GethTrieNodeIterator
+	key      []byte
+	blob     []byte
+	ok       bool
+	err      error
+}
+
+func newLeafIterator(iterator trie.GethTrieNodeIterator) *leafIterator {
+	it := &leafIterator{iterator: iterator}
+	it.next()
+	return it
+}
+
+func (it *leafIterator) next() {
+	for it.iterator.Next(true) {
+		if it.iterator.Leaf() {
+			it.key = common.CopyBytes(it.iterator.LeafKey())
+			it.blob = common.CopyBytes(it.iterator.LeafBlob())
+			it.ok = true
+			return
+		}
+	}
+	it.key, it.blob, it.ok = nil, nil, false
+	it.err = it.iterator.Error()
+}
+
+// iterationError returns the error that stopped any of the iterators
+func iterationError(iterators ...*leafIterator) error {
+	for _, it := range iterators {
+		if it.err != nil {
+			return it.err
+		}
+	}
+	return nil
+}
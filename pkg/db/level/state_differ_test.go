@@ -0,0 +1,197 @@
+package level_test
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	eth_rlp "github.com/ethereum/go-ethereum/rlp"
+	eth_trie "github.com/ethereum/go-ethereum/trie"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	real_state "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	level_wrapper "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db/level"
+	state_wrapper "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/wrappers/core/state"
+	mock_rlp "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/wrappers/rlp"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/wrappers/trie"
+)
+
+var _ = Describe("State differ", func() {
+	var (
+		fromRoot = common.HexToHash("0x1")
+		toRoot   = common.HexToHash("0x2")
+		keyA     = common.HexToHash("0xa").Bytes()
+		keyB     = common.HexToHash("0xb").Bytes()
+		keyC     = common.HexToHash("0xc").Bytes()
+	)
+
+	It("returns added, removed and changed accounts in key order", func() {
+		added := encodeAccount(1, 100)
+		removed := encodeAccount(2, 200)
+		changedFrom := encodeAccount(3, 300)
+		changedTo := encodeAccount(4, 250)
+		db := state_wrapper.NewMockStateDatabase()
+		db.SetReturnTrieForRoot(fromRoot, newLeafTrie([][]byte{keyA, keyB}, [][]byte{removed, changedFrom}))
+		db.SetReturnTrieForRoot(toRoot, newLeafTrie([][]byte{keyB, keyC}, [][]byte{changedTo, added}))
+		differ := level.NewStateDiffer(db, rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver())
+
+		diffs, err := differ.DiffStateTries(context.Background(), fromRoot, toRoot)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(diffs)).To(Equal(3))
+		Expect(diffs[0].AddressHash).To(Equal(common.BytesToHash(keyA)))
+		Expect(diffs[0].From.Nonce).To(Equal(uint64(2)))
+		Expect(diffs[0].To).To(BeNil())
+		Expect(diffs[1].AddressHash).To(Equal(common.BytesToHash(keyB)))
+		Expect(diffs[1].From.Balance).To(Equal(big.NewInt(300)))
+		Expect(diffs[1].To.Balance).To(Equal(big.NewInt(250)))
+		Expect(diffs[2].AddressHash).To(Equal(common.BytesToHash(keyC)))
+		Expect(diffs[2].From).To(BeNil())
+		Expect(diffs[2].To.Nonce).To(Equal(uint64(1)))
+	})
+
+	It("returns changed storage slots for accounts whose storage root changed", func() {
+		fromStorageRoot := common.HexToHash("0x3")
+		toStorageRoot := common.HexToHash("0x4")
+		slotA := common.HexToHash("0x5a").Bytes()
+		slotB := common.HexToHash("0x5b").Bytes()
+		db := state_wrapper.NewMockStateDatabase()
+		db.SetReturnTrieForRoot(fromRoot, newLeafTrie([][]byte{keyA}, [][]byte{encodeAccountWithStorage(1, 100, fromStorageRoot)}))
+		db.SetReturnTrieForRoot(toRoot, newLeafTrie([][]byte{keyA}, [][]byte{encodeAccountWithStorage(1, 100, toStorageRoot)}))
+		db.SetReturnTrieForRoot(fromStorageRoot, newLeafTrie([][]byte{slotA}, [][]byte{encodeStorageValue(0x01)}))
+		db.SetReturnTrieForRoot(toStorageRoot, newLeafTrie([][]byte{slotA, slotB}, [][]byte{encodeStorageValue(0x02), encodeStorageValue(0x03)}))
+		differ := level.NewStateDiffer(db, rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver())
+
+		diffs, err := differ.DiffStateTries(context.Background(), fromRoot, toRoot)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(diffs)).To(Equal(1))
+		Expect(diffs[0].Storage).To(Equal([]level.StorageDiff{
+			{KeyHash: common.BytesToHash(slotA), From: common.BigToHash(big.NewInt(1)), To: common.BigToHash(big.NewInt(2))},
+			{KeyHash: common.BytesToHash(slotB), From: common.Hash{}, To: common.BigToHash(big.NewInt(3))},
+		}))
+	})
+
+	It("annotates diffs with the address and slot keys whose preimages are known", func() {
+		fromStorageRoot := common.HexToHash("0x3")
+		toStorageRoot := common.HexToHash("0x4")
+		slotA := common.HexToHash("0x5a").Bytes()
+		slotB := common.HexToHash("0x5b").Bytes()
+		address := common.HexToAddress("0xabc")
+		key := common.HexToHash("0x1")
+		db := state_wrapper.NewMockStateDatabase()
+		db.SetReturnTrieForRoot(fromRoot, newLeafTrie([][]byte{keyA}, [][]byte{encodeAccountWithStorage(1, 100, fromStorageRoot)}))
+		db.SetReturnTrieForRoot(toRoot, newLeafTrie([][]byte{keyA}, [][]byte{encodeAccountWithStorage(1, 100, toStorageRoot)}))
+		db.SetReturnTrieForRoot(fromStorageRoot, newLeafTrie(nil, nil))
+		db.SetReturnTrieForRoot(toStorageRoot, newLeafTrie([][]byte{slotA, slotB}, [][]byte{encodeStorageValue(0x02), encodeStorageValue(0x03)}))
+		resolver := level_wrapper.NewMockPreimageResolver()
+		resolver.SetAddress(common.BytesToHash(keyA), address)
+		resolver.SetSlotKey(common.BytesToHash(slotA), key)
+		differ := level.NewStateDiffer(db, rlp.RlpDecoder{}, resolver)
+
+		diffs, err := differ.DiffStateTries(context.Background(), fromRoot, toRoot)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(diffs)).To(Equal(1))
+		Expect(diffs[0].Address).To(Equal(&address))
+		Expect(diffs[0].Storage[0].Key).To(Equal(&key))
+		Expect(diffs[0].Storage[1].Key).To(BeNil())
+	})
+
+	It("returns no diffs for identical tries", func() {
+		leaf := encodeAccount(1, 100)
+		db := state_wrapper.NewMockStateDatabase()
+		db.SetReturnTrieForRoot(fromRoot, newLeafTrie([][]byte{keyA}, [][]byte{leaf}))
+		db.SetReturnTrieForRoot(toRoot, newLeafTrie([][]byte{keyA}, [][]byte{leaf}))
+		differ := level.NewStateDiffer(db, rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver())
+
+		diffs, err := differ.DiffStateTries(context.Background(), fromRoot, toRoot)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffs).To(BeEmpty())
+	})
+
+	It("returns error if opening a trie fails", func() {
+		db := state_wrapper.NewMockStateDatabase()
+		db.ReturnOpenTrieErr = test_helpers.FakeError
+		differ := level.NewStateDiffer(db, rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver())
+
+		_, err := differ.DiffStateTries(context.Background(), fromRoot, toRoot)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+
+	It("returns error if a trie node is missing", func() {
+		ethDB := rawdb.NewMemoryDatabase()
+		stateDB, err := state.New(common.Hash{}, state.NewDatabase(ethDB))
+		Expect(err).NotTo(HaveOccurred())
+		for i := int64(0); i < 50; i++ {
+			stateDB.SetBalance(common.BigToAddress(big.NewInt(i+1)), big.NewInt(i+1))
+		}
+		root, err := stateDB.Commit(true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stateDB.Database().TrieDB().Commit(root, false)).To(Succeed())
+		iterator := ethDB.NewIterator()
+		for iterator.Next() {
+			if len(iterator.Key()) == common.HashLength && common.BytesToHash(iterator.Key()) != root {
+				Expect(ethDB.Delete(common.CopyBytes(iterator.Key()))).To(Succeed())
+				break
+			}
+		}
+		iterator.Release()
+		differ := level.NewStateDiffer(real_state.NewDatabase(ethDB), rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver())
+
+		_, err = differ.DiffStateTries(context.Background(), common.BytesToHash(level.EmptyStorageTrieRoot), root)
+
+		Expect(err).To(BeAssignableToTypeOf(&eth_trie.MissingNodeError{}))
+	})
+
+	It("returns error if decoding an account fails", func() {
+		db := state_wrapper.NewMockStateDatabase()
+		db.SetReturnTrieForRoot(fromRoot, newLeafTrie(nil, nil))
+		db.SetReturnTrieForRoot(toRoot, newLeafTrie([][]byte{keyA}, [][]byte{encodeAccount(1, 100)}))
+		decoder := mock_rlp.NewMockDecoder()
+		decoder.SetReturnOut(&state.Account{})
+		decoder.SetError(test_helpers.FakeError)
+		differ := level.NewStateDiffer(db, decoder, level_wrapper.NewMockPreimageResolver())
+
+		_, err := differ.DiffStateTries(context.Background(), fromRoot, toRoot)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+})
+
+func encodeAccount(nonce uint64, balance int64) []byte {
+	return encodeAccountWithStorage(nonce, balance, common.BytesToHash(level.EmptyStorageTrieRoot))
+}
+
+func encodeAccountWithStorage(nonce uint64, balance int64, storageRoot common.Hash) []byte {
+	leaf, err := eth_rlp.EncodeToBytes(state.Account{
+		Nonce:    nonce,
+		Balance:  big.NewInt(balance),
+		Root:     storageRoot,
+		CodeHash: common.HexToHash("0xc5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470").Bytes(),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return leaf
+}
+
+func encodeStorageValue(value byte) []byte {
+	leaf, err := eth_rlp.EncodeToBytes([]byte{value})
+	Expect(err).NotTo(HaveOccurred())
+	return leaf
+}
+
+func newLeafTrie(keys, blobs [][]byte) *state_wrapper.MockTrie {
+	mockTrie := state_wrapper.NewMockTrie()
+	mockTrie.SetReturnIterator(trie.NewMockLeafIterator(keys, blobs))
+	return mockTrie
+}
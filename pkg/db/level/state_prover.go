@@ -0,0 +1,128 @@
+package level
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	state_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/trie"
+)
+
+// AccountProof is a Merkle proof of an account and some of its storage slots,
+// in the format returned by eth_getProof
+type AccountProof struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []hexutil.Bytes `json:"accountProof"`
+	Balance      *hexutil.Big    `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        hexutil.Uint64  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageProof  `json:"storageProof"`
+}
+
+// StorageProof is a Merkle proof of a storage slot against its account's storage root
+type StorageProof struct {
+	Key   common.Hash     `json:"key"`
+	Value *hexutil.Big    `json:"value"`
+	Proof []hexutil.Bytes `json:"proof"`
+}
+
+type IStateProver interface {
+	ProveAccount(root common.Hash, address common.Address, storageKeys []common.Hash) (AccountProof, error)
+}
+
+type StateProver struct {
+	db      state_wrapper.GethStateDatabase
+	decoder rlp.Decoder
+}
+
+func NewStateProver(db state_wrapper.GethStateDatabase, decoder rlp.Decoder) *StateProver {
+	return &StateProver{
+		db:      db,
+		decoder: decoder,
+	}
+}
+
+// ProveAccount proves the account at address and the given storage slots in the
+// state trie with the given root. Each proof is verified against its root before
+// it is returned. Absent accounts and slots are proven absent and reported as empty.
+func (sp *StateProver) ProveAccount(root common.Hash, address common.Address, storageKeys []common.Hash) (AccountProof, error) {
+	accountProof, blob, err := sp.prove(root, address.Bytes())
+	if err != nil {
+		return AccountProof{}, fmt.Errorf("Error proving account %s: %s", address.Hex(), err)
+	}
+	account := &Account{
+		Balance:     big.NewInt(0),
+		StorageRoot: common.BytesToHash(EmptyStorageTrieRoot),
+		CodeHash:    common.BytesToHash(crypto.Keccak256(nil)),
+	}
+	if blob != nil {
+		account, err = decodeAccount(sp.decoder, blob)
+		if err != nil {
+			return AccountProof{}, err
+		}
+	}
+	storageProofs := make([]StorageProof, 0, len(storageKeys))
+	for _, key := range storageKeys {
+		storageProof, err := sp.proveStorage(account.StorageRoot, key)
+		if err != nil {
+			return AccountProof{}, fmt.Errorf("Error proving storage key %s of account %s: %s", key.Hex(), address.Hex(), err)
+		}
+		storageProofs = append(storageProofs, storageProof)
+	}
+	return AccountProof{
+		Address:      address,
+		AccountProof: accountProof,
+		Balance:      (*hexutil.Big)(account.Balance),
+		CodeHash:     account.CodeHash,
+		Nonce:        hexutil.Uint64(account.Nonce),
+		StorageHash:  account.StorageRoot,
+		StorageProof: storageProofs,
+	}, nil
+}
+
+func (sp *StateProver) proveStorage(storageRoot, key common.Hash) (StorageProof, error) {
+	proof, blob, err := sp.prove(storageRoot, key.Bytes())
+	if err != nil {
+		return StorageProof{}, err
+	}
+	value, err := decodeStorageValue(sp.decoder, blob)
+	if err != nil {
+		return StorageProof{}, err
+	}
+	return StorageProof{
+		Key:   key,
+		Value: (*hexutil.Big)(value.Big()),
+		Proof: proof,
+	}, nil
+}
+
+// prove returns the proof for the unhashed key in the trie at root, and the value
+// it proves
+func (sp *StateProver) prove(root common.Hash, key []byte) ([]hexutil.Bytes, []byte, error) {
+	proof := []hexutil.Bytes{}
+	if root == common.BytesToHash(EmptyStorageTrieRoot) {
+		return proof, nil, nil
+	}
+	t, err := sp.db.OpenTrie(root)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashedKey := crypto.Keccak256(key)
+	nodes, err := t.Prove(hashedKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := trie.VerifyProof(root, hashedKey, nodes)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, node := range nodes {
+		proof = append(proof, node)
+	}
+	return proof, value, nil
+}
@@ -0,0 +1,113 @@
+package level_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	state_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/trie"
+)
+
+var _ = Describe("State prover", func() {
+	var (
+		address       = common.HexToAddress("0xabc")
+		otherAddress  = common.HexToAddress("0xdef")
+		storageKey    = common.HexToHash("0x1")
+		storageValue  = common.HexToHash("0x2a")
+		code          = []byte{1, 2, 3}
+		stateDatabase *state_wrapper.Database
+		root          common.Hash
+		prover        *level.StateProver
+	)
+
+	BeforeEach(func() {
+		stateDatabase = state_wrapper.NewDatabase(rawdb.NewMemoryDatabase())
+		stateDB, err := state.New(common.Hash{}, stateDatabase.Database())
+		Expect(err).NotTo(HaveOccurred())
+		stateDB.SetNonce(address, 3)
+		stateDB.SetBalance(address, big.NewInt(100))
+		stateDB.SetCode(address, code)
+		stateDB.SetState(address, storageKey, storageValue)
+		stateDB.SetBalance(otherAddress, big.NewInt(1))
+		root, err = stateDB.Commit(true)
+		Expect(err).NotTo(HaveOccurred())
+		prover = level.NewStateProver(stateDatabase, rlp.RlpDecoder{})
+	})
+
+	It("proves an account against the state root", func() {
+		proof, err := prover.ProveAccount(root, address, nil)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proof.Address).To(Equal(address))
+		Expect(uint64(proof.Nonce)).To(Equal(uint64(3)))
+		Expect(proof.Balance.ToInt()).To(Equal(big.NewInt(100)))
+		Expect(proof.CodeHash).To(Equal(crypto.Keccak256Hash(code)))
+		Expect(proof.StorageHash).NotTo(Equal(common.BytesToHash(level.EmptyStorageTrieRoot)))
+		Expect(proof.StorageProof).To(BeEmpty())
+		value, err := trie.VerifyProof(root, crypto.Keccak256(address.Bytes()), toBytes(proof.AccountProof))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).NotTo(BeNil())
+	})
+
+	It("proves storage slots against the account's storage root", func() {
+		proof, err := prover.ProveAccount(root, address, []common.Hash{storageKey})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(proof.StorageProof)).To(Equal(1))
+		storageProof := proof.StorageProof[0]
+		Expect(storageProof.Key).To(Equal(storageKey))
+		Expect(storageProof.Value.ToInt()).To(Equal(storageValue.Big()))
+		value, err := trie.VerifyProof(proof.StorageHash, crypto.Keccak256(storageKey.Bytes()), toBytes(storageProof.Proof))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).NotTo(BeNil())
+	})
+
+	It("proves absent storage slots are zero", func() {
+		proof, err := prover.ProveAccount(root, address, []common.Hash{common.HexToHash("0x2")})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proof.StorageProof[0].Value.ToInt().Sign()).To(Equal(0))
+		Expect(proof.StorageProof[0].Proof).NotTo(BeEmpty())
+	})
+
+	It("proves absent accounts are empty", func() {
+		absent := common.HexToAddress("0x123")
+
+		proof, err := prover.ProveAccount(root, absent, []common.Hash{storageKey})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proof.AccountProof).NotTo(BeEmpty())
+		Expect(uint64(proof.Nonce)).To(BeZero())
+		Expect(proof.Balance.ToInt().Sign()).To(Equal(0))
+		Expect(proof.CodeHash).To(Equal(crypto.Keccak256Hash(nil)))
+		Expect(proof.StorageHash).To(Equal(common.BytesToHash(level.EmptyStorageTrieRoot)))
+		Expect(proof.StorageProof[0].Value.ToInt().Sign()).To(Equal(0))
+		Expect(proof.StorageProof[0].Proof).To(BeEmpty())
+		value, err := trie.VerifyProof(root, crypto.Keccak256(absent.Bytes()), toBytes(proof.AccountProof))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(BeNil())
+	})
+
+	It("returns error if state trie is unavailable", func() {
+		_, err := prover.ProveAccount(common.HexToHash("0x123"), address, nil)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func toBytes(proof []hexutil.Bytes) [][]byte {
+	var nodes [][]byte
+	for _, node := range proof {
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
@@ -1,59 +1,226 @@
 package level
 
 import (
+	"context"
+	"sync"
+
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/opentracing/opentracing-go"
 	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/trie"
 )
 
 type IStateTrieReader interface {
-	GetStateAndStorageTrieNodes(stateRoot common.Hash) (stateTrieNodes, storageTrieNodes [][]byte, err error)
+	GetAccountTrieNodes(ctx context.Context, stateRoot common.Hash, addresses []common.Address) (stateTrieNodes [][]byte, storageTrieNodes []StorageTrieNode, codes [][]byte, err error)
+	GetStateAndStorageTrieNodes(ctx context.Context, stateRoot common.Hash) (stateTrieNodes [][]byte, storageTrieNodes []StorageTrieNode, err error)
 }
 
 type StateTrieReader struct {
 	db                state.GethStateDatabase
+	decoder           rlp.Decoder
+	keySpace          KeySpaceRange
+	resolver          IPreimageResolver
 	storageTrieReader IStorageTrieReader
+	workers           int
 }
 
-func NewStateTrieReader(db state.GethStateDatabase, storageTrieReader IStorageTrieReader) *StateTrieReader {
+// NewStateTrieReader returns a reader of the state trie nodes in keySpace, and
+// the storage tries of their accounts. keySpace is walked by the given number
+// of concurrent workers, each covering its own part of it, and as many workers
+// read the storage tries of the accounts they find.
+func NewStateTrieReader(db state.GethStateDatabase, storageTrieReader IStorageTrieReader, decoder rlp.Decoder, resolver IPreimageResolver, keySpace KeySpaceRange, workers int) *StateTrieReader {
 	return &StateTrieReader{
 		db:                db,
+		decoder:           decoder,
+		keySpace:          keySpace,
+		resolver:          resolver,
 		storageTrieReader: storageTrieReader,
+		workers:           workers,
+	}
+}
+
+// GetAccountTrieNodes returns the state trie nodes on the path to each address,
+// plus the storage trie nodes and code of each address's account. Repeated
+// addresses are read once, and nodes shared by several paths are returned
+// once. Absent accounts contribute only the path proving their absence.
+func (str *StateTrieReader) GetAccountTrieNodes(ctx context.Context, stateRoot common.Hash, addresses []common.Address) (stateTrieNodes [][]byte, storageTrieNodes []StorageTrieNode, codes [][]byte, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "trie.traverse", opentracing.Tag{Key: "addresses", Value: len(addresses)})
+	defer span.Finish()
+	stateTrie, err := str.db.OpenTrie(stateRoot)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	seen := make(map[common.Hash]bool)
+	read := make(map[common.Address]bool)
+	for i := range addresses {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, nil, err
+		}
+		address := addresses[i]
+		if read[address] {
+			continue
+		}
+		read[address] = true
+		addressHash := crypto.Keccak256(address.Bytes())
+		path, err := stateTrie.Prove(addressHash)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, node := range path {
+			nodeHash := crypto.Keccak256Hash(node)
+			if !seen[nodeHash] {
+				seen[nodeHash] = true
+				stateTrieNodes = append(stateTrieNodes, node)
+			}
+		}
+		leaf, err := trie.VerifyProof(stateRoot, addressHash, path)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if leaf == nil {
+			continue
+		}
+		accountStorageTrieNodes, err := str.storageTrieReader.GetStorageTrie(ctx, common.BytesToHash(addressHash), &address, leaf)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		storageTrieNodes = append(storageTrieNodes, accountStorageTrieNodes...)
+		code, err := str.getCode(common.BytesToHash(addressHash), leaf)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if code != nil {
+			codes = append(codes, code)
+		}
 	}
+	return stateTrieNodes, storageTrieNodes, codes, nil
 }
 
-func (str *StateTrieReader) GetStateAndStorageTrieNodes(stateRoot common.Hash) (stateTrieNodes, storageTrieNodes [][]byte, err error) {
-	trieDb := str.db.TrieDB()
-	// fetch and append state root node
-	stateRootNode, err := trieDb.Node(stateRoot)
+// getCode returns the code of the account in stateTrieLeafNode, or nil if it has none
+func (str *StateTrieReader) getCode(addressHash common.Hash, stateTrieLeafNode []byte) ([]byte, error) {
+	account, err := decodeAccount(str.decoder, stateTrieLeafNode)
 	if err != nil {
-		return stateTrieNodes, storageTrieNodes, err
+		return nil, err
+	}
+	if account.CodeHash == crypto.Keccak256Hash(nil) {
+		return nil, nil
 	}
-	stateTrieNodes = append(stateTrieNodes, stateRootNode)
+	return str.db.Database().ContractCode(addressHash, account.CodeHash)
+}
 
-	// fetch and append remaining nodes in the state trie
+// GetStateAndStorageTrieNodes returns the nodes of the state trie in the
+// reader's key space, and every node in the storage tries of their accounts.
+// The key space is walked in partitions concurrently, the storage tries of the
+// accounts they find are read by a pool of as many workers, and the results
+// are returned in the order of a single walk.
+func (str *StateTrieReader) GetStateAndStorageTrieNodes(ctx context.Context, stateRoot common.Hash) (stateTrieNodes [][]byte, storageTrieNodes []StorageTrieNode, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "trie.traverse", opentracing.Tag{Key: "key_space", Value: str.keySpace.String()})
+	defer span.Finish()
+	// read storage tries as the partitions find their accounts
+	workers := str.workers
+	if workers < 1 {
+		workers = 1
+	}
+	storageReads := make(chan *storageRead)
+	var storageWorkers sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		storageWorkers.Add(1)
+		go func() {
+			defer storageWorkers.Done()
+			for read := range storageReads {
+				read.storageTrieNodes, read.err = str.storageTrieReader.GetStorageTrie(ctx, read.addressHash, str.resolver.ResolveAddress(read.addressHash), read.leaf)
+				read.done.Done()
+			}
+		}()
+	}
+
+	// fetch and append the nodes in the state trie, starting from the root node
+	// at the empty path when the key space does
+	partitions := str.keySpace.split(str.workers)
+	results := make([]partitionNodes, len(partitions))
+	var wg sync.WaitGroup
+	for i, partition := range partitions {
+		wg.Add(1)
+		go func(i int, partition KeySpaceRange) {
+			defer wg.Done()
+			results[i] = str.readPartition(ctx, stateRoot, partition, storageReads)
+		}(i, partition)
+	}
+	wg.Wait()
+	close(storageReads)
+	storageWorkers.Wait()
+	for _, result := range results {
+		if result.err != nil {
+			return stateTrieNodes, storageTrieNodes, result.err
+		}
+		stateTrieNodes = append(stateTrieNodes, result.stateTrieNodes...)
+		storageTrieNodes = append(storageTrieNodes, result.storageTrieNodes...)
+	}
+	return stateTrieNodes, storageTrieNodes, nil
+}
+
+type partitionNodes struct {
+	stateTrieNodes   [][]byte
+	storageTrieNodes []StorageTrieNode
+	err              error
+}
+
+// storageRead is the storage trie of an account in a state trie leaf, read by
+// the storage workers while the partition that found it walks on
+type storageRead struct {
+	addressHash      common.Hash
+	leaf             []byte
+	storageTrieNodes []StorageTrieNode
+	err              error
+	done             *sync.WaitGroup
+}
+
+func (str *StateTrieReader) readPartition(ctx context.Context, stateRoot common.Hash, partition KeySpaceRange, storageReads chan<- *storageRead) (result partitionNodes) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "trie.partition", opentracing.Tag{Key: "key_space", Value: partition.String()})
+	defer func() {
+		span.SetTag("state_nodes", len(result.stateTrieNodes))
+		span.SetTag("storage_nodes", len(result.storageTrieNodes))
+		span.Finish()
+	}()
 	stateTrie, err := str.db.OpenTrie(stateRoot)
 	if err != nil {
-		return stateTrieNodes, storageTrieNodes, err
+		result.err = err
+		return result
 	}
-	stateTrieIterator := stateTrie.NodeIterator(nil)
-	for stateTrieIterator.Next(true) {
+	var reads []*storageRead
+	var pending sync.WaitGroup
+	result.err = partition.walk(ctx, stateTrie, func(stateTrieIterator trie.GethTrieNodeIterator) error {
 		if stateTrieIterator.Leaf() {
 			node := stateTrieIterator.LeafBlob()
-			stateTrieNodes = append(stateTrieNodes, node)
-			// fetch and append storage trie nodes for state trie leaf (account snapshot)
-			accountStorageTrieNodes, err := str.storageTrieReader.GetStorageTrie(node)
-			if err != nil {
-				return stateTrieNodes, storageTrieNodes, err
-			}
-			storageTrieNodes = append(storageTrieNodes, accountStorageTrieNodes...)
-		} else {
-			nodeKey := stateTrieIterator.Hash()
-			node, err := str.db.TrieDB().Node(nodeKey)
-			if err != nil {
-				return stateTrieNodes, storageTrieNodes, err
-			}
-			stateTrieNodes = append(stateTrieNodes, node)
+			result.stateTrieNodes = append(result.stateTrieNodes, node)
+			// queue the storage trie nodes for state trie leaf (account snapshot)
+			read := &storageRead{addressHash: common.BytesToHash(stateTrieIterator.LeafKey()), leaf: node, done: &pending}
+			reads = append(reads, read)
+			pending.Add(1)
+			storageReads <- read
+			return nil
 		}
+		nodeKey := stateTrieIterator.Hash()
+		node, err := str.db.TrieDB().Node(nodeKey)
+		if err != nil {
+			return err
+		}
+		result.stateTrieNodes = append(result.stateTrieNodes, node)
+		return nil
+	})
+	pending.Wait()
+	if result.err != nil {
+		return result
 	}
-	return stateTrieNodes, storageTrieNodes, nil
+	// append storage trie nodes in the order their accounts were walked
+	for _, read := range reads {
+		if read.err != nil {
+			result.err = read.err
+			return result
+		}
+		result.storageTrieNodes = append(result.storageTrieNodes, read.storageTrieNodes...)
+	}
+	return result
 }
@@ -1,9 +1,19 @@
 package level_test
 
 import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	geth_state "github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	rawdb_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/rawdb"
+	state_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
 	"github.com/vulcanize/eth-block-extractor/test_helpers"
 	level_wrapper "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db/level"
 	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/wrappers/core/state"
@@ -15,14 +25,15 @@ var _ = Describe("State trie reader", func() {
 		db := state.NewMockStateDatabase()
 		trieDB := db.CreateFakeUnderlyingDatabase()
 		db.ReturnDB = trieDB
-		mockIteratror := trie.NewMockIterator(0)
+		mockIteratror := trie.NewMockIterator(1)
+		mockIteratror.SetReturnHash(test_helpers.FakeHash)
 		mockTrie := state.NewMockTrie()
 		mockTrie.SetReturnIterator(mockIteratror)
 		db.ReturnTrie = mockTrie
 		mockStorageTrieReader := level_wrapper.NewMockStorageTrieReader()
-		reader := level.NewStateTrieReader(db, mockStorageTrieReader)
+		reader := level.NewStateTrieReader(db, mockStorageTrieReader, rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver(), level.FullKeySpace, 1)
 
-		stateTrieNodes, _, err := reader.GetStateAndStorageTrieNodes(test_helpers.FakeHash)
+		stateTrieNodes, _, err := reader.GetStateAndStorageTrieNodes(context.Background(), test_helpers.FakeHash)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(stateTrieNodes).To(ContainElement(test_helpers.FakeTrieNode))
@@ -38,12 +49,12 @@ var _ = Describe("State trie reader", func() {
 		mockTrie.SetReturnIterator(mockIteratror)
 		db.ReturnTrie = mockTrie
 		mockStorageTrieReader := level_wrapper.NewMockStorageTrieReader()
-		reader := level.NewStateTrieReader(db, mockStorageTrieReader)
+		reader := level.NewStateTrieReader(db, mockStorageTrieReader, rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver(), level.FullKeySpace, 1)
 
-		stateTrieNodes, storageTrieNodes, err := reader.GetStateAndStorageTrieNodes(test_helpers.FakeHash)
+		stateTrieNodes, storageTrieNodes, err := reader.GetStateAndStorageTrieNodes(context.Background(), test_helpers.FakeHash)
 
 		Expect(err).NotTo(HaveOccurred())
-		Expect(len(stateTrieNodes)).To(Equal(3))
+		Expect(len(stateTrieNodes)).To(Equal(2))
 		Expect(len(storageTrieNodes)).To(BeZero())
 	})
 
@@ -57,11 +68,254 @@ var _ = Describe("State trie reader", func() {
 		mockTrie.SetReturnIterator(mockIteratror)
 		db.ReturnTrie = mockTrie
 		mockStorageTrieReader := level_wrapper.NewMockStorageTrieReader()
-		reader := level.NewStateTrieReader(db, mockStorageTrieReader)
+		reader := level.NewStateTrieReader(db, mockStorageTrieReader, rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver(), level.FullKeySpace, 1)
 
-		_, _, err := reader.GetStateAndStorageTrieNodes(test_helpers.FakeHash)
+		_, _, err := reader.GetStateAndStorageTrieNodes(context.Background(), test_helpers.FakeHash)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockStorageTrieReader.AssertGetStorageTrieCalled()
+		mockStorageTrieReader.AssertGetStorageTrieCalledWith(test_helpers.FakeHash, nil)
+	})
+
+	It("returns error if iterating the state trie fails", func() {
+		db := state.NewMockStateDatabase()
+		trieDB := db.CreateFakeUnderlyingDatabase()
+		db.ReturnDB = trieDB
+		mockIteratror := trie.NewMockIterator(1)
+		mockIteratror.SetReturnHash(test_helpers.FakeHash)
+		mockIteratror.SetReturnErr(test_helpers.FakeError)
+		mockTrie := state.NewMockTrie()
+		mockTrie.SetReturnIterator(mockIteratror)
+		db.ReturnTrie = mockTrie
+		reader := level.NewStateTrieReader(db, level_wrapper.NewMockStorageTrieReader(), rlp.RlpDecoder{}, level_wrapper.NewMockPreimageResolver(), level.FullKeySpace, 1)
+
+		_, _, err := reader.GetStateAndStorageTrieNodes(context.Background(), test_helpers.FakeHash)
+
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+
+	Describe("walking the state trie in partitions", func() {
+		var (
+			stateDatabase              *state_wrapper.Database
+			resolver                   *level.PreimageResolver
+			root                       common.Hash
+			storageTrieReader          *level.StorageTrieReader
+			sequentialStateTrieNodes   [][]byte
+			sequentialStorageTrieNodes []level.StorageTrieNode
+		)
+
+		BeforeEach(func() {
+			ethDB := rawdb.NewMemoryDatabase()
+			stateDatabase = state_wrapper.NewDatabase(ethDB)
+			resolver = level.NewPreimageResolver(stateDatabase, rawdb_wrapper.NewPreimages(ethDB))
+			stateDB, err := geth_state.New(common.Hash{}, stateDatabase.Database())
+			Expect(err).NotTo(HaveOccurred())
+			for i := int64(0); i < 200; i++ {
+				address := common.BigToAddress(big.NewInt(i + 1))
+				stateDB.SetBalance(address, big.NewInt(i+1))
+				if i%10 == 0 {
+					stateDB.SetState(address, common.BigToHash(big.NewInt(i)), common.HexToHash("0x1"))
+				}
+			}
+			root, err = stateDB.Commit(true)
+			Expect(err).NotTo(HaveOccurred())
+			storageTrieReader = level.NewStorageTrieReader(stateDatabase, rlp.RlpDecoder{}, resolver)
+			sequentialStateTrieNodes, sequentialStorageTrieNodes, err = level.NewStateTrieReader(stateDatabase, storageTrieReader, rlp.RlpDecoder{}, resolver, level.FullKeySpace, 1).GetStateAndStorageTrieNodes(context.Background(), root)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the same nodes in the same order as a single walk", func() {
+			for _, workers := range []int{2, 3, 16, 300} {
+				reader := level.NewStateTrieReader(stateDatabase, storageTrieReader, rlp.RlpDecoder{}, resolver, level.FullKeySpace, workers)
+
+				stateTrieNodes, storageTrieNodes, err := reader.GetStateAndStorageTrieNodes(context.Background(), root)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stateTrieNodes).To(Equal(sequentialStateTrieNodes))
+				Expect(storageTrieNodes).To(Equal(sequentialStorageTrieNodes))
+			}
+		})
+
+		It("stops reading when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			for _, workers := range []int{1, 4} {
+				reader := level.NewStateTrieReader(stateDatabase, storageTrieReader, rlp.RlpDecoder{}, resolver, level.FullKeySpace, workers)
+
+				_, _, err := reader.GetStateAndStorageTrieNodes(ctx, root)
+
+				Expect(err).To(MatchError(context.Canceled))
+			}
+		})
+
+		It("returns storage trie nodes with the address of their account", func() {
+			Expect(sequentialStorageTrieNodes).NotTo(BeEmpty())
+			owners := make(map[common.Hash]bool)
+			for _, node := range sequentialStorageTrieNodes {
+				Expect(node.Address).NotTo(BeNil())
+				Expect(crypto.Keccak256Hash(node.Address.Bytes())).To(Equal(node.AddressHash))
+				owners[node.AddressHash] = true
+			}
+			Expect(owners).To(HaveLen(20))
+		})
+
+		It("returns disjoint shards that together make up the whole state", func() {
+			for _, count := range []int{2, 16, 300} {
+				var stateTrieNodes [][]byte
+				var storageTrieNodes []level.StorageTrieNode
+				for index := 0; index < count; index++ {
+					keySpace, err := level.NewShardRange(index, count)
+					Expect(err).NotTo(HaveOccurred())
+					reader := level.NewStateTrieReader(stateDatabase, storageTrieReader, rlp.RlpDecoder{}, resolver, keySpace, 2)
+
+					shardStateTrieNodes, shardStorageTrieNodes, err := reader.GetStateAndStorageTrieNodes(context.Background(), root)
+
+					Expect(err).NotTo(HaveOccurred())
+					stateTrieNodes = append(stateTrieNodes, shardStateTrieNodes...)
+					storageTrieNodes = append(storageTrieNodes, shardStorageTrieNodes...)
+				}
+				Expect(stateTrieNodes).To(Equal(sequentialStateTrieNodes))
+				Expect(storageTrieNodes).To(Equal(sequentialStorageTrieNodes))
+			}
+		})
+
+		It("returns the state root node once, in the first shard", func() {
+			rootCount := func(nodes [][]byte) int {
+				count := 0
+				for _, node := range nodes {
+					if crypto.Keccak256Hash(node) == root {
+						count++
+					}
+				}
+				return count
+			}
+			Expect(rootCount(sequentialStateTrieNodes)).To(Equal(1))
+			for index := 0; index < 16; index++ {
+				keySpace, err := level.NewShardRange(index, 16)
+				Expect(err).NotTo(HaveOccurred())
+				reader := level.NewStateTrieReader(stateDatabase, storageTrieReader, rlp.RlpDecoder{}, resolver, keySpace, 2)
+
+				stateTrieNodes, _, err := reader.GetStateAndStorageTrieNodes(context.Background(), root)
+
+				Expect(err).NotTo(HaveOccurred())
+				if index == 0 {
+					Expect(rootCount(stateTrieNodes)).To(Equal(1))
+					Expect(crypto.Keccak256Hash(stateTrieNodes[0])).To(Equal(root))
+				} else {
+					Expect(rootCount(stateTrieNodes)).To(BeZero())
+				}
+			}
+		})
+
+		It("returns the state trie nodes under a path prefix", func() {
+			keySpace, err := level.NewPrefixRange("0x3")
+			Expect(err).NotTo(HaveOccurred())
+			reader := level.NewStateTrieReader(stateDatabase, storageTrieReader, rlp.RlpDecoder{}, resolver, keySpace, 1)
+
+			stateTrieNodes, _, err := reader.GetStateAndStorageTrieNodes(context.Background(), root)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stateTrieNodes).NotTo(BeEmpty())
+			Expect(len(stateTrieNodes)).To(BeNumerically("<", len(sequentialStateTrieNodes)))
+			for _, node := range stateTrieNodes {
+				Expect(sequentialStateTrieNodes).To(ContainElement(node))
+				Expect(crypto.Keccak256Hash(node)).NotTo(Equal(root))
+			}
+		})
+	})
+
+	Describe("reading selected accounts", func() {
+		var (
+			contract      = common.HexToAddress("0xabc")
+			other         = common.HexToAddress("0xdef")
+			code          = []byte{0x60, 0x80}
+			stateDatabase *state_wrapper.Database
+			resolver      *level.PreimageResolver
+			root          common.Hash
+			reader        *level.StateTrieReader
+		)
+
+		BeforeEach(func() {
+			ethDB := rawdb.NewMemoryDatabase()
+			stateDatabase = state_wrapper.NewDatabase(ethDB)
+			resolver = level.NewPreimageResolver(stateDatabase, rawdb_wrapper.NewPreimages(ethDB))
+			stateDB, err := geth_state.New(common.Hash{}, stateDatabase.Database())
+			Expect(err).NotTo(HaveOccurred())
+			stateDB.SetCode(contract, code)
+			stateDB.SetState(contract, common.HexToHash("0x1"), common.HexToHash("0x2"))
+			for i := int64(0); i < 20; i++ {
+				stateDB.SetBalance(common.BigToAddress(big.NewInt(i+1)), big.NewInt(i+1))
+			}
+			stateDB.SetBalance(other, big.NewInt(1))
+			root, err = stateDB.Commit(true)
+			Expect(err).NotTo(HaveOccurred())
+			storageTrieReader := level.NewStorageTrieReader(stateDatabase, rlp.RlpDecoder{}, resolver)
+			reader = level.NewStateTrieReader(stateDatabase, storageTrieReader, rlp.RlpDecoder{}, resolver, level.FullKeySpace, 1)
+		})
+
+		It("returns only the state trie path to each address", func() {
+			allStateTrieNodes, _, err := reader.GetStateAndStorageTrieNodes(context.Background(), root)
+			Expect(err).NotTo(HaveOccurred())
+
+			stateTrieNodes, _, _, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{contract})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stateTrieNodes).NotTo(BeEmpty())
+			Expect(len(stateTrieNodes)).To(BeNumerically("<", len(allStateTrieNodes)))
+			Expect(crypto.Keccak256Hash(stateTrieNodes[0])).To(Equal(root))
+		})
+
+		It("returns shared path nodes once", func() {
+			contractNodes, _, _, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{contract})
+			Expect(err).NotTo(HaveOccurred())
+			otherNodes, _, _, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{other})
+			Expect(err).NotTo(HaveOccurred())
+
+			bothNodes, _, _, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{contract, other})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(bothNodes)).To(BeNumerically("<", len(contractNodes)+len(otherNodes)))
+		})
+
+		It("reads repeated addresses once", func() {
+			stateTrieNodes, storageTrieNodes, codes, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{contract})
+			Expect(err).NotTo(HaveOccurred())
+
+			repeatedStateTrieNodes, repeatedStorageTrieNodes, repeatedCodes, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{contract, contract})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repeatedStateTrieNodes).To(Equal(stateTrieNodes))
+			Expect(repeatedStorageTrieNodes).To(Equal(storageTrieNodes))
+			Expect(repeatedCodes).To(Equal(codes))
+		})
+
+		It("returns storage trie nodes and code of selected accounts", func() {
+			_, storageTrieNodes, codes, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{contract})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storageTrieNodes).NotTo(BeEmpty())
+			for _, node := range storageTrieNodes {
+				Expect(node.AddressHash).To(Equal(crypto.Keccak256Hash(contract.Bytes())))
+				Expect(node.Address).To(Equal(&contract))
+			}
+			Expect(codes).To(Equal([][]byte{code}))
+		})
+
+		It("returns no storage or code for accounts without them", func() {
+			_, storageTrieNodes, codes, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{other})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(storageTrieNodes).To(BeEmpty())
+			Expect(codes).To(BeEmpty())
+		})
+
+		It("returns the path proving an absent account's absence", func() {
+			stateTrieNodes, storageTrieNodes, codes, err := reader.GetAccountTrieNodes(context.Background(), root, []common.Address{common.HexToAddress("0x999")})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stateTrieNodes).NotTo(BeEmpty())
+			Expect(storageTrieNodes).To(BeEmpty())
+			Expect(codes).To(BeEmpty())
+		})
 	})
 })
@@ -2,30 +2,51 @@ package level
 
 import (
 	"bytes"
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/state"
 	state_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
 	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
+	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/trie"
 )
 
 var EmptyStorageTrieRoot = []byte{86, 232, 31, 23, 27, 204, 85, 166, 255, 131, 69, 230, 146, 192, 248, 110, 91, 72, 224, 27, 153, 108, 173, 192, 1, 98, 47, 181, 227, 99, 180, 33}
 
+// StorageTrieNode is a node of an account's storage trie, with the account it
+// belongs to and the nibbles of its path from the storage root. Address is
+// only set if the preimage of AddressHash is known, and Key only for a leaf
+// whose slot key's preimage is known.
+type StorageTrieNode struct {
+	AddressHash common.Hash     `json:"addressHash"`
+	Address     *common.Address `json:"address,omitempty"`
+	Key         *common.Hash    `json:"key,omitempty"`
+	Path        hexutil.Bytes   `json:"path"`
+	Node        hexutil.Bytes   `json:"node"`
+}
+
 type IStorageTrieReader interface {
-	GetStorageTrie(stateTrieLeafNode []byte) (storageTrieResults [][]byte, err error)
+	GetStorageTrie(ctx context.Context, addressHash common.Hash, address *common.Address, stateTrieLeafNode []byte) (storageTrieResults []StorageTrieNode, err error)
 }
 
 type StorageTrieReader struct {
-	db      state_wrapper.GethStateDatabase
-	decoder rlp.Decoder
+	db       state_wrapper.GethStateDatabase
+	decoder  rlp.Decoder
+	resolver IPreimageResolver
 }
 
-func NewStorageTrieReader(db state_wrapper.GethStateDatabase, decoder rlp.Decoder) *StorageTrieReader {
+func NewStorageTrieReader(db state_wrapper.GethStateDatabase, decoder rlp.Decoder, resolver IPreimageResolver) *StorageTrieReader {
 	return &StorageTrieReader{
-		db:      db,
-		decoder: decoder,
+		db:       db,
+		decoder:  decoder,
+		resolver: resolver,
 	}
 }
 
-func (stc *StorageTrieReader) GetStorageTrie(stateTrieLeafNode []byte) (storageTrieResults [][]byte, err error) {
+// GetStorageTrie returns the storage trie nodes of the account in
+// stateTrieLeafNode, whose key in the state trie is addressHash
+func (stc *StorageTrieReader) GetStorageTrie(ctx context.Context, addressHash common.Hash, address *common.Address, stateTrieLeafNode []byte) (storageTrieResults []StorageTrieNode, err error) {
 	trieDb := stc.db.TrieDB()
 	var account state.Account
 	err = stc.decoder.Decode(stateTrieLeafNode, &account)
@@ -36,28 +57,41 @@ func (stc *StorageTrieReader) GetStorageTrie(stateTrieLeafNode []byte) (storageT
 	if bytes.Equal(EmptyStorageTrieRoot, account.Root.Bytes()) {
 		return storageTrieResults, err
 	}
-	// if storage trie root not empty, fetch and append root node
-	storageRootNode, err := trieDb.Node(account.Root)
-	if err != nil {
-		return storageTrieResults, err
-	}
-	storageTrieResults = append(storageTrieResults, storageRootNode)
 	storageTrie, err := stc.db.OpenTrie(account.Root)
 	if err != nil {
 		return storageTrieResults, err
 	}
-	storageTrieIterator := storageTrie.NodeIterator(account.Root.Bytes())
-	for storageTrieIterator.Next(true) {
+	// storage tries are read whole, by whichever reader owns their account,
+	// starting from the root node at the empty path. Only state tries are
+	// partitioned: seeking a storage trie's iterator to its root hash, as it
+	// once was, treats the hash as a key and skips the slots ordered before it.
+	err = FullKeySpace.walk(ctx, storageTrie, func(storageTrieIterator trie.GethTrieNodeIterator) error {
+		path := storageTriePath(storageTrieIterator.Path())
 		if storageTrieIterator.Leaf() {
-			storageTrieResults = append(storageTrieResults, storageTrieIterator.LeafBlob())
-		} else {
-			nextStorageHash := storageTrieIterator.Hash()
-			nextStorageNode, err := trieDb.Node(nextStorageHash)
-			if err != nil {
-				return storageTrieResults, err
-			}
-			storageTrieResults = append(storageTrieResults, nextStorageNode)
+			key := stc.resolver.ResolveSlotKey(common.BytesToHash(storageTrieIterator.LeafKey()))
+			storageTrieResults = append(storageTrieResults, StorageTrieNode{AddressHash: addressHash, Address: address, Key: key, Path: path, Node: storageTrieIterator.LeafBlob()})
+			return nil
 		}
-	}
+		nextStorageHash := storageTrieIterator.Hash()
+		nextStorageNode, err := trieDb.Node(nextStorageHash)
+		if err != nil {
+			return err
+		}
+		storageTrieResults = append(storageTrieResults, StorageTrieNode{AddressHash: addressHash, Address: address, Path: path, Node: nextStorageNode})
+		return nil
+	})
 	return storageTrieResults, err
 }
+
+// storageTriePath copies an iterator's path, which is only valid until it
+// moves, without a leaf's terminator
+func storageTriePath(iteratorPath []byte) []byte {
+	path := make([]byte, 0, len(iteratorPath))
+	for _, nibble := range iteratorPath {
+		if nibble > 0x0f {
+			break
+		}
+		path = append(path, nibble)
+	}
+	return path
+}
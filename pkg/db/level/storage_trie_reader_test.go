@@ -1,12 +1,23 @@
 package level_test
 
 import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	real_rawdb "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/rawdb"
+	real_state "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	real_rlp "github.com/vulcanize/eth-block-extractor/pkg/wrappers/rlp"
 	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	level_wrapper "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db/level"
 	state_wrapper "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/wrappers/core/state"
 	mock_rlp "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/wrappers/rlp"
 	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/wrappers/trie"
@@ -25,9 +36,9 @@ var _ = Describe("Storage trie reader", func() {
 		decoder := mock_rlp.NewMockDecoder()
 		acct := &test_helpers.FakeStateAccount
 		decoder.SetReturnOut(acct)
-		reader := level.NewStorageTrieReader(db, decoder)
+		reader := level.NewStorageTrieReader(db, decoder, level_wrapper.NewMockPreimageResolver())
 
-		_, err := reader.GetStorageTrie(test_helpers.FakeStateLeaf)
+		_, err := reader.GetStorageTrie(context.Background(), test_helpers.FakeHash, nil, test_helpers.FakeStateLeaf)
 
 		Expect(err).NotTo(HaveOccurred())
 		decoder.AssertDecodeCalledWith(test_helpers.FakeStateLeaf, &state.Account{})
@@ -37,16 +48,17 @@ var _ = Describe("Storage trie reader", func() {
 		db := state_wrapper.NewMockStateDatabase()
 		trieDb := db.CreateFakeUnderlyingDatabase()
 		db.ReturnDB = trieDb
-		mockIteratror := trie.NewMockIterator(0)
+		mockIteratror := trie.NewMockIterator(1)
+		mockIteratror.SetReturnHash(test_helpers.FakeHash)
 		mockTrie := state_wrapper.NewMockTrie()
 		mockTrie.SetReturnIterator(mockIteratror)
 		db.ReturnTrie = mockTrie
 		decoder := mock_rlp.NewMockDecoder()
 		acct := &test_helpers.FakeStateAccount
 		decoder.SetReturnOut(acct)
-		reader := level.NewStorageTrieReader(db, decoder)
+		reader := level.NewStorageTrieReader(db, decoder, level_wrapper.NewMockPreimageResolver())
 
-		storageTrieNodes, err := reader.GetStorageTrie(test_helpers.FakeStateLeaf)
+		storageTrieNodes, err := reader.GetStorageTrie(context.Background(), test_helpers.FakeHash, nil, test_helpers.FakeStateLeaf)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(storageTrieNodes)).To(Equal(1))
@@ -56,19 +68,67 @@ var _ = Describe("Storage trie reader", func() {
 		db := state_wrapper.NewMockStateDatabase()
 		trieDb := db.CreateFakeUnderlyingDatabase()
 		db.ReturnDB = trieDb
-		mockIteratror := trie.NewMockIterator(1)
+		mockIteratror := trie.NewMockIterator(2)
 		mockIteratror.SetIncludeLeaf()
+		mockIteratror.SetReturnHash(test_helpers.FakeHash)
 		mockTrie := state_wrapper.NewMockTrie()
 		mockTrie.SetReturnIterator(mockIteratror)
 		db.ReturnTrie = mockTrie
 		decoder := mock_rlp.NewMockDecoder()
 		acct := &test_helpers.FakeStateAccount
 		decoder.SetReturnOut(acct)
-		reader := level.NewStorageTrieReader(db, decoder)
+		reader := level.NewStorageTrieReader(db, decoder, level_wrapper.NewMockPreimageResolver())
 
-		storageTrieNodes, err := reader.GetStorageTrie(test_helpers.FakeStateLeaf)
+		storageTrieNodes, err := reader.GetStorageTrie(context.Background(), test_helpers.FakeHash, nil, test_helpers.FakeStateLeaf)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(len(storageTrieNodes)).To(Equal(2))
 	})
+
+	It("returns every leaf of the storage trie", func() {
+		ethDB := rawdb.NewMemoryDatabase()
+		stateDatabase := real_state.NewDatabase(ethDB)
+		stateDB, err := state.New(common.Hash{}, stateDatabase.Database())
+		Expect(err).NotTo(HaveOccurred())
+		address := common.HexToAddress("0xabc")
+		stateDB.SetNonce(address, 1)
+		slots := 50
+		for i := 1; i <= slots; i++ {
+			stateDB.SetState(address, common.BigToHash(big.NewInt(int64(i))), common.BigToHash(big.NewInt(int64(i))))
+		}
+		root, err := stateDB.Commit(true)
+		Expect(err).NotTo(HaveOccurred())
+		stateTrie, err := stateDatabase.Database().OpenTrie(root)
+		Expect(err).NotTo(HaveOccurred())
+		leaf, err := stateTrie.TryGet(address.Bytes())
+		Expect(err).NotTo(HaveOccurred())
+		reader := level.NewStorageTrieReader(stateDatabase, real_rlp.RlpDecoder{}, level.NewPreimageResolver(stateDatabase, real_rawdb.NewPreimages(ethDB)))
+
+		storageTrieNodes, err := reader.GetStorageTrie(context.Background(), crypto.Keccak256Hash(address.Bytes()), &address, leaf)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(storageTrieNodes[0].Path).To(BeEmpty())
+		Expect(crypto.Keccak256Hash(storageTrieNodes[0].Node)).To(Equal(stateDB.StorageTrie(address).Hash()))
+		leaves := 0
+		seen := make(map[common.Hash]bool)
+		for _, node := range storageTrieNodes {
+			Expect(seen[crypto.Keccak256Hash(node.Node)]).To(BeFalse())
+			seen[crypto.Keccak256Hash(node.Node)] = true
+			var value []byte
+			Expect(node.AddressHash).To(Equal(crypto.Keccak256Hash(address.Bytes())))
+			Expect(node.Address).To(Equal(&address))
+			for _, nibble := range node.Path {
+				Expect(nibble).To(BeNumerically("<=", 0x0f))
+			}
+			if rlp.DecodeBytes(node.Node, &value) == nil {
+				Expect(node.Path).To(HaveLen(64))
+				Expect(node.Key).NotTo(BeNil())
+				Expect(new(big.Int).SetBytes(value)).To(Equal(node.Key.Big()))
+				leaves++
+			} else {
+				Expect(node.Key).To(BeNil())
+			}
+		}
+		Expect(leaves).To(Equal(slots))
+	})
 })
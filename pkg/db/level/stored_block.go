@@ -0,0 +1,15 @@
+package level
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// StoredBlock is a block stored at some height, whether or not it is on the
+// canonical chain. Header is the header's RLP encoding.
+type StoredBlock struct {
+	Hash      common.Hash
+	Canonical bool
+	Header    []byte
+	Body      *types.Body
+}
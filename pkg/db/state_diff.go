@@ -0,0 +1,18 @@
+package db
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+// EmptyTrieRoot is the root of a trie with no entries, i.e. the state before genesis
+var EmptyTrieRoot = common.BytesToHash(level.EmptyStorageTrieRoot)
+
+// StateDiff holds the account and storage changes made by a block
+type StateDiff struct {
+	BlockNumber     int64               `json:"blockNumber"`
+	BlockHash       common.Hash         `json:"blockHash"`
+	ParentStateRoot common.Hash         `json:"parentStateRoot"`
+	StateRoot       common.Hash         `json:"stateRoot"`
+	Accounts        []level.AccountDiff `json:"accounts"`
+}
@@ -0,0 +1,13 @@
+package db
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+// BlockTraces holds the call trace of each transaction in a block
+type BlockTraces struct {
+	BlockNumber  int64                    `json:"blockNumber"`
+	BlockHash    common.Hash              `json:"blockHash"`
+	Transactions []level.TransactionTrace `json:"transactions"`
+}
@@ -0,0 +1,73 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// PublishedBlocks reads canonical blocks and their receipts back out of the
+// published IPLDs, resolving block numbers through the lookup
+type PublishedBlocks struct {
+	reader reader
+	lookup *Lookup
+}
+
+func NewPublishedBlocks(getter ipfs.Getter, lookup *Lookup) *PublishedBlocks {
+	return &PublishedBlocks{reader: reader{getter: getter}, lookup: lookup}
+}
+
+// GetBlock returns the canonical block numbered blockNumber with its receipts,
+// checked against the roots in its header
+func (b *PublishedBlocks) GetBlock(ctx context.Context, blockNumber int64) (*types.Block, types.Receipts, error) {
+	entry, ok := b.lookup.Block(blockNumber)
+	if !ok {
+		return nil, nil, fmt.Errorf("Block %d not indexed", blockNumber)
+	}
+	header, err := b.reader.getIndexedHeader(ctx, entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	transactions, err := b.reader.getTransactions(ctx, entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	if types.DeriveSha(types.Transactions(transactions)) != header.TxHash {
+		return nil, nil, fmt.Errorf("Transactions of block %d do not match its header", blockNumber)
+	}
+	if len(entry.ReceiptCids) != len(transactions) {
+		return nil, nil, fmt.Errorf("Receipts of block %d not indexed", blockNumber)
+	}
+	receipts, err := b.reader.getReceipts(ctx, entry, transactions)
+	if err != nil {
+		return nil, nil, err
+	}
+	if types.DeriveSha(types.Receipts(receipts)) != header.ReceiptHash {
+		return nil, nil, fmt.Errorf("Receipts of block %d do not match its header", blockNumber)
+	}
+	uncles, ok, err := b.reader.getUncles(ctx, entry, header)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, fmt.Errorf("Uncles of block %d not published or indexed", blockNumber)
+	}
+	return types.NewBlockWithHeader(header).WithBody(transactions, uncles), receipts, nil
+}
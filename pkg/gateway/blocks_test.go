@@ -0,0 +1,71 @@
+package gateway_test
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/gateway"
+	ipfs_mocks "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Published blocks", func() {
+	var (
+		getter *ipfs_mocks.MockGetter
+		lookup *gateway.Lookup
+		blocks *gateway.PublishedBlocks
+		block  publishedBlock
+	)
+
+	BeforeEach(func() {
+		getter = ipfs_mocks.NewMockGetter()
+		lookup = gateway.NewLookup()
+		blocks = gateway.NewPublishedBlocks(getter, lookup)
+		block = publishBlock(getter, lookup, common.Hash{}, newUncle())
+	})
+
+	It("returns the block with its uncles, transactions and receipts", func() {
+		result, receipts, err := blocks.GetBlock(context.Background(), 5)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Hash()).To(Equal(block.header.Hash()))
+		Expect(result.Uncles()).To(HaveLen(1))
+		Expect(result.Uncles()[0].Hash()).To(Equal(block.uncles[0].Hash()))
+		Expect(result.Transactions()).To(HaveLen(2))
+		Expect(receipts).To(HaveLen(2))
+		Expect(receipts[1].TxHash).To(Equal(block.transactions[1].Hash()))
+		Expect(receipts[1].GasUsed).To(Equal(uint64(60000)))
+	})
+
+	It("returns an error for blocks not indexed", func() {
+		_, _, err := blocks.GetBlock(context.Background(), 6)
+
+		Expect(err).To(MatchError("Block 6 not indexed"))
+	})
+
+	It("returns an error for blocks whose receipts are not indexed", func() {
+		lookup = gateway.NewLookup()
+		entry := block.entry
+		entry.ReceiptCids = nil
+		lookup.Add(entry)
+		blocks = gateway.NewPublishedBlocks(getter, lookup)
+
+		_, _, err := blocks.GetBlock(context.Background(), 5)
+
+		Expect(err).To(MatchError("Receipts of block 5 not indexed"))
+	})
+
+	It("returns an error for blocks whose uncles are not indexed", func() {
+		lookup = gateway.NewLookup()
+		entry := block.entry
+		entry.UncleCids = nil
+		lookup.Add(entry)
+		blocks = gateway.NewPublishedBlocks(getter, lookup)
+
+		_, _, err := blocks.GetBlock(context.Background(), 5)
+
+		Expect(err).To(MatchError("Uncles of block 5 not published or indexed"))
+	})
+})
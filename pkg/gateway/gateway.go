@@ -0,0 +1,293 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ipfs/go-cid"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+)
+
+// notFoundError is returned for objects that were not published or indexed
+type notFoundError struct {
+	message string
+}
+
+func (e notFoundError) Error() string {
+	return e.message
+}
+
+func notFound(format string, args ...interface{}) error {
+	return notFoundError{message: fmt.Sprintf(format, args...)}
+}
+
+// badRequestError is returned for malformed numbers, hashes and CIDs
+type badRequestError struct {
+	message string
+}
+
+func (e badRequestError) Error() string {
+	return e.message
+}
+
+func badRequest(format string, args ...interface{}) error {
+	return badRequestError{message: fmt.Sprintf(format, args...)}
+}
+
+// Gateway is a read-only HTTP gateway to the Ethereum IPLDs published to an
+// IPFS repo:
+//
+//	GET /block/<number>            the canonical block's header, uncles, transactions and receipts
+//	GET /header/<cid>              a block header
+//	GET /tx/<hash>                 a transaction
+//	GET /receipt/<hash>            a transaction's receipt
+//	GET /state/<root>/<address>    an account in the state trie with root
+//
+// Responses are decoded JSON, or the raw RLP with ?format=rlp or an Accept
+// header of application/octet-stream.
+type Gateway struct {
+	reader reader
+	lookup *Lookup
+	mux    *http.ServeMux
+}
+
+func NewGateway(getter ipfs.Getter, lookup *Lookup) *Gateway {
+	g := &Gateway{reader: reader{getter: getter}, lookup: lookup, mux: http.NewServeMux()}
+	g.handle("/block/", g.block)
+	g.handle("/header/", g.header)
+	g.handle("/tx/", g.transaction)
+	g.handle("/receipt/", g.receipt)
+	g.handle("/state/", g.account)
+	return g
+}
+
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// response is an object's raw RLP and its decoded form
+type response struct {
+	raw     []byte
+	decoded interface{}
+}
+
+func (g *Gateway) handle(prefix string, get func(ctx context.Context, path string) (response, error)) {
+	g.mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeError(w, http.StatusMethodNotAllowed, errors.New("Method not allowed"))
+			return
+		}
+		raw, err := wantsRLP(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		resp, err := get(r.Context(), strings.TrimPrefix(r.URL.Path, prefix))
+		switch err.(type) {
+		case nil:
+		case notFoundError:
+			writeError(w, http.StatusNotFound, err)
+			return
+		case badRequestError:
+			writeError(w, http.StatusBadRequest, err)
+			return
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if raw {
+			// blocks indexed before their uncles were published have no RLP
+			if resp.raw == nil {
+				writeError(w, http.StatusNotAcceptable, errors.New("RLP not available: uncles not published or indexed"))
+				return
+			}
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(resp.raw)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp.decoded)
+	})
+}
+
+func wantsRLP(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("format") {
+	case "rlp":
+		return true, nil
+	case "json":
+		return false, nil
+	case "":
+		return r.Header.Get("Accept") == "application/octet-stream", nil
+	default:
+		return false, badRequest("Unknown format %s: use json or rlp", r.URL.Query().Get("format"))
+	}
+}
+
+// blockResponse is a block's header, uncles, transactions and receipts.
+// Uncles and receipts are omitted unless they were published and indexed.
+type blockResponse struct {
+	Header       *types.Header        `json:"header"`
+	Uncles       []*types.Header      `json:"uncles,omitempty"`
+	Transactions []*types.Transaction `json:"transactions"`
+	Receipts     []*types.Receipt     `json:"receipts,omitempty"`
+}
+
+func (g *Gateway) block(ctx context.Context, path string) (response, error) {
+	blockNumber, err := strconv.ParseInt(path, 10, 64)
+	if err != nil {
+		return response{}, badRequest("Invalid block number %s", path)
+	}
+	entry, ok := g.lookup.Block(blockNumber)
+	if !ok {
+		return response{}, notFound("Block %d not indexed", blockNumber)
+	}
+	header, err := g.reader.getIndexedHeader(ctx, entry)
+	if err != nil {
+		return response{}, err
+	}
+	transactions, err := g.reader.getTransactions(ctx, entry)
+	if err != nil {
+		return response{}, err
+	}
+	receipts, err := g.reader.getReceipts(ctx, entry, transactions)
+	if err != nil {
+		return response{}, err
+	}
+	uncles, ok, err := g.reader.getUncles(ctx, entry, header)
+	if err != nil {
+		return response{}, err
+	}
+	var raw []byte
+	// blocks indexed before their uncles were published have no uncles or RLP
+	if ok {
+		raw, err = rlp.EncodeToBytes(types.NewBlockWithHeader(header).WithBody(transactions, uncles))
+		if err != nil {
+			return response{}, err
+		}
+	} else {
+		uncles = nil
+	}
+	return response{raw: raw, decoded: blockResponse{Header: header, Uncles: uncles, Transactions: transactions, Receipts: receipts}}, nil
+}
+
+func (g *Gateway) header(ctx context.Context, path string) (response, error) {
+	headerCid, err := cid.Decode(path)
+	if err != nil || headerCid.Type() != cid.EthBlock {
+		return response{}, badRequest("Invalid header CID %s", path)
+	}
+	header, raw, err := g.reader.getHeader(ctx, headerCid)
+	if err != nil {
+		return response{}, err
+	}
+	return response{raw: raw, decoded: header}, nil
+}
+
+func (g *Gateway) transaction(ctx context.Context, path string) (response, error) {
+	hash, err := parseHash(path)
+	if err != nil {
+		return response{}, err
+	}
+	transactionCid, err := util.HashToCid(cid.EthTx, hash.Bytes())
+	if err != nil {
+		return response{}, err
+	}
+	transaction, raw, err := g.reader.getTransaction(ctx, transactionCid)
+	if err != nil {
+		return response{}, err
+	}
+	return response{raw: raw, decoded: transaction}, nil
+}
+
+func (g *Gateway) receipt(ctx context.Context, path string) (response, error) {
+	hash, err := parseHash(path)
+	if err != nil {
+		return response{}, err
+	}
+	entry, index, ok := g.lookup.Transaction(hash)
+	if !ok {
+		return response{}, notFound("Transaction %s not indexed", hash.Hex())
+	}
+	if index >= len(entry.ReceiptCids) {
+		return response{}, notFound("Receipt of transaction %s not indexed", hash.Hex())
+	}
+	transactions, err := g.reader.getTransactions(ctx, entry)
+	if err != nil {
+		return response{}, err
+	}
+	receipts, err := g.reader.getReceipts(ctx, entry, transactions)
+	if err != nil {
+		return response{}, err
+	}
+	raw, err := rlp.EncodeToBytes(receipts[index])
+	if err != nil {
+		return response{}, err
+	}
+	return response{raw: raw, decoded: receipts[index]}, nil
+}
+
+// accountResponse is an account in the state trie
+type accountResponse struct {
+	Address     common.Address `json:"address"`
+	Nonce       hexutil.Uint64 `json:"nonce"`
+	Balance     *hexutil.Big   `json:"balance"`
+	StorageRoot common.Hash    `json:"storageRoot"`
+	CodeHash    common.Hash    `json:"codeHash"`
+}
+
+func (g *Gateway) account(ctx context.Context, path string) (response, error) {
+	parts := strings.Split(path, "/")
+	if len(parts) != 2 {
+		return response{}, badRequest("Expected /state/<root>/<address>")
+	}
+	root, err := parseHash(parts[0])
+	if err != nil {
+		return response{}, err
+	}
+	if !common.IsHexAddress(parts[1]) {
+		return response{}, badRequest("Invalid address %s", parts[1])
+	}
+	address := common.HexToAddress(parts[1])
+	account, raw, err := g.reader.getAccount(ctx, root, address)
+	if err == errKeyNotFound {
+		return response{}, notFound("Account %s not in state %s", address.Hex(), root.Hex())
+	}
+	if err != nil {
+		return response{}, err
+	}
+	return response{raw: raw, decoded: accountResponse{
+		Address:     address,
+		Nonce:       hexutil.Uint64(account.Nonce),
+		Balance:     (*hexutil.Big)(account.Balance),
+		StorageRoot: account.Root,
+		CodeHash:    common.BytesToHash(account.CodeHash),
+	}}, nil
+}
+
+func parseHash(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, badRequest("Invalid hash %s", s)
+	}
+	return common.BytesToHash(b), nil
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
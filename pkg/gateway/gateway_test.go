@@ -0,0 +1,370 @@
+package gateway_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ipfs/go-cid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/gateway"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	state_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	ipfs_mocks "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Gateway", func() {
+	var (
+		getter       *ipfs_mocks.MockGetter
+		lookup       *gateway.Lookup
+		handler      http.Handler
+		header       *types.Header
+		headerCid    cid.Cid
+		transactions []*types.Transaction
+		receipts     []*types.Receipt
+		entry        transformers.BlockIndexEntry
+		root         common.Hash
+	)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		return recorder
+	}
+
+	decode := func(recorder *httptest.ResponseRecorder) map[string]interface{} {
+		var body map[string]interface{}
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &body)).To(Succeed())
+		return body
+	}
+
+	BeforeEach(func() {
+		getter = ipfs_mocks.NewMockGetter()
+		lookup = gateway.NewLookup()
+		handler = gateway.NewGateway(getter, lookup)
+		root = publishState(getter)
+		block := publishBlock(getter, lookup, root, newUncle())
+		header, headerCid, transactions, receipts, entry = block.header, block.headerCid, block.transactions, block.receipts, block.entry
+	})
+
+	Describe("headers", func() {
+		It("returns the decoded header", func() {
+			recorder := get("/header/" + headerCid.String())
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("application/json"))
+			Expect(decode(recorder)["hash"]).To(Equal(header.Hash().Hex()))
+		})
+
+		It("returns the raw RLP when asked", func() {
+			recorder := get("/header/" + headerCid.String() + "?format=rlp")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(recorder.Header().Get("Content-Type")).To(Equal("application/octet-stream"))
+			Expect(crypto.Keccak256Hash(recorder.Body.Bytes())).To(Equal(header.Hash()))
+		})
+
+		It("returns not found for headers not published", func() {
+			c, err := util.HashToCid(cid.EthBlock, test_helpers.FakeHash.Bytes())
+			Expect(err).NotTo(HaveOccurred())
+
+			recorder := get("/header/" + c.String())
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+			Expect(decode(recorder)["error"]).To(ContainSubstring("not published"))
+		})
+
+		It("rejects CIDs of other IPLDs", func() {
+			recorder := get("/header/" + entry.TransactionCids[0])
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+
+		It("returns a server error when the IPFS repo fails", func() {
+			getter.SetError(test_helpers.FakeError)
+
+			recorder := get("/header/" + headerCid.String())
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(decode(recorder)["error"]).To(Equal(test_helpers.FakeError.Error()))
+		})
+	})
+
+	Describe("transactions", func() {
+		It("returns the decoded transaction by hash", func() {
+			recorder := get("/tx/" + transactions[0].Hash().Hex())
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(decode(recorder)["nonce"]).To(Equal("0x7"))
+		})
+
+		It("rejects malformed hashes", func() {
+			recorder := get("/tx/0x123")
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("receipts", func() {
+		It("returns the receipt with the fields derived from its block and transaction", func() {
+			recorder := get("/receipt/" + transactions[1].Hash().Hex())
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			body := decode(recorder)
+			Expect(body["transactionHash"]).To(Equal(transactions[1].Hash().Hex()))
+			Expect(body["blockHash"]).To(Equal(header.Hash().Hex()))
+			Expect(body["blockNumber"]).To(Equal("0x5"))
+			Expect(body["transactionIndex"]).To(Equal("0x1"))
+			Expect(body["gasUsed"]).To(Equal("0xea60"))
+			sender, err := types.Sender(types.NewEIP155Signer(big.NewInt(1)), transactions[1])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(body["contractAddress"]).To(Equal(strings.ToLower(crypto.CreateAddress(sender, 8).Hex())))
+			logs := body["logs"].([]interface{})
+			Expect(logs[0].(map[string]interface{})["logIndex"]).To(Equal("0x1"))
+		})
+
+		It("returns the receipt's consensus RLP when asked", func() {
+			recorder := get("/receipt/" + transactions[0].Hash().Hex() + "?format=rlp")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			expected, err := rlp.EncodeToBytes(receipts[0])
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.Body.Bytes()).To(Equal(expected))
+		})
+
+		It("returns not found for transactions not indexed", func() {
+			recorder := get("/receipt/" + test_helpers.FakeHash.Hex())
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	Describe("blocks", func() {
+		It("returns the canonical block's header, uncles, transactions and receipts", func() {
+			recorder := get("/block/5")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			body := decode(recorder)
+			Expect(body["header"].(map[string]interface{})["hash"]).To(Equal(header.Hash().Hex()))
+			Expect(body["uncles"]).To(HaveLen(1))
+			Expect(body["transactions"]).To(HaveLen(2))
+			Expect(body["receipts"]).To(HaveLen(2))
+		})
+
+		It("returns the block's RLP when asked", func() {
+			recorder := get("/block/5?format=rlp")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var block types.Block
+			Expect(rlp.DecodeBytes(recorder.Body.Bytes(), &block)).To(Succeed())
+			Expect(block.Hash()).To(Equal(header.Hash()))
+			Expect(block.Transactions()).To(HaveLen(2))
+			Expect(block.Uncles()).To(HaveLen(1))
+		})
+
+		It("omits the uncles of blocks indexed without them", func() {
+			entry.UncleCids = nil
+			lookup.Add(entry)
+
+			recorder := get("/block/5")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			Expect(decode(recorder)).NotTo(HaveKey("uncles"))
+		})
+
+		It("returns not acceptable for the RLP of blocks indexed without their uncles", func() {
+			entry.UncleCids = nil
+			lookup.Add(entry)
+
+			recorder := get("/block/5?format=rlp")
+
+			Expect(recorder.Code).To(Equal(http.StatusNotAcceptable))
+			Expect(decode(recorder)["error"]).To(ContainSubstring("RLP not available"))
+		})
+
+		It("returns not found for blocks not indexed", func() {
+			recorder := get("/block/6")
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("rejects malformed block numbers", func() {
+			recorder := get("/block/latest")
+
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+
+	Describe("state", func() {
+		It("returns the decoded account", func() {
+			recorder := get("/state/" + root.Hex() + "/" + address.Hex())
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			body := decode(recorder)
+			Expect(body["address"]).To(Equal(strings.ToLower(address.Hex())))
+			Expect(body["nonce"]).To(Equal("0x3"))
+			Expect(body["balance"]).To(Equal("0x64"))
+		})
+
+		It("returns the account's RLP when asked", func() {
+			recorder := get("/state/" + root.Hex() + "/" + address.Hex() + "?format=rlp")
+
+			Expect(recorder.Code).To(Equal(http.StatusOK))
+			var account state.Account
+			Expect(rlp.DecodeBytes(recorder.Body.Bytes(), &account)).To(Succeed())
+			Expect(account.Nonce).To(Equal(uint64(3)))
+		})
+
+		It("returns not found for accounts not in the state", func() {
+			recorder := get("/state/" + root.Hex() + "/" + common.HexToAddress("0xfff").Hex())
+
+			Expect(recorder.Code).To(Equal(http.StatusNotFound))
+		})
+
+		It("returns an error for state not published", func() {
+			recorder := get("/state/" + test_helpers.FakeHash.Hex() + "/" + address.Hex())
+
+			Expect(recorder.Code).To(Equal(http.StatusInternalServerError))
+			Expect(decode(recorder)["error"]).To(ContainSubstring("not published"))
+		})
+	})
+
+	It("rejects methods other than GET and HEAD", func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/block/5", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(recorder.Header().Get("Allow")).To(Equal("GET, HEAD"))
+	})
+})
+
+var (
+	address      = common.HexToAddress("0xabc")
+	storageKey   = common.HexToHash("0x1")
+	storageValue = common.HexToHash("0x2a")
+	code         = []byte{1, 2, 3}
+)
+
+// newUncle returns a header at height 4 that block 5 can include as an uncle
+func newUncle() *types.Header {
+	return &types.Header{Number: big.NewInt(4), Difficulty: big.NewInt(1), Time: big.NewInt(1), Extra: []byte{1}}
+}
+
+// publishedBlock is block 5, holding a transfer and a contract creation,
+// published with its uncles and receipts and indexed
+type publishedBlock struct {
+	header       *types.Header
+	headerCid    cid.Cid
+	uncles       []*types.Header
+	transactions []*types.Transaction
+	receipts     []*types.Receipt
+	entry        transformers.BlockIndexEntry
+}
+
+func publishBlock(getter *ipfs_mocks.MockGetter, lookup *gateway.Lookup, root common.Hash, uncles ...*types.Header) publishedBlock {
+	key, err := crypto.GenerateKey()
+	Expect(err).NotTo(HaveOccurred())
+	signer := types.NewEIP155Signer(big.NewInt(1))
+	transfer, err := types.SignTx(types.NewTransaction(7, address, big.NewInt(1), 21000, big.NewInt(1), nil), signer, key)
+	Expect(err).NotTo(HaveOccurred())
+	creation, err := types.SignTx(types.NewContractCreation(8, big.NewInt(0), 100000, big.NewInt(1), []byte{1}), signer, key)
+	Expect(err).NotTo(HaveOccurred())
+	transactions := []*types.Transaction{transfer, creation}
+
+	first := types.NewReceipt(nil, false, 21000)
+	first.Logs = []*types.Log{{Address: address, Data: []byte{1}}}
+	second := types.NewReceipt(nil, false, 81000)
+	second.Logs = []*types.Log{{Address: common.HexToAddress("0xdef"), Data: []byte{2}}}
+	receipts := []*types.Receipt{first, second}
+
+	header := &types.Header{
+		Number:      big.NewInt(5),
+		Difficulty:  big.NewInt(1),
+		Time:        big.NewInt(1),
+		Root:        root,
+		UncleHash:   types.CalcUncleHash(uncles),
+		TxHash:      types.DeriveSha(types.Transactions(transactions)),
+		ReceiptHash: types.DeriveSha(types.Receipts(receipts)),
+		GasUsed:     81000,
+	}
+	rawHeader, err := rlp.EncodeToBytes(header)
+	Expect(err).NotTo(HaveOccurred())
+	headerCid := putIPLD(getter, cid.EthBlock, header.Hash(), rawHeader)
+
+	entry := transformers.BlockIndexEntry{BlockNumber: 5, Hash: header.Hash(), Canonical: true, HeaderCid: headerCid.String()}
+	for _, uncle := range uncles {
+		raw, err := rlp.EncodeToBytes(uncle)
+		Expect(err).NotTo(HaveOccurred())
+		entry.UncleCids = append(entry.UncleCids, putIPLD(getter, cid.EthBlock, uncle.Hash(), raw).String())
+	}
+	for _, transaction := range transactions {
+		raw, err := rlp.EncodeToBytes(transaction)
+		Expect(err).NotTo(HaveOccurred())
+		entry.TransactionCids = append(entry.TransactionCids, putIPLD(getter, cid.EthTx, transaction.Hash(), raw).String())
+	}
+	for _, receipt := range receipts {
+		raw, err := rlp.EncodeToBytes(receipt)
+		Expect(err).NotTo(HaveOccurred())
+		entry.ReceiptCids = append(entry.ReceiptCids, putIPLD(getter, cid.EthTxReceipt, crypto.Keccak256Hash(raw), raw).String())
+	}
+	lookup.Add(entry)
+	return publishedBlock{header: header, headerCid: headerCid, uncles: uncles, transactions: transactions, receipts: receipts, entry: entry}
+}
+
+// publishState publishes the nodes of a state trie holding a contract at
+// address, with its storage trie and code, among other accounts
+func publishState(getter *ipfs_mocks.MockGetter) common.Hash {
+	stateDatabase := state_wrapper.NewDatabase(rawdb.NewMemoryDatabase())
+	stateDB, err := state.New(common.Hash{}, stateDatabase.Database())
+	Expect(err).NotTo(HaveOccurred())
+	stateDB.SetNonce(address, 3)
+	stateDB.SetBalance(address, big.NewInt(100))
+	stateDB.SetCode(address, code)
+	stateDB.SetState(address, storageKey, storageValue)
+	for i := int64(1); i < 20; i++ {
+		stateDB.SetBalance(common.BigToAddress(big.NewInt(i)), big.NewInt(i))
+	}
+	root, err := stateDB.Commit(true)
+	Expect(err).NotTo(HaveOccurred())
+	stateTrie, err := stateDatabase.Database().OpenTrie(root)
+	Expect(err).NotTo(HaveOccurred())
+	putTrieNodes(getter, stateDatabase, cid.EthStateTrie, stateTrie.NodeIterator(nil))
+	storageTrie, err := stateDatabase.Database().OpenStorageTrie(crypto.Keccak256Hash(address.Bytes()), stateDB.StorageTrie(address).Hash())
+	Expect(err).NotTo(HaveOccurred())
+	putTrieNodes(getter, stateDatabase, eth_storage_trie.EthStorageTrieNodeCode, storageTrie.NodeIterator(nil))
+	putIPLD(getter, cid.Raw, crypto.Keccak256Hash(code), code)
+	return root
+}
+
+func putTrieNodes(getter *ipfs_mocks.MockGetter, stateDatabase *state_wrapper.Database, codec uint64, iterator trie.NodeIterator) {
+	for iterator.Next(true) {
+		if iterator.Hash() == (common.Hash{}) {
+			continue
+		}
+		node, err := stateDatabase.Database().TrieDB().Node(iterator.Hash())
+		Expect(err).NotTo(HaveOccurred())
+		putIPLD(getter, codec, iterator.Hash(), node)
+	}
+	Expect(iterator.Error()).NotTo(HaveOccurred())
+}
+
+func putIPLD(getter *ipfs_mocks.MockGetter, codec uint64, hash common.Hash, raw []byte) cid.Cid {
+	c, err := util.HashToCid(codec, hash.Bytes())
+	Expect(err).NotTo(HaveOccurred())
+	getter.Put(c, raw)
+	return c
+}
@@ -0,0 +1,109 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ipfs/go-cid"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// Lookup resolves block numbers and transaction hashes to the published
+// blocks that hold them, from the block indexes written while extracting.
+// It is filled before serving, and only read while serving.
+type Lookup struct {
+	blocks       map[common.Hash]transformers.BlockIndexEntry
+	canonical    map[int64]common.Hash
+	latest       int64
+	transactions map[string]transactionLocation
+}
+
+// transactionLocation is the block holding a transaction, and its index there
+type transactionLocation struct {
+	blockHash common.Hash
+	index     int
+}
+
+func NewLookup() *Lookup {
+	return &Lookup{
+		blocks:       make(map[common.Hash]transformers.BlockIndexEntry),
+		canonical:    make(map[int64]common.Hash),
+		latest:       -1,
+		transactions: make(map[string]transactionLocation),
+	}
+}
+
+// Load adds the entries of a block index, written as one JSON object per line
+func (l *Lookup) Load(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry transformers.BlockIndexEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return fmt.Errorf("Error reading block index line %d: %s", line, err)
+		}
+		l.Add(entry)
+	}
+	return scanner.Err()
+}
+
+// Add indexes a block. A block indexed again replaces its earlier entry, so
+// that receipts extracted later are found, and transactions are located in a
+// canonical block when one holds them.
+func (l *Lookup) Add(entry transformers.BlockIndexEntry) {
+	previous, indexed := l.blocks[entry.Hash]
+	if indexed && len(entry.ReceiptCids) == 0 {
+		entry.ReceiptCids = previous.ReceiptCids
+	}
+	l.blocks[entry.Hash] = entry
+	if entry.Canonical {
+		l.canonical[entry.BlockNumber] = entry.Hash
+		if entry.BlockNumber > l.latest {
+			l.latest = entry.BlockNumber
+		}
+	}
+	for i, transactionCid := range entry.TransactionCids {
+		location, ok := l.transactions[transactionCid]
+		if ok && l.blocks[location.blockHash].Canonical && !entry.Canonical {
+			continue
+		}
+		l.transactions[transactionCid] = transactionLocation{blockHash: entry.Hash, index: i}
+	}
+}
+
+// Block returns the canonical block numbered blockNumber
+func (l *Lookup) Block(blockNumber int64) (transformers.BlockIndexEntry, bool) {
+	hash, ok := l.canonical[blockNumber]
+	if !ok {
+		return transformers.BlockIndexEntry{}, false
+	}
+	return l.blocks[hash], true
+}
+
+// Latest returns the highest numbered canonical block
+func (l *Lookup) Latest() (transformers.BlockIndexEntry, bool) {
+	return l.Block(l.latest)
+}
+
+// Transaction returns the block holding the transaction with hash, and the
+// transaction's index in it
+func (l *Lookup) Transaction(hash common.Hash) (transformers.BlockIndexEntry, int, bool) {
+	transactionCid, err := util.HashToCid(cid.EthTx, hash.Bytes())
+	if err != nil {
+		return transformers.BlockIndexEntry{}, 0, false
+	}
+	location, ok := l.transactions[transactionCid.String()]
+	if !ok {
+		return transformers.BlockIndexEntry{}, 0, false
+	}
+	return l.blocks[location.blockHash], location.index, true
+}
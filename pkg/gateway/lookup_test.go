@@ -0,0 +1,112 @@
+package gateway_test
+
+import (
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ipfs/go-cid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/gateway"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+var _ = Describe("Lookup", func() {
+	var (
+		lookup          *gateway.Lookup
+		transactionHash = common.HexToHash("0xabc")
+		transactionCid  string
+		canonical       transformers.BlockIndexEntry
+		uncle           transformers.BlockIndexEntry
+	)
+
+	BeforeEach(func() {
+		c, err := util.HashToCid(cid.EthTx, transactionHash.Bytes())
+		Expect(err).NotTo(HaveOccurred())
+		transactionCid = c.String()
+		canonical = transformers.BlockIndexEntry{
+			BlockNumber:     5,
+			Hash:            common.HexToHash("0x5"),
+			Canonical:       true,
+			HeaderCid:       "header",
+			TransactionCids: []string{"other", transactionCid},
+		}
+		uncle = transformers.BlockIndexEntry{
+			BlockNumber:     5,
+			Hash:            common.HexToHash("0x55"),
+			HeaderCid:       "uncle",
+			TransactionCids: []string{transactionCid},
+		}
+		lookup = gateway.NewLookup()
+	})
+
+	It("resolves block numbers to canonical blocks", func() {
+		lookup.Add(canonical)
+		lookup.Add(uncle)
+
+		entry, ok := lookup.Block(5)
+
+		Expect(ok).To(BeTrue())
+		Expect(entry.Hash).To(Equal(canonical.Hash))
+		_, ok = lookup.Block(6)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("returns the highest numbered canonical block as the latest", func() {
+		_, ok := lookup.Latest()
+		Expect(ok).To(BeFalse())
+		lookup.Add(canonical)
+		uncle.BlockNumber = 6
+		lookup.Add(uncle)
+
+		entry, ok := lookup.Latest()
+
+		Expect(ok).To(BeTrue())
+		Expect(entry.Hash).To(Equal(canonical.Hash))
+	})
+
+	It("locates transactions in canonical blocks over side chain blocks", func() {
+		lookup.Add(canonical)
+		lookup.Add(uncle)
+
+		entry, index, ok := lookup.Transaction(transactionHash)
+
+		Expect(ok).To(BeTrue())
+		Expect(entry.Hash).To(Equal(canonical.Hash))
+		Expect(index).To(Equal(1))
+		_, _, ok = lookup.Transaction(common.HexToHash("0xdef"))
+		Expect(ok).To(BeFalse())
+	})
+
+	It("keeps receipts indexed before a block is indexed again", func() {
+		canonical.ReceiptCids = []string{"receipt", "other receipt"}
+		lookup.Add(canonical)
+		canonical.ReceiptCids = nil
+		lookup.Add(canonical)
+
+		entry, _ := lookup.Block(5)
+
+		Expect(entry.ReceiptCids).To(Equal([]string{"receipt", "other receipt"}))
+	})
+
+	It("loads block indexes written while extracting", func() {
+		index := `{"blockNumber":5,"hash":"0x0000000000000000000000000000000000000000000000000000000000000005","canonical":true,"headerCid":"header","transactionCids":[]}
+
+`
+		err := lookup.Load(strings.NewReader(index))
+
+		Expect(err).NotTo(HaveOccurred())
+		entry, ok := lookup.Block(5)
+		Expect(ok).To(BeTrue())
+		Expect(entry.HeaderCid).To(Equal("header"))
+	})
+
+	It("returns an error for malformed block indexes", func() {
+		err := lookup.Load(strings.NewReader("{}\nnot json\n"))
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("line 2"))
+	})
+})
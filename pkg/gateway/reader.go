@@ -0,0 +1,212 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ipfs/go-cid"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// reader decodes the Ethereum objects in published IPLDs
+type reader struct {
+	getter ipfs.Getter
+}
+
+func (r reader) get(ctx context.Context, c cid.Cid, object string) ([]byte, error) {
+	raw, err := r.getter.Get(ctx, c)
+	if err == ipfs.ErrNotPublished {
+		return nil, notFound("%s %s not published", object, c)
+	}
+	return raw, err
+}
+
+func (r reader) getHeader(ctx context.Context, headerCid cid.Cid) (*types.Header, []byte, error) {
+	raw, err := r.get(ctx, headerCid, "Header")
+	if err != nil {
+		return nil, nil, err
+	}
+	var header types.Header
+	err = rlp.DecodeBytes(raw, &header)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error decoding header %s: %s", headerCid, err)
+	}
+	return &header, raw, nil
+}
+
+// getIndexedHeader returns the header of a block in the block index
+func (r reader) getIndexedHeader(ctx context.Context, entry transformers.BlockIndexEntry) (*types.Header, error) {
+	headerCid, err := cid.Decode(entry.HeaderCid)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid header CID %s in block index: %s", entry.HeaderCid, err)
+	}
+	header, _, err := r.getHeader(ctx, headerCid)
+	return header, err
+}
+
+// getUncles returns the uncles of a block in the block index, and whether
+// they match its header. Blocks indexed before their uncles were published
+// have no uncle CIDs, so they only match when the block has no uncles.
+func (r reader) getUncles(ctx context.Context, entry transformers.BlockIndexEntry, header *types.Header) ([]*types.Header, bool, error) {
+	uncles := make([]*types.Header, 0, len(entry.UncleCids))
+	for _, uncleCid := range entry.UncleCids {
+		c, err := cid.Decode(uncleCid)
+		if err != nil {
+			return nil, false, fmt.Errorf("Invalid uncle CID %s in block index: %s", uncleCid, err)
+		}
+		uncle, _, err := r.getHeader(ctx, c)
+		if err != nil {
+			return nil, false, err
+		}
+		uncles = append(uncles, uncle)
+	}
+	return uncles, types.CalcUncleHash(uncles) == header.UncleHash, nil
+}
+
+func (r reader) getTransaction(ctx context.Context, transactionCid cid.Cid) (*types.Transaction, []byte, error) {
+	raw, err := r.get(ctx, transactionCid, "Transaction")
+	if err != nil {
+		return nil, nil, err
+	}
+	var transaction types.Transaction
+	err = rlp.DecodeBytes(raw, &transaction)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error decoding transaction %s: %s", transactionCid, err)
+	}
+	return &transaction, raw, nil
+}
+
+func (r reader) getTransactions(ctx context.Context, entry transformers.BlockIndexEntry) ([]*types.Transaction, error) {
+	transactions := make([]*types.Transaction, 0, len(entry.TransactionCids))
+	for _, transactionCid := range entry.TransactionCids {
+		c, err := cid.Decode(transactionCid)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid transaction CID %s in block index: %s", transactionCid, err)
+		}
+		transaction, _, err := r.getTransaction(ctx, c)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+	return transactions, nil
+}
+
+// getReceipts returns a block's receipts, if indexed, with the fields derived
+// from their block and transactions filled in as geth does
+func (r reader) getReceipts(ctx context.Context, entry transformers.BlockIndexEntry, transactions []*types.Transaction) ([]*types.Receipt, error) {
+	if len(entry.ReceiptCids) == 0 {
+		return nil, nil
+	}
+	receipts := make([]*types.Receipt, 0, len(entry.ReceiptCids))
+	var cumulativeGasUsed uint64
+	var logIndex uint
+	for i, receiptCid := range entry.ReceiptCids {
+		c, err := cid.Decode(receiptCid)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid receipt CID %s in block index: %s", receiptCid, err)
+		}
+		raw, err := r.get(ctx, c, "Receipt")
+		if err != nil {
+			return nil, err
+		}
+		var receipt types.Receipt
+		err = rlp.DecodeBytes(raw, &receipt)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding receipt %s: %s", receiptCid, err)
+		}
+		receipt.BlockHash = entry.Hash
+		receipt.BlockNumber = big.NewInt(entry.BlockNumber)
+		receipt.TransactionIndex = uint(i)
+		receipt.GasUsed = receipt.CumulativeGasUsed - cumulativeGasUsed
+		cumulativeGasUsed = receipt.CumulativeGasUsed
+		if i < len(transactions) {
+			transaction := transactions[i]
+			receipt.TxHash = transaction.Hash()
+			if transaction.To() == nil {
+				sender, err := types.Sender(signer(transaction), transaction)
+				if err == nil {
+					receipt.ContractAddress = crypto.CreateAddress(sender, transaction.Nonce())
+				}
+			}
+		}
+		for _, log := range receipt.Logs {
+			log.BlockNumber = uint64(entry.BlockNumber)
+			log.BlockHash = entry.Hash
+			log.TxHash = receipt.TxHash
+			log.TxIndex = uint(i)
+			log.Index = logIndex
+			logIndex++
+		}
+		receipts = append(receipts, &receipt)
+	}
+	return receipts, nil
+}
+
+// getAccount returns the RLP and decoded form of the account at address in
+// the state with root
+func (r reader) getAccount(ctx context.Context, root common.Hash, address common.Address) (*state.Account, []byte, error) {
+	raw, err := getTrieValue(ctx, r.getter, cid.EthStateTrie, root, crypto.Keccak256(address.Bytes()))
+	if err != nil {
+		return nil, nil, err
+	}
+	var account state.Account
+	err = rlp.DecodeBytes(raw, &account)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error decoding account %s: %s", address.Hex(), err)
+	}
+	return &account, raw, nil
+}
+
+// getStorage returns the value of an account's storage slot, which is zero if
+// the slot is not in the storage trie
+func (r reader) getStorage(ctx context.Context, storageRoot common.Hash, key common.Hash) (common.Hash, error) {
+	if storageRoot == types.EmptyRootHash {
+		return common.Hash{}, nil
+	}
+	raw, err := getTrieValue(ctx, r.getter, eth_storage_trie.EthStorageTrieNodeCode, storageRoot, crypto.Keccak256(key.Bytes()))
+	if err == errKeyNotFound {
+		return common.Hash{}, nil
+	}
+	if err != nil {
+		return common.Hash{}, err
+	}
+	value, _, err := rlp.SplitString(raw)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("Error decoding storage slot %s: %s", key.Hex(), err)
+	}
+	return common.BytesToHash(value), nil
+}
+
+// getCode returns the contract code with codeHash, published as a raw IPLD
+func (r reader) getCode(ctx context.Context, codeHash common.Hash) ([]byte, error) {
+	if codeHash == emptyCodeHash {
+		return []byte{}, nil
+	}
+	codeCid, err := util.HashToCid(cid.Raw, codeHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return r.get(ctx, codeCid, "Code")
+}
+
+// signer recovers the sender of replay protected transactions with their
+// chain ID, and of others as before EIP-155
+func signer(transaction *types.Transaction) types.Signer {
+	if transaction.Protected() {
+		return types.NewEIP155Signer(transaction.ChainId())
+	}
+	return types.FrontierSigner{}
+}
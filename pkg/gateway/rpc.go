@@ -0,0 +1,468 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ipfs/go-cid"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+)
+
+// JSON-RPC 2.0 error codes
+const (
+	parseErrorCode     = -32700
+	invalidRequestCode = -32600
+	methodNotFoundCode = -32601
+	invalidParamsCode  = -32602
+	serverErrorCode    = -32000
+)
+
+// maxRequestSize bounds the body of a JSON-RPC request or batch
+const maxRequestSize = 5 * 1024 * 1024
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Result  *json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+type rpcMethod func(ctx context.Context, params []json.RawMessage) (interface{}, error)
+
+// RPCHandler serves a subset of Ethereum's JSON-RPC API from the IPLDs
+// published to an IPFS repo, for tools that query history without an archive
+// node. Blocks are those in the lookup: "latest" is the highest numbered
+// canonical block indexed, and "pending" is the same. Objects that are not
+// published or indexed are null, as geth returns for unknown objects.
+type RPCHandler struct {
+	reader  reader
+	lookup  *Lookup
+	methods map[string]rpcMethod
+}
+
+func NewRPCHandler(getter ipfs.Getter, lookup *Lookup) *RPCHandler {
+	h := &RPCHandler{reader: reader{getter: getter}, lookup: lookup}
+	h.methods = map[string]rpcMethod{
+		"eth_getBlockByNumber":      h.getBlockByNumber,
+		"eth_getTransactionByHash":  h.getTransactionByHash,
+		"eth_getTransactionReceipt": h.getTransactionReceipt,
+		"eth_getBalance":            h.getBalance,
+		"eth_getStorageAt":          h.getStorageAt,
+		"eth_getCode":               h.getCode,
+	}
+	return h
+}
+
+// ServeHTTP answers a JSON-RPC request, or a batch of them, POSTed in the body.
+// Notifications, requests without an id, are not answered.
+func (h *RPCHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, errors.New("Method not allowed"))
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		writeJSON(w, http.StatusOK, errorResponse(nil, parseErrorCode, err.Error()))
+		return
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '[' {
+		response, ok := h.call(r.Context(), body)
+		if !ok {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+	var batch []json.RawMessage
+	err = json.Unmarshal(body, &batch)
+	if err != nil {
+		writeJSON(w, http.StatusOK, errorResponse(nil, parseErrorCode, err.Error()))
+		return
+	}
+	if len(batch) == 0 {
+		writeJSON(w, http.StatusOK, errorResponse(nil, invalidRequestCode, "Empty batch"))
+		return
+	}
+	responses := make([]rpcResponse, 0, len(batch))
+	for _, request := range batch {
+		response, ok := h.call(r.Context(), request)
+		if ok {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, responses)
+}
+
+// call answers a request, and returns false for a notification, which gets no
+// response. Since every method only reads, notifications are not executed.
+func (h *RPCHandler) call(ctx context.Context, body []byte) (rpcResponse, bool) {
+	var request rpcRequest
+	err := json.Unmarshal(body, &request)
+	if err != nil {
+		return errorResponse(nil, parseErrorCode, err.Error()), true
+	}
+	if request.JSONRPC != "2.0" || request.Method == "" {
+		return errorResponse(request.ID, invalidRequestCode, "Invalid request"), true
+	}
+	// an id of null is present, so only a missing id makes a notification
+	if len(request.ID) == 0 {
+		return rpcResponse{}, false
+	}
+	method, ok := h.methods[request.Method]
+	if !ok {
+		return errorResponse(request.ID, methodNotFoundCode, fmt.Sprintf("The method %s does not exist/is not available", request.Method)), true
+	}
+	var params []json.RawMessage
+	if len(request.Params) > 0 && string(request.Params) != "null" {
+		err = json.Unmarshal(request.Params, &params)
+		if err != nil {
+			return errorResponse(request.ID, invalidParamsCode, "Params must be an array"), true
+		}
+	}
+	result, err := method(ctx, params)
+	if _, ok := err.(badRequestError); ok {
+		return errorResponse(request.ID, invalidParamsCode, err.Error()), true
+	}
+	if err != nil {
+		return errorResponse(request.ID, serverErrorCode, err.Error()), true
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return errorResponse(request.ID, serverErrorCode, err.Error()), true
+	}
+	raw := json.RawMessage(encoded)
+	return rpcResponse{JSONRPC: "2.0", ID: responseID(request.ID), Result: &raw}, true
+}
+
+func errorResponse(id json.RawMessage, code int, message string) rpcResponse {
+	return rpcResponse{JSONRPC: "2.0", ID: responseID(id), Error: &rpcError{Code: code, Message: message}}
+}
+
+func responseID(id json.RawMessage) json.RawMessage {
+	if len(id) == 0 {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+// param decodes the ith of params into v
+func param(params []json.RawMessage, i int, v interface{}) error {
+	if i >= len(params) {
+		return badRequest("Missing value for required argument %d", i)
+	}
+	err := json.Unmarshal(params[i], v)
+	if err != nil {
+		return badRequest("Invalid argument %d: %s", i, err)
+	}
+	return nil
+}
+
+// blockByTag returns the indexed canonical block with a hex number, or tagged
+// latest, pending or earliest
+func (h *RPCHandler) blockByTag(tag string) (transformers.BlockIndexEntry, bool, error) {
+	switch tag {
+	case "latest", "pending":
+		entry, ok := h.lookup.Latest()
+		return entry, ok, nil
+	case "earliest":
+		entry, ok := h.lookup.Block(0)
+		return entry, ok, nil
+	}
+	blockNumber, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return transformers.BlockIndexEntry{}, false, badRequest("Invalid block number %s", tag)
+	}
+	entry, ok := h.lookup.Block(int64(blockNumber))
+	return entry, ok, nil
+}
+
+func (h *RPCHandler) getBlockByNumber(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var tag string
+	var fullTransactions bool
+	err := param(params, 0, &tag)
+	if err != nil {
+		return nil, err
+	}
+	err = param(params, 1, &fullTransactions)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok, err := h.blockByTag(tag)
+	if err != nil || !ok {
+		return nil, err
+	}
+	header, err := h.reader.getIndexedHeader(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	transactions, err := h.reader.getTransactions(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{
+		"number":           (*hexutil.Big)(header.Number),
+		"hash":             header.Hash(),
+		"parentHash":       header.ParentHash,
+		"nonce":            header.Nonce,
+		"mixHash":          header.MixDigest,
+		"sha3Uncles":       header.UncleHash,
+		"logsBloom":        header.Bloom,
+		"stateRoot":        header.Root,
+		"miner":            header.Coinbase,
+		"difficulty":       (*hexutil.Big)(header.Difficulty),
+		"extraData":        hexutil.Bytes(header.Extra),
+		"gasLimit":         hexutil.Uint64(header.GasLimit),
+		"gasUsed":          hexutil.Uint64(header.GasUsed),
+		"timestamp":        (*hexutil.Big)(header.Time),
+		"transactionsRoot": header.TxHash,
+		"receiptsRoot":     header.ReceiptHash,
+	}
+	formatted := make([]interface{}, 0, len(transactions))
+	for i, transaction := range transactions {
+		if fullTransactions {
+			formatted = append(formatted, newRPCTransaction(transaction, &entry, i))
+		} else {
+			formatted = append(formatted, transaction.Hash())
+		}
+	}
+	fields["transactions"] = formatted
+	uncles, ok, err := h.reader.getUncles(ctx, entry, header)
+	if err != nil {
+		return nil, err
+	}
+	// blocks indexed before their uncles were published have no uncle hashes or size
+	if ok {
+		uncleHashes := make([]common.Hash, 0, len(uncles))
+		for _, uncle := range uncles {
+			uncleHashes = append(uncleHashes, uncle.Hash())
+		}
+		fields["uncles"] = uncleHashes
+		fields["size"] = hexutil.Uint64(types.NewBlockWithHeader(header).WithBody(transactions, uncles).Size())
+	}
+	return fields, nil
+}
+
+// rpcTransaction is a transaction as returned by geth, with null block fields
+// for transactions published but not indexed
+type rpcTransaction struct {
+	BlockHash        *common.Hash    `json:"blockHash"`
+	BlockNumber      *hexutil.Big    `json:"blockNumber"`
+	From             common.Address  `json:"from"`
+	Gas              hexutil.Uint64  `json:"gas"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+	Hash             common.Hash     `json:"hash"`
+	Input            hexutil.Bytes   `json:"input"`
+	Nonce            hexutil.Uint64  `json:"nonce"`
+	To               *common.Address `json:"to"`
+	TransactionIndex *hexutil.Uint64 `json:"transactionIndex"`
+	Value            *hexutil.Big    `json:"value"`
+	V                *hexutil.Big    `json:"v"`
+	R                *hexutil.Big    `json:"r"`
+	S                *hexutil.Big    `json:"s"`
+}
+
+func newRPCTransaction(transaction *types.Transaction, entry *transformers.BlockIndexEntry, index int) *rpcTransaction {
+	from, _ := types.Sender(signer(transaction), transaction)
+	v, r, s := transaction.RawSignatureValues()
+	result := &rpcTransaction{
+		From:     from,
+		Gas:      hexutil.Uint64(transaction.Gas()),
+		GasPrice: (*hexutil.Big)(transaction.GasPrice()),
+		Hash:     transaction.Hash(),
+		Input:    hexutil.Bytes(transaction.Data()),
+		Nonce:    hexutil.Uint64(transaction.Nonce()),
+		To:       transaction.To(),
+		Value:    (*hexutil.Big)(transaction.Value()),
+		V:        (*hexutil.Big)(v),
+		R:        (*hexutil.Big)(r),
+		S:        (*hexutil.Big)(s),
+	}
+	if entry != nil {
+		transactionIndex := hexutil.Uint64(index)
+		result.BlockHash = &entry.Hash
+		result.BlockNumber = (*hexutil.Big)(big.NewInt(entry.BlockNumber))
+		result.TransactionIndex = &transactionIndex
+	}
+	return result
+}
+
+func (h *RPCHandler) getTransactionByHash(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var hash common.Hash
+	err := param(params, 0, &hash)
+	if err != nil {
+		return nil, err
+	}
+	transactionCid, err := util.HashToCid(cid.EthTx, hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	transaction, _, err := h.reader.getTransaction(ctx, transactionCid)
+	if _, ok := err.(notFoundError); ok {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry, index, ok := h.lookup.Transaction(hash)
+	if !ok {
+		return newRPCTransaction(transaction, nil, 0), nil
+	}
+	return newRPCTransaction(transaction, &entry, index), nil
+}
+
+func (h *RPCHandler) getTransactionReceipt(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var hash common.Hash
+	err := param(params, 0, &hash)
+	if err != nil {
+		return nil, err
+	}
+	entry, index, ok := h.lookup.Transaction(hash)
+	if !ok || index >= len(entry.ReceiptCids) {
+		return nil, nil
+	}
+	transactions, err := h.reader.getTransactions(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	receipts, err := h.reader.getReceipts(ctx, entry, transactions)
+	if err != nil {
+		return nil, err
+	}
+	transaction, receipt := transactions[index], receipts[index]
+	from, _ := types.Sender(signer(transaction), transaction)
+	fields := map[string]interface{}{
+		"blockHash":         entry.Hash,
+		"blockNumber":       hexutil.Uint64(entry.BlockNumber),
+		"transactionHash":   hash,
+		"transactionIndex":  hexutil.Uint64(index),
+		"from":              from,
+		"to":                transaction.To(),
+		"gasUsed":           hexutil.Uint64(receipt.GasUsed),
+		"cumulativeGasUsed": hexutil.Uint64(receipt.CumulativeGasUsed),
+		"contractAddress":   nil,
+		"logs":              receipt.Logs,
+		"logsBloom":         receipt.Bloom,
+	}
+	if len(receipt.PostState) > 0 {
+		fields["root"] = hexutil.Bytes(receipt.PostState)
+	} else {
+		fields["status"] = hexutil.Uint(receipt.Status)
+	}
+	if receipt.Logs == nil {
+		fields["logs"] = []*types.Log{}
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		fields["contractAddress"] = receipt.ContractAddress
+	}
+	return fields, nil
+}
+
+// account returns the account whose address is the first of params, in the
+// state of the block tagged by the ith, or nil if there is no such account
+func (h *RPCHandler) account(ctx context.Context, params []json.RawMessage, i int) (*state.Account, error) {
+	var address common.Address
+	err := param(params, 0, &address)
+	if err != nil {
+		return nil, err
+	}
+	var tag string
+	err = param(params, i, &tag)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok, err := h.blockByTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("Block %s not indexed", tag)
+	}
+	header, err := h.reader.getIndexedHeader(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	account, _, err := h.reader.getAccount(ctx, header.Root, address)
+	if err == errKeyNotFound {
+		return nil, nil
+	}
+	return account, err
+}
+
+func (h *RPCHandler) getBalance(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	account, err := h.account(ctx, params, 1)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return (*hexutil.Big)(big.NewInt(0)), nil
+	}
+	return (*hexutil.Big)(account.Balance), nil
+}
+
+func (h *RPCHandler) getStorageAt(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	var key string
+	err := param(params, 1, &key)
+	if err != nil {
+		return nil, err
+	}
+	account, err := h.account(ctx, params, 2)
+	if err != nil {
+		return nil, err
+	}
+	var value common.Hash
+	if account != nil {
+		value, err = h.reader.getStorage(ctx, account.Root, common.HexToHash(key))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return hexutil.Bytes(value.Bytes()), nil
+}
+
+func (h *RPCHandler) getCode(ctx context.Context, params []json.RawMessage) (interface{}, error) {
+	account, err := h.account(ctx, params, 1)
+	if err != nil {
+		return nil, err
+	}
+	if account == nil {
+		return hexutil.Bytes{}, nil
+	}
+	code, err := h.reader.getCode(ctx, common.BytesToHash(account.CodeHash))
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(code), nil
+}
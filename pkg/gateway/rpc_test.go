@@ -0,0 +1,250 @@
+package gateway_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/gateway"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	ipfs_mocks "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("JSON-RPC handler", func() {
+	var (
+		getter  *ipfs_mocks.MockGetter
+		handler http.Handler
+		block   publishedBlock
+	)
+
+	type rpcResponse struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	post := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body)))
+		return recorder
+	}
+
+	call := func(method string, params ...interface{}) rpcResponse {
+		encoded, err := json.Marshal(params)
+		Expect(err).NotTo(HaveOccurred())
+		recorder := post(`{"jsonrpc": "2.0", "id": 1, "method": "` + method + `", "params": ` + string(encoded) + `}`)
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		var response rpcResponse
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &response)).To(Succeed())
+		Expect(response.ID).To(Equal(1))
+		return response
+	}
+
+	result := func(response rpcResponse) map[string]interface{} {
+		Expect(response.Error).To(BeNil())
+		var fields map[string]interface{}
+		Expect(json.Unmarshal(response.Result, &fields)).To(Succeed())
+		return fields
+	}
+
+	BeforeEach(func() {
+		getter = ipfs_mocks.NewMockGetter()
+		lookup := gateway.NewLookup()
+		handler = gateway.NewRPCHandler(getter, lookup)
+		block = publishBlock(getter, lookup, publishState(getter), newUncle())
+	})
+
+	Describe("eth_getBlockByNumber", func() {
+		It("returns the block with its transaction hashes", func() {
+			fields := result(call("eth_getBlockByNumber", "0x5", false))
+
+			Expect(fields["hash"]).To(Equal(block.header.Hash().Hex()))
+			Expect(fields["number"]).To(Equal("0x5"))
+			Expect(fields["transactions"]).To(Equal([]interface{}{block.transactions[0].Hash().Hex(), block.transactions[1].Hash().Hex()}))
+			Expect(fields["uncles"]).To(Equal([]interface{}{block.uncles[0].Hash().Hex()}))
+			Expect(fields).To(HaveKey("size"))
+		})
+
+		It("returns the block with full transactions", func() {
+			fields := result(call("eth_getBlockByNumber", "latest", true))
+
+			transaction := fields["transactions"].([]interface{})[1].(map[string]interface{})
+			Expect(transaction["hash"]).To(Equal(block.transactions[1].Hash().Hex()))
+			Expect(transaction["blockHash"]).To(Equal(block.header.Hash().Hex()))
+			Expect(transaction["transactionIndex"]).To(Equal("0x1"))
+			Expect(transaction["to"]).To(BeNil())
+		})
+
+		It("returns null for blocks not indexed", func() {
+			response := call("eth_getBlockByNumber", "0x6", false)
+
+			Expect(response.Error).To(BeNil())
+			Expect(string(response.Result)).To(Equal("null"))
+		})
+
+		It("rejects malformed block numbers", func() {
+			response := call("eth_getBlockByNumber", "five", false)
+
+			Expect(response.Error.Code).To(Equal(-32602))
+		})
+	})
+
+	Describe("eth_getTransactionByHash", func() {
+		It("returns the transaction with its block", func() {
+			fields := result(call("eth_getTransactionByHash", block.transactions[0].Hash()))
+
+			Expect(fields["nonce"]).To(Equal("0x7"))
+			Expect(fields["to"]).To(Equal(strings.ToLower(address.Hex())))
+			Expect(fields["blockNumber"]).To(Equal("0x5"))
+			Expect(fields["transactionIndex"]).To(Equal("0x0"))
+		})
+
+		It("returns null for transactions not published", func() {
+			response := call("eth_getTransactionByHash", test_helpers.FakeHash)
+
+			Expect(response.Error).To(BeNil())
+			Expect(string(response.Result)).To(Equal("null"))
+		})
+	})
+
+	Describe("eth_getTransactionReceipt", func() {
+		It("returns the receipt as geth does", func() {
+			fields := result(call("eth_getTransactionReceipt", block.transactions[1].Hash()))
+
+			Expect(fields["blockHash"]).To(Equal(block.header.Hash().Hex()))
+			Expect(fields["transactionIndex"]).To(Equal("0x1"))
+			Expect(fields["gasUsed"]).To(Equal("0xea60"))
+			Expect(fields["cumulativeGasUsed"]).To(Equal("0x13c68"))
+			Expect(fields["status"]).To(Equal("0x1"))
+			Expect(fields["contractAddress"]).NotTo(BeNil())
+			Expect(fields["logs"]).To(HaveLen(1))
+		})
+
+		It("returns null for transactions not indexed", func() {
+			response := call("eth_getTransactionReceipt", test_helpers.FakeHash)
+
+			Expect(string(response.Result)).To(Equal("null"))
+		})
+	})
+
+	Describe("state", func() {
+		It("returns balances", func() {
+			response := call("eth_getBalance", address, "0x5")
+
+			Expect(response.Error).To(BeNil())
+			Expect(string(response.Result)).To(Equal(`"0x64"`))
+		})
+
+		It("returns zero balances for accounts not in the state", func() {
+			response := call("eth_getBalance", common.HexToAddress("0xfff"), "latest")
+
+			Expect(string(response.Result)).To(Equal(`"0x0"`))
+		})
+
+		It("returns storage slots", func() {
+			response := call("eth_getStorageAt", address, "0x1", "latest")
+
+			Expect(response.Error).To(BeNil())
+			Expect(string(response.Result)).To(Equal(`"` + hexutil.Encode(storageValue.Bytes()) + `"`))
+		})
+
+		It("returns zero for slots not in storage", func() {
+			response := call("eth_getStorageAt", address, "0x2", "latest")
+
+			Expect(string(response.Result)).To(Equal(`"` + hexutil.Encode(common.Hash{}.Bytes()) + `"`))
+		})
+
+		It("returns code", func() {
+			response := call("eth_getCode", address, "latest")
+
+			Expect(response.Error).To(BeNil())
+			Expect(string(response.Result)).To(Equal(`"0x010203"`))
+		})
+
+		It("returns empty code for accounts without code", func() {
+			response := call("eth_getCode", common.BigToAddress(common.Big1), "latest")
+
+			Expect(string(response.Result)).To(Equal(`"0x"`))
+		})
+
+		It("returns an error for blocks not indexed", func() {
+			response := call("eth_getBalance", address, "0x6")
+
+			Expect(response.Error.Code).To(Equal(-32000))
+			Expect(response.Error.Message).To(ContainSubstring("not indexed"))
+		})
+
+		It("returns an error when the state is not published", func() {
+			getter.SetError(test_helpers.FakeError)
+
+			response := call("eth_getBalance", address, "latest")
+
+			Expect(response.Error.Message).To(Equal(test_helpers.FakeError.Error()))
+		})
+	})
+
+	It("answers batches of requests", func() {
+		recorder := post(`[{"jsonrpc": "2.0", "id": 1, "method": "eth_getBalance", "params": ["` + address.Hex() + `", "latest"]}, {"jsonrpc": "2.0", "id": 2, "method": "eth_getBlockByNumber", "params": ["0x6", false]}]`)
+
+		var responses []rpcResponse
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &responses)).To(Succeed())
+		Expect(responses).To(HaveLen(2))
+		Expect(responses[0].ID).To(Equal(1))
+		Expect(responses[1].ID).To(Equal(2))
+	})
+
+	It("does not answer notifications", func() {
+		recorder := post(`{"jsonrpc": "2.0", "method": "eth_getBalance", "params": ["` + address.Hex() + `", "latest"]}`)
+
+		Expect(recorder.Code).To(Equal(http.StatusNoContent))
+		Expect(recorder.Body.Len()).To(BeZero())
+	})
+
+	It("leaves notifications out of batch responses", func() {
+		recorder := post(`[{"jsonrpc": "2.0", "method": "eth_getBalance", "params": ["` + address.Hex() + `", "latest"]}, {"jsonrpc": "2.0", "id": 2, "method": "eth_getBlockByNumber", "params": ["0x6", false]}, {"jsonrpc": "2.0", "method": "eth_unknown"}]`)
+
+		var responses []rpcResponse
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &responses)).To(Succeed())
+		Expect(responses).To(HaveLen(1))
+		Expect(responses[0].ID).To(Equal(2))
+	})
+
+	It("does not answer batches of notifications", func() {
+		recorder := post(`[{"jsonrpc": "2.0", "method": "eth_getBalance", "params": ["` + address.Hex() + `", "latest"]}]`)
+
+		Expect(recorder.Code).To(Equal(http.StatusNoContent))
+		Expect(recorder.Body.Len()).To(BeZero())
+	})
+
+	It("returns errors for unknown methods and malformed requests", func() {
+		Expect(call("eth_sendRawTransaction").Error.Code).To(Equal(-32601))
+		Expect(call("eth_getBalance").Error.Code).To(Equal(-32602))
+		var response rpcResponse
+		Expect(json.Unmarshal(post("{").Body.Bytes(), &response)).To(Succeed())
+		Expect(response.Error.Code).To(Equal(-32700))
+	})
+
+	It("only accepts POST requests", func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/rpc", nil))
+
+		Expect(recorder.Code).To(Equal(http.StatusMethodNotAllowed))
+	})
+
+	It("fills in log fields as geth does", func() {
+		fields := result(call("eth_getTransactionReceipt", block.transactions[0].Hash()))
+
+		log := fields["logs"].([]interface{})[0].(map[string]interface{})
+		Expect(log["transactionHash"]).To(Equal(block.transactions[0].Hash().Hex()))
+		Expect(log["logIndex"]).To(Equal("0x0"))
+	})
+})
@@ -0,0 +1,144 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+)
+
+var errKeyNotFound = errors.New("Key not found in trie")
+
+// getTrieValue walks the published IPLDs of the state or storage trie with
+// root, encoded with codec, to the leaf of key, returning the leaf's value
+func getTrieValue(ctx context.Context, getter ipfs.Getter, codec uint64, root common.Hash, key []byte) ([]byte, error) {
+	path := keyToNibbles(key)
+	node, err := getTrieNode(ctx, getter, codec, root.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	for {
+		elems, _, err := rlp.SplitList(node)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding trie node: %s", err)
+		}
+		count, err := rlp.CountValues(elems)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding trie node: %s", err)
+		}
+		switch count {
+		case 17:
+			// branch node, with a child per nibble and a value
+			if len(path) == 0 {
+				return nil, errKeyNotFound
+			}
+			child, err := nthValue(elems, int(path[0]))
+			if err != nil {
+				return nil, err
+			}
+			path = path[1:]
+			node, err = resolveChild(ctx, getter, codec, child)
+			if err != nil {
+				return nil, err
+			}
+		case 2:
+			// extension or leaf node, holding part of the path
+			compactKey, rest, err := rlp.SplitString(elems)
+			if err != nil {
+				return nil, fmt.Errorf("Error decoding trie node: %s", err)
+			}
+			nibbles, leaf := compactToNibbles(compactKey)
+			if !bytes.HasPrefix(path, nibbles) {
+				return nil, errKeyNotFound
+			}
+			path = path[len(nibbles):]
+			if leaf {
+				value, _, err := rlp.SplitString(rest)
+				if err != nil {
+					return nil, fmt.Errorf("Error decoding trie leaf: %s", err)
+				}
+				return value, nil
+			}
+			node, err = resolveChild(ctx, getter, codec, rest)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("Error decoding trie node: %d items", count)
+		}
+	}
+}
+
+// resolveChild returns a node referenced from its parent, which embeds nodes
+// shorter than a hash and references others by hash
+func resolveChild(ctx context.Context, getter ipfs.Getter, codec uint64, child []byte) ([]byte, error) {
+	kind, content, _, err := rlp.Split(child)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding trie node: %s", err)
+	}
+	if kind == rlp.List {
+		return child, nil
+	}
+	switch len(content) {
+	case 0:
+		return nil, errKeyNotFound
+	case common.HashLength:
+		return getTrieNode(ctx, getter, codec, content)
+	default:
+		return nil, fmt.Errorf("Error decoding trie node: %d byte reference", len(content))
+	}
+}
+
+func getTrieNode(ctx context.Context, getter ipfs.Getter, codec uint64, hash []byte) ([]byte, error) {
+	nodeCid, err := util.HashToCid(codec, hash)
+	if err != nil {
+		return nil, err
+	}
+	node, err := getter.Get(ctx, nodeCid)
+	if err == ipfs.ErrNotPublished {
+		return nil, fmt.Errorf("Trie node %s not published", nodeCid)
+	}
+	return node, err
+}
+
+// nthValue returns the nth of a list's RLP encoded values
+func nthValue(elems []byte, n int) ([]byte, error) {
+	for i := 0; ; i++ {
+		_, _, rest, err := rlp.Split(elems)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding trie node: %s", err)
+		}
+		if i == n {
+			return elems[:len(elems)-len(rest)], nil
+		}
+		elems = rest
+	}
+}
+
+func keyToNibbles(key []byte) []byte {
+	nibbles := make([]byte, 0, 2*len(key))
+	for _, b := range key {
+		nibbles = append(nibbles, b/16, b%16)
+	}
+	return nibbles
+}
+
+// compactToNibbles decodes a hex-prefix encoded path, whose first nibble flags
+// a leaf and an odd number of nibbles
+func compactToNibbles(compact []byte) (nibbles []byte, leaf bool) {
+	if len(compact) == 0 {
+		return nil, false
+	}
+	nibbles = keyToNibbles(compact)
+	leaf = nibbles[0] >= 2
+	if nibbles[0]%2 == 1 {
+		return nibbles[1:], leaf
+	}
+	return nibbles[2:], leaf
+}
@@ -1,7 +1,11 @@
 package ipfs
 
-import ipld "github.com/ipfs/go-ipld-format"
+import (
+	"context"
+
+	ipld "github.com/ipfs/go-ipld-format"
+)
 
 type Adder interface {
-	Add(node ipld.Node) error
+	Add(ctx context.Context, node ipld.Node) error
 }
@@ -1,5 +1,54 @@
 package ipfs
 
-type DagPutter interface {
-	DagPut(raw interface{}) ([]string, error)
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ipfs/go-cid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+// StartEncodeSpan starts a span for encoding an IPLD node with codec, under
+// the block's span in ctx
+func StartEncodeSpan(ctx context.Context, codec uint64) opentracing.Span {
+	span, _ := opentracing.StartSpanFromContext(ctx, "dag.encode", opentracing.Tag{Key: "codec", Value: cid.CodecToStr[codec]})
+	return span
+}
+
+type CodeDagPutter interface {
+	DagPutCode(ctx context.Context, blockNumber int64, code []byte) (Result, error)
+}
+
+type HeaderDagPutter interface {
+	DagPutHeader(ctx context.Context, blockNumber int64, header []byte) (Result, error)
+}
+
+type BodyDagPutter interface {
+	DagPutBody(ctx context.Context, blockNumber int64, body *types.Body) ([]Result, error)
+}
+
+type ReceiptsDagPutter interface {
+	DagPutReceipts(ctx context.Context, blockNumber int64, receipts types.Receipts) ([]Result, error)
+}
+
+type StateTrieNodeDagPutter interface {
+	DagPutStateTrieNode(ctx context.Context, blockNumber int64, node []byte) (Result, error)
+}
+
+type StorageTrieNodeDagPutter interface {
+	DagPutStorageTrieNode(ctx context.Context, blockNumber int64, node []byte) (Result, error)
+}
+
+type StateDiffDagPutter interface {
+	DagPutStateDiff(ctx context.Context, blockNumber int64, diff db.StateDiff) (Result, error)
+}
+
+type WitnessDagPutter interface {
+	DagPutWitness(ctx context.Context, blockNumber int64, witness level.Witness) (Result, error)
+}
+
+type TracesDagPutter interface {
+	DagPutTraces(ctx context.Context, blockNumber int64, traces db.BlockTraces) ([]Result, error)
 }
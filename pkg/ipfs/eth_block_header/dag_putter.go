@@ -1,6 +1,8 @@
 package eth_block_header
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/core/types"
 	ipld "github.com/ipfs/go-ipld-format"
 
@@ -22,17 +24,18 @@ func NewBlockHeaderDagPutter(adder ipfs.Adder, decoder rlp.Decoder) *BlockHeader
 	return &BlockHeaderDagPutter{adder: adder, decoder: decoder}
 }
 
-func (bhdp *BlockHeaderDagPutter) DagPut(raw interface{}) ([]string, error) {
-	input := raw.([]byte)
-	nd, err := bhdp.getNodeForBlockHeader(input)
+func (bhdp *BlockHeaderDagPutter) DagPutHeader(ctx context.Context, blockNumber int64, raw []byte) (ipfs.Result, error) {
+	span := ipfs.StartEncodeSpan(ctx, EthBlockHeaderCode)
+	nd, err := bhdp.getNodeForBlockHeader(raw)
+	span.Finish()
 	if err != nil {
-		return nil, err
+		return ipfs.Result{}, err
 	}
-	err = bhdp.adder.Add(nd)
+	err = bhdp.adder.Add(ctx, nd)
 	if err != nil {
-		return nil, err
+		return ipfs.Result{}, err
 	}
-	return []string{nd.Cid().String()}, nil
+	return ipfs.NewResult(nd, blockNumber), nil
 }
 
 func (bhdp *BlockHeaderDagPutter) getNodeForBlockHeader(raw []byte) (ipld.Node, error) {
@@ -1,6 +1,8 @@
 package eth_block_header_test
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -18,7 +20,7 @@ var _ = Describe("Creating an IPLD for a block header", func() {
 		dagPutter := eth_block_header.NewBlockHeaderDagPutter(ipfs.NewMockAdder(), mockDecoder)
 		fakeBytes := []byte{1, 2, 3, 4, 5}
 
-		_, err := dagPutter.DagPut(fakeBytes)
+		_, err := dagPutter.DagPutHeader(context.Background(), 1, fakeBytes)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockDecoder.AssertDecodeCalledWith(fakeBytes, &types.Header{})
@@ -30,7 +32,7 @@ var _ = Describe("Creating an IPLD for a block header", func() {
 		mockDecoder.SetError(test_helpers.FakeError)
 		dagPutter := eth_block_header.NewBlockHeaderDagPutter(ipfs.NewMockAdder(), mockDecoder)
 
-		_, err := dagPutter.DagPut([]byte{1, 2, 3, 4, 5})
+		_, err := dagPutter.DagPutHeader(context.Background(), 1, []byte{1, 2, 3, 4, 5})
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
@@ -43,12 +45,26 @@ var _ = Describe("Creating an IPLD for a block header", func() {
 		dagPutter := eth_block_header.NewBlockHeaderDagPutter(mockAdder, mockDecoder)
 		fakeBytes := []byte{1, 2, 3, 4, 5}
 
-		_, err := dagPutter.DagPut(fakeBytes)
+		_, err := dagPutter.DagPutHeader(context.Background(), 1, fakeBytes)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockAdder.AssertAddCalled(1, &eth_block_header.EthBlockHeaderNode{})
 	})
 
+	It("adds with the passed context", func() {
+		mockAdder := ipfs.NewMockAdder()
+		mockDecoder := rlp.NewMockDecoder()
+		mockDecoder.SetReturnOut(&types.Header{})
+		dagPutter := eth_block_header.NewBlockHeaderDagPutter(mockAdder, mockDecoder)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		_, err := dagPutter.DagPutHeader(ctx, 1, []byte{1, 2, 3, 4, 5})
+
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder.AssertAddCalledWithContext(ctx)
+	})
+
 	It("returns error if adding to ipfs fails", func() {
 		mockAdder := ipfs.NewMockAdder()
 		mockAdder.SetError(test_helpers.FakeError)
@@ -56,7 +72,7 @@ var _ = Describe("Creating an IPLD for a block header", func() {
 		mockDecoder.SetReturnOut(&types.Header{})
 		dagPutter := eth_block_header.NewBlockHeaderDagPutter(mockAdder, mockDecoder)
 
-		_, err := dagPutter.DagPut([]byte{1, 2, 3, 4, 5})
+		_, err := dagPutter.DagPutHeader(context.Background(), 1, []byte{1, 2, 3, 4, 5})
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
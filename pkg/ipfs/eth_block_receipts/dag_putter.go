@@ -2,6 +2,7 @@ package eth_block_receipts
 
 import (
 	"bytes"
+	"context"
 
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ipfs/go-cid"
@@ -18,19 +19,20 @@ func NewEthBlockReceiptDagPutter(adder ipfs.Adder) *EthBlockReceiptDagPutter {
 	return &EthBlockReceiptDagPutter{adder: adder}
 }
 
-func (dagPutter *EthBlockReceiptDagPutter) DagPut(raw interface{}) ([]string, error) {
-	input := raw.(types.Receipts)
-	var output []string
-	for _, r := range input {
+func (dagPutter *EthBlockReceiptDagPutter) DagPutReceipts(ctx context.Context, blockNumber int64, receipts types.Receipts) ([]ipfs.Result, error) {
+	var output []ipfs.Result
+	for _, r := range receipts {
+		span := ipfs.StartEncodeSpan(ctx, cid.EthTxReceipt)
 		node, err := getReceiptNode(r)
+		span.Finish()
 		if err != nil {
 			return nil, err
 		}
-		err = dagPutter.adder.Add(node)
+		err = dagPutter.adder.Add(ctx, node)
 		if err != nil {
 			return nil, err
 		}
-		output = append(output, node.cid.String())
+		output = append(output, ipfs.NewResult(node, blockNumber))
 	}
 	return output, nil
 }
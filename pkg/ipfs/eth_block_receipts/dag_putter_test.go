@@ -1,7 +1,10 @@
 package eth_block_receipts_test
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ipfs/go-cid"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -19,7 +22,7 @@ var _ = Describe("Eth block receipts dag putter", func() {
 			&types.Receipt{},
 		}
 
-		_, err := dagPutter.DagPut(fakeReceipts)
+		_, err := dagPutter.DagPutReceipts(context.Background(), 1, fakeReceipts)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockAdder.AssertAddCalled(2, &eth_block_receipts.EthReceiptNode{})
@@ -34,9 +37,24 @@ var _ = Describe("Eth block receipts dag putter", func() {
 			&types.Receipt{},
 		}
 
-		_, err := dagPutter.DagPut(fakeReceipts)
+		_, err := dagPutter.DagPutReceipts(context.Background(), 1, fakeReceipts)
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
 	})
+
+	It("returns a result for each published receipt", func() {
+		dagPutter := eth_block_receipts.NewEthBlockReceiptDagPutter(ipfs.NewMockAdder())
+		fakeReceipts := types.Receipts{
+			&types.Receipt{},
+			&types.Receipt{},
+		}
+
+		results, err := dagPutter.DagPutReceipts(context.Background(), 123, fakeReceipts)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(results)).To(Equal(2))
+		Expect(results[0].Codec).To(Equal(uint64(cid.EthTxReceipt)))
+		Expect(results[0].BlockNumber).To(Equal(int64(123)))
+	})
 })
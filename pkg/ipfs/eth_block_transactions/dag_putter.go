@@ -2,6 +2,7 @@ package eth_block_transactions
 
 import (
 	"bytes"
+	"context"
 
 	"github.com/ethereum/go-ethereum/core/types"
 
@@ -21,30 +22,38 @@ func NewBlockTransactionsDagPutter(adder ipfs.Adder) *BlockTransactionsDagPutter
 	return &BlockTransactionsDagPutter{adder: adder}
 }
 
-func (bbdp *BlockTransactionsDagPutter) DagPut(body interface{}) ([]string, error) {
-	blockBody := body.(*types.Body)
-	transactions := blockBody.Transactions
-	var cids []string
+func (bbdp *BlockTransactionsDagPutter) DagPutBody(ctx context.Context, blockNumber int64, body *types.Body) ([]ipfs.Result, error) {
+	transactions := body.Transactions
+	var results []ipfs.Result
 	for _, transaction := range transactions {
-		buffer := new(bytes.Buffer)
-		err := transaction.EncodeRLP(buffer)
+		span := ipfs.StartEncodeSpan(ctx, EthBlockTransactionCode)
+		transactionNode, err := getTransactionNode(transaction)
+		span.Finish()
 		if err != nil {
 			return nil, err
 		}
-		transactionCid, err := util.RawToCid(EthBlockTransactionCode, buffer.Bytes())
+		err = bbdp.adder.Add(ctx, transactionNode)
 		if err != nil {
 			return nil, err
 		}
-		transactionNode := &EthTransactionNode{
-			Transaction: transaction,
-			cid:         transactionCid,
-			rawdata:     buffer.Bytes(),
-		}
-		err = bbdp.adder.Add(transactionNode)
-		if err != nil {
-			return nil, err
-		}
-		cids = append(cids, transactionCid.String())
+		results = append(results, ipfs.NewResult(transactionNode, blockNumber))
+	}
+	return results, nil
+}
+
+func getTransactionNode(transaction *types.Transaction) (*EthTransactionNode, error) {
+	buffer := new(bytes.Buffer)
+	err := transaction.EncodeRLP(buffer)
+	if err != nil {
+		return nil, err
+	}
+	transactionCid, err := util.RawToCid(EthBlockTransactionCode, buffer.Bytes())
+	if err != nil {
+		return nil, err
 	}
-	return cids, nil
+	return &EthTransactionNode{
+		Transaction: transaction,
+		cid:         transactionCid,
+		rawdata:     buffer.Bytes(),
+	}, nil
 }
@@ -1,6 +1,8 @@
 package eth_block_transactions_test
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -21,7 +23,7 @@ var _ = Describe("Eth block transactions dag putter", func() {
 		}
 		dagPutter := eth_block_transactions.NewBlockTransactionsDagPutter(mockAdder)
 
-		_, err := dagPutter.DagPut(fakeBlockBody)
+		_, err := dagPutter.DagPutBody(context.Background(), 1, fakeBlockBody)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockAdder.AssertAddCalled(2, &eth_block_transactions.EthTransactionNode{})
@@ -32,7 +34,7 @@ var _ = Describe("Eth block transactions dag putter", func() {
 		mockAdder.SetError(test_helpers.FakeError)
 		dagPutter := eth_block_transactions.NewBlockTransactionsDagPutter(mockAdder)
 
-		_, err := dagPutter.DagPut(&types.Body{Transactions: types.Transactions{{}}})
+		_, err := dagPutter.DagPutBody(context.Background(), 1, &types.Body{Transactions: types.Transactions{{}}})
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
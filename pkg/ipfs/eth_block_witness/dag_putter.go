@@ -0,0 +1,75 @@
+package eth_block_witness
+
+import (
+	"context"
+	"math"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipld-cbor"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+)
+
+// WitnessDagPutter publishes a block witness as a dag-cbor object linking to
+// the block header and to each state and storage trie node in the witness.
+// The trie nodes themselves are published separately.
+type WitnessDagPutter struct {
+	adder ipfs.Adder
+}
+
+func NewWitnessDagPutter(adder ipfs.Adder) *WitnessDagPutter {
+	return &WitnessDagPutter{adder: adder}
+}
+
+func (wdp WitnessDagPutter) DagPutWitness(ctx context.Context, blockNumber int64, witness level.Witness) (ipfs.Result, error) {
+	span := ipfs.StartEncodeSpan(ctx, cid.DagCBOR)
+	node, err := wdp.getWitnessNode(blockNumber, witness)
+	span.Finish()
+	if err != nil {
+		return ipfs.Result{}, err
+	}
+	err = wdp.adder.Add(ctx, node)
+	if err != nil {
+		return ipfs.Result{}, err
+	}
+	return ipfs.NewResult(node, blockNumber), nil
+}
+
+func (wdp WitnessDagPutter) getWitnessNode(blockNumber int64, witness level.Witness) (*cbornode.Node, error) {
+	headerCid, err := util.HashToCid(eth_block_header.EthBlockHeaderCode, witness.BlockHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	stateTrieNodes, err := links(eth_state_trie.EthStateTrieNodeCode, witness.StateTrieNodes)
+	if err != nil {
+		return nil, err
+	}
+	storageTrieNodes, err := links(eth_storage_trie.EthStorageTrieNodeCode, witness.StorageTrieNodes)
+	if err != nil {
+		return nil, err
+	}
+	obj := map[string]interface{}{
+		"blockNumber":      blockNumber,
+		"header":           headerCid,
+		"stateTrieNodes":   stateTrieNodes,
+		"storageTrieNodes": storageTrieNodes,
+	}
+	return cbornode.WrapObject(obj, math.MaxUint64, -1)
+}
+
+func links(codec uint64, nodes [][]byte) ([]interface{}, error) {
+	cids := make([]interface{}, 0, len(nodes))
+	for _, node := range nodes {
+		c, err := util.RawToCid(codec, node)
+		if err != nil {
+			return nil, err
+		}
+		cids = append(cids, c)
+	}
+	return cids, nil
+}
@@ -0,0 +1,75 @@
+package eth_block_witness_test
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipld-cbor"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_witness"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Ethereum block witness dag putter", func() {
+	var fakeWitness = level.Witness{
+		BlockHash:        test_helpers.FakeHash,
+		StateTrieNodes:   [][]byte{{1, 2, 3}},
+		StorageTrieNodes: [][]byte{{4, 5, 6}},
+	}
+
+	It("adds passed witness to ipfs", func() {
+		mockAdder := ipfs.NewMockAdder()
+		dagPutter := eth_block_witness.NewWitnessDagPutter(mockAdder)
+
+		_, err := dagPutter.DagPutWitness(context.Background(), 1, fakeWitness)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder.AssertAddCalled(1, &cbornode.Node{})
+	})
+
+	It("links the witness to the block header and its trie nodes", func() {
+		headerCid, err := util.HashToCid(eth_block_header.EthBlockHeaderCode, test_helpers.FakeHash.Bytes())
+		Expect(err).NotTo(HaveOccurred())
+		stateNodeCid, err := util.RawToCid(eth_state_trie.EthStateTrieNodeCode, fakeWitness.StateTrieNodes[0])
+		Expect(err).NotTo(HaveOccurred())
+		storageNodeCid, err := util.RawToCid(eth_storage_trie.EthStorageTrieNodeCode, fakeWitness.StorageTrieNodes[0])
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder := ipfs.NewMockAdder()
+		dagPutter := eth_block_witness.NewWitnessDagPutter(mockAdder)
+
+		_, err = dagPutter.DagPutWitness(context.Background(), 1, fakeWitness)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder.AssertAddedNodeLinksTo(headerCid)
+		mockAdder.AssertAddedNodeLinksTo(stateNodeCid)
+		mockAdder.AssertAddedNodeLinksTo(storageNodeCid)
+	})
+
+	It("returns error if adding to ipfs fails", func() {
+		mockAdder := ipfs.NewMockAdder()
+		mockAdder.SetError(test_helpers.FakeError)
+		dagPutter := eth_block_witness.NewWitnessDagPutter(mockAdder)
+
+		_, err := dagPutter.DagPutWitness(context.Background(), 1, fakeWitness)
+
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+
+	It("returns result describing the published node", func() {
+		dagPutter := eth_block_witness.NewWitnessDagPutter(ipfs.NewMockAdder())
+
+		result, err := dagPutter.DagPutWitness(context.Background(), 123, fakeWitness)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Codec).To(Equal(uint64(cid.DagCBOR)))
+		Expect(result.BlockNumber).To(Equal(int64(123)))
+	})
+})
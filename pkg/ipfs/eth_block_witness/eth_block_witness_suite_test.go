@@ -0,0 +1,13 @@
+package eth_block_witness_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEthBlockWitness(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EthBlockWitness Suite")
+}
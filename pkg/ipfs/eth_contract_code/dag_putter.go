@@ -0,0 +1,40 @@
+package eth_contract_code
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-merkledag"
+	mh "github.com/multiformats/go-multihash"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// CodeDagPutter publishes contract code as raw IPLDs addressed by keccak-256,
+// so an account's code hash identifies its code's CID
+type CodeDagPutter struct {
+	adder ipfs.Adder
+}
+
+func NewCodeDagPutter(adder ipfs.Adder) *CodeDagPutter {
+	return &CodeDagPutter{adder: adder}
+}
+
+func (cdp CodeDagPutter) DagPutCode(ctx context.Context, blockNumber int64, code []byte) (ipfs.Result, error) {
+	span := ipfs.StartEncodeSpan(ctx, cid.Raw)
+	node, err := merkledag.NewRawNodeWPrefix(code, cid.Prefix{
+		Codec:    cid.Raw,
+		Version:  1,
+		MhType:   mh.KECCAK_256,
+		MhLength: -1,
+	})
+	span.Finish()
+	if err != nil {
+		return ipfs.Result{}, err
+	}
+	err = cdp.adder.Add(ctx, node)
+	if err != nil {
+		return ipfs.Result{}, err
+	}
+	return ipfs.NewResult(node, blockNumber), nil
+}
@@ -0,0 +1,53 @@
+package eth_contract_code_test
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-merkledag"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_contract_code"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Ethereum contract code dag putter", func() {
+	var fakeCode = []byte{0x60, 0x80, 0x60, 0x40}
+
+	It("adds passed code to ipfs", func() {
+		mockAdder := ipfs.NewMockAdder()
+		dagPutter := eth_contract_code.NewCodeDagPutter(mockAdder)
+
+		_, err := dagPutter.DagPutCode(context.Background(), 1, fakeCode)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder.AssertAddCalled(1, &merkledag.RawNode{})
+	})
+
+	It("addresses code by its code hash", func() {
+		expectedCid, err := util.HashToCid(cid.Raw, crypto.Keccak256(fakeCode))
+		Expect(err).NotTo(HaveOccurred())
+		dagPutter := eth_contract_code.NewCodeDagPutter(ipfs.NewMockAdder())
+
+		result, err := dagPutter.DagPutCode(context.Background(), 123, fakeCode)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Cid).To(Equal(expectedCid))
+		Expect(result.Codec).To(Equal(uint64(cid.Raw)))
+		Expect(result.BlockNumber).To(Equal(int64(123)))
+	})
+
+	It("returns error if adding to ipfs fails", func() {
+		mockAdder := ipfs.NewMockAdder()
+		mockAdder.SetError(test_helpers.FakeError)
+		dagPutter := eth_contract_code.NewCodeDagPutter(mockAdder)
+
+		_, err := dagPutter.DagPutCode(context.Background(), 1, fakeCode)
+
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+})
@@ -0,0 +1,13 @@
+package eth_contract_code_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEthContractCode(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EthContractCode Suite")
+}
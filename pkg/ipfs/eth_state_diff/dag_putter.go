@@ -0,0 +1,91 @@
+package eth_state_diff
+
+import (
+	"context"
+	"math"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipld-cbor"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+)
+
+// StateDiffDagPutter publishes state diffs as dag-cbor objects linking to the
+// header of the block that produced them
+type StateDiffDagPutter struct {
+	adder ipfs.Adder
+}
+
+func NewStateDiffDagPutter(adder ipfs.Adder) *StateDiffDagPutter {
+	return &StateDiffDagPutter{adder: adder}
+}
+
+func (sddp StateDiffDagPutter) DagPutStateDiff(ctx context.Context, blockNumber int64, diff db.StateDiff) (ipfs.Result, error) {
+	span := ipfs.StartEncodeSpan(ctx, cid.DagCBOR)
+	node, err := sddp.getStateDiffNode(diff)
+	span.Finish()
+	if err != nil {
+		return ipfs.Result{}, err
+	}
+	err = sddp.adder.Add(ctx, node)
+	if err != nil {
+		return ipfs.Result{}, err
+	}
+	return ipfs.NewResult(node, blockNumber), nil
+}
+
+func (sddp StateDiffDagPutter) getStateDiffNode(diff db.StateDiff) (*cbornode.Node, error) {
+	headerCid, err := util.HashToCid(eth_block_header.EthBlockHeaderCode, diff.BlockHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]interface{}, 0, len(diff.Accounts))
+	for _, accountDiff := range diff.Accounts {
+		accounts = append(accounts, accountDiffObject(accountDiff))
+	}
+	obj := map[string]interface{}{
+		"blockNumber":     diff.BlockNumber,
+		"header":          headerCid,
+		"parentStateRoot": diff.ParentStateRoot.Bytes(),
+		"stateRoot":       diff.StateRoot.Bytes(),
+		"accounts":        accounts,
+	}
+	return cbornode.WrapObject(obj, math.MaxUint64, -1)
+}
+
+func accountDiffObject(diff level.AccountDiff) map[string]interface{} {
+	obj := map[string]interface{}{
+		"addressHash": diff.AddressHash.Bytes(),
+	}
+	if diff.From != nil {
+		obj["from"] = accountObject(diff.From)
+	}
+	if diff.To != nil {
+		obj["to"] = accountObject(diff.To)
+	}
+	if len(diff.Storage) > 0 {
+		storage := make([]interface{}, 0, len(diff.Storage))
+		for _, slot := range diff.Storage {
+			storage = append(storage, map[string]interface{}{
+				"keyHash": slot.KeyHash.Bytes(),
+				"from":    slot.From.Bytes(),
+				"to":      slot.To.Bytes(),
+			})
+		}
+		obj["storage"] = storage
+	}
+	return obj
+}
+
+func accountObject(account *level.Account) map[string]interface{} {
+	return map[string]interface{}{
+		"nonce":       account.Nonce,
+		"balance":     account.Balance.Bytes(),
+		"storageRoot": account.StorageRoot.Bytes(),
+		"codeHash":    account.CodeHash.Bytes(),
+	}
+}
@@ -0,0 +1,83 @@
+package eth_state_diff_test
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipld-cbor"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_diff"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Ethereum state diff dag putter", func() {
+	var fakeDiff = db.StateDiff{
+		BlockNumber: 1,
+		BlockHash:   test_helpers.FakeHash,
+		StateRoot:   common.HexToHash("0x456"),
+		Accounts: []level.AccountDiff{{
+			AddressHash: common.HexToHash("0xabc"),
+			To:          &level.Account{Nonce: 1, Balance: big.NewInt(100)},
+			Storage:     []level.StorageDiff{{KeyHash: common.HexToHash("0x1"), To: common.HexToHash("0x2")}},
+		}},
+	}
+
+	It("adds passed state diff to ipfs", func() {
+		mockAdder := ipfs.NewMockAdder()
+		dagPutter := eth_state_diff.NewStateDiffDagPutter(mockAdder)
+
+		_, err := dagPutter.DagPutStateDiff(context.Background(), 1, fakeDiff)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder.AssertAddCalled(1, &cbornode.Node{})
+	})
+
+	It("links the state diff to the block header", func() {
+		header := &types.Header{Number: big.NewInt(1)}
+		rawHeader, err := rlp.EncodeToBytes(header)
+		Expect(err).NotTo(HaveOccurred())
+		headerCid, err := util.RawToCid(eth_block_header.EthBlockHeaderCode, rawHeader)
+		Expect(err).NotTo(HaveOccurred())
+		diff := fakeDiff
+		diff.BlockHash = header.Hash()
+		mockAdder := ipfs.NewMockAdder()
+		dagPutter := eth_state_diff.NewStateDiffDagPutter(mockAdder)
+
+		_, err = dagPutter.DagPutStateDiff(context.Background(), 1, diff)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder.AssertAddedNodeLinksTo(headerCid)
+	})
+
+	It("returns error if adding to ipfs fails", func() {
+		mockAdder := ipfs.NewMockAdder()
+		mockAdder.SetError(test_helpers.FakeError)
+		dagPutter := eth_state_diff.NewStateDiffDagPutter(mockAdder)
+
+		_, err := dagPutter.DagPutStateDiff(context.Background(), 1, fakeDiff)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+
+	It("returns result describing the published node", func() {
+		dagPutter := eth_state_diff.NewStateDiffDagPutter(ipfs.NewMockAdder())
+
+		result, err := dagPutter.DagPutStateDiff(context.Background(), 123, fakeDiff)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Codec).To(Equal(uint64(cid.DagCBOR)))
+		Expect(result.BlockNumber).To(Equal(int64(123)))
+	})
+})
@@ -0,0 +1,13 @@
+package eth_state_diff_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEthStateDiff(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EthStateDiff Suite")
+}
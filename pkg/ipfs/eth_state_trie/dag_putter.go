@@ -1,6 +1,8 @@
 package eth_state_trie
 
 import (
+	"context"
+
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
 )
@@ -17,17 +19,18 @@ func NewStateTrieDagPutter(adder ipfs.Adder) *StateTrieDagPutter {
 	return &StateTrieDagPutter{adder: adder}
 }
 
-func (stdp StateTrieDagPutter) DagPut(raw interface{}) ([]string, error) {
-	input := raw.([]byte)
-	stateTrieNode, err := stdp.getStateTrieNode(input)
+func (stdp StateTrieDagPutter) DagPutStateTrieNode(ctx context.Context, blockNumber int64, raw []byte) (ipfs.Result, error) {
+	span := ipfs.StartEncodeSpan(ctx, EthStateTrieNodeCode)
+	stateTrieNode, err := stdp.getStateTrieNode(raw)
+	span.Finish()
 	if err != nil {
-		return nil, err
+		return ipfs.Result{}, err
 	}
-	err = stdp.adder.Add(stateTrieNode)
+	err = stdp.adder.Add(ctx, stateTrieNode)
 	if err != nil {
-		return nil, err
+		return ipfs.Result{}, err
 	}
-	return []string{stateTrieNode.Cid().String()}, nil
+	return ipfs.NewResult(stateTrieNode, blockNumber), nil
 }
 
 func (stdp StateTrieDagPutter) getStateTrieNode(raw []byte) (*EthStateTrieNode, error) {
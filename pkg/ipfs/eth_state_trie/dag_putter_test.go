@@ -1,6 +1,8 @@
 package eth_state_trie_test
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -14,7 +16,7 @@ var _ = Describe("Ethereum state trie node dag putter", func() {
 		mockAdder := ipfs.NewMockAdder()
 		dagPutter := eth_state_trie.NewStateTrieDagPutter(mockAdder)
 
-		_, err := dagPutter.DagPut([]byte{1, 2, 3, 4, 5})
+		_, err := dagPutter.DagPutStateTrieNode(context.Background(), 1, []byte{1, 2, 3, 4, 5})
 
 		Expect(err).NotTo(HaveOccurred())
 		mockAdder.AssertAddCalled(1, &eth_state_trie.EthStateTrieNode{})
@@ -25,9 +27,22 @@ var _ = Describe("Ethereum state trie node dag putter", func() {
 		mockAdder.SetError(test_helpers.FakeError)
 		dagPutter := eth_state_trie.NewStateTrieDagPutter(mockAdder)
 
-		_, err := dagPutter.DagPut([]byte{1, 2, 3, 4, 5})
+		_, err := dagPutter.DagPutStateTrieNode(context.Background(), 1, []byte{1, 2, 3, 4, 5})
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
 	})
+
+	It("returns result describing the published node", func() {
+		dagPutter := eth_state_trie.NewStateTrieDagPutter(ipfs.NewMockAdder())
+		fakeNode := []byte{1, 2, 3, 4, 5}
+
+		result, err := dagPutter.DagPutStateTrieNode(context.Background(), 123, fakeNode)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Codec).To(Equal(uint64(eth_state_trie.EthStateTrieNodeCode)))
+		Expect(result.Size).To(Equal(len(fakeNode)))
+		Expect(result.BlockNumber).To(Equal(int64(123)))
+		Expect(result.Cid.Type()).To(Equal(uint64(eth_state_trie.EthStateTrieNodeCode)))
+	})
 })
@@ -1,6 +1,8 @@
 package eth_storage_trie
 
 import (
+	"context"
+
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
 )
@@ -17,19 +19,20 @@ func NewStorageTrieDagPutter(adder ipfs.Adder) *StorageTrieDagPutter {
 	return &StorageTrieDagPutter{adder: adder}
 }
 
-func (stdp StorageTrieDagPutter) DagPut(raw interface{}) ([]string, error) {
-	input := raw.([]byte)
-	cid, err := util.RawToCid(EthStorageTrieNodeCode, input)
+func (stdp StorageTrieDagPutter) DagPutStorageTrieNode(ctx context.Context, blockNumber int64, raw []byte) (ipfs.Result, error) {
+	span := ipfs.StartEncodeSpan(ctx, EthStorageTrieNodeCode)
+	cid, err := util.RawToCid(EthStorageTrieNodeCode, raw)
+	span.Finish()
 	if err != nil {
-		return nil, err
+		return ipfs.Result{}, err
 	}
 	node := &EthStorageTrieNode{
 		cid:     cid,
-		rawdata: input,
+		rawdata: raw,
 	}
-	err = stdp.adder.Add(node)
+	err = stdp.adder.Add(ctx, node)
 	if err != nil {
-		return nil, err
+		return ipfs.Result{}, err
 	}
-	return []string{node.Cid().String()}, nil
+	return ipfs.NewResult(node, blockNumber), nil
 }
@@ -1,6 +1,8 @@
 package eth_storage_trie_test
 
 import (
+	"context"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
@@ -13,7 +15,7 @@ var _ = Describe("Ethereum storage trie node dag putter", func() {
 		mockAdder := ipfs.NewMockAdder()
 		dagPutter := eth_storage_trie.NewStorageTrieDagPutter(mockAdder)
 
-		_, err := dagPutter.DagPut([]byte{1, 2, 3, 4, 5})
+		_, err := dagPutter.DagPutStorageTrieNode(context.Background(), 1, []byte{1, 2, 3, 4, 5})
 
 		Expect(err).NotTo(HaveOccurred())
 		mockAdder.AssertAddCalled(1, &eth_storage_trie.EthStorageTrieNode{})
@@ -24,7 +26,7 @@ var _ = Describe("Ethereum storage trie node dag putter", func() {
 		mockAdder.SetError(test_helpers.FakeError)
 		dagPutter := eth_storage_trie.NewStorageTrieDagPutter(mockAdder)
 
-		_, err := dagPutter.DagPut([]byte{1, 2, 3, 4, 5})
+		_, err := dagPutter.DagPutStorageTrieNode(context.Background(), 1, []byte{1, 2, 3, 4, 5})
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
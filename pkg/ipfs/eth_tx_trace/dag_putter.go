@@ -0,0 +1,84 @@
+package eth_tx_trace
+
+import (
+	"context"
+	"math"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipld-cbor"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_transactions"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+)
+
+// TraceDagPutter publishes each transaction's call trace as a dag-cbor object
+// linking to the transaction and the header of its block
+type TraceDagPutter struct {
+	adder ipfs.Adder
+}
+
+func NewTraceDagPutter(adder ipfs.Adder) *TraceDagPutter {
+	return &TraceDagPutter{adder: adder}
+}
+
+func (tdp TraceDagPutter) DagPutTraces(ctx context.Context, blockNumber int64, traces db.BlockTraces) ([]ipfs.Result, error) {
+	headerCid, err := util.HashToCid(eth_block_header.EthBlockHeaderCode, traces.BlockHash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	var results []ipfs.Result
+	for _, trace := range traces.Transactions {
+		transactionCid, err := util.HashToCid(eth_block_transactions.EthBlockTransactionCode, trace.TxHash.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		obj := map[string]interface{}{
+			"blockNumber": blockNumber,
+			"header":      headerCid,
+			"transaction": transactionCid,
+			"trace":       callFrameObject(trace.CallFrame),
+		}
+		span := ipfs.StartEncodeSpan(ctx, cid.DagCBOR)
+		node, err := cbornode.WrapObject(obj, math.MaxUint64, -1)
+		span.Finish()
+		if err != nil {
+			return nil, err
+		}
+		err = tdp.adder.Add(ctx, node)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, ipfs.NewResult(node, blockNumber))
+	}
+	return results, nil
+}
+
+func callFrameObject(frame *level.CallFrame) map[string]interface{} {
+	obj := map[string]interface{}{
+		"type":    frame.Type,
+		"from":    frame.From.Bytes(),
+		"to":      frame.To.Bytes(),
+		"gas":     frame.Gas,
+		"gasUsed": frame.GasUsed,
+		"input":   []byte(frame.Input),
+		"output":  []byte(frame.Output),
+	}
+	if frame.Value != nil {
+		obj["value"] = frame.Value.Bytes()
+	}
+	if frame.Error != "" {
+		obj["error"] = frame.Error
+	}
+	if len(frame.Calls) > 0 {
+		calls := make([]interface{}, 0, len(frame.Calls))
+		for _, call := range frame.Calls {
+			calls = append(calls, callFrameObject(call))
+		}
+		obj["calls"] = calls
+	}
+	return obj
+}
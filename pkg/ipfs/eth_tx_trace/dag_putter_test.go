@@ -0,0 +1,107 @@
+package eth_tx_trace_test
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipld-cbor"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_transactions"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_tx_trace"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Ethereum transaction trace dag putter", func() {
+	var (
+		fakeTransaction = types.NewTransaction(0, common.HexToAddress("0x1"), big.NewInt(1), 21000, big.NewInt(1), nil)
+		fakeTraces      = db.BlockTraces{
+			BlockNumber: 1,
+			BlockHash:   test_helpers.FakeHash,
+			Transactions: []level.TransactionTrace{{
+				TxHash: fakeTransaction.Hash(),
+				CallFrame: &level.CallFrame{
+					Type:  "CALL",
+					From:  common.HexToAddress("0xaa"),
+					To:    common.HexToAddress("0xbb"),
+					Value: big.NewInt(1),
+					Calls: []*level.CallFrame{{Type: "SELFDESTRUCT", Value: big.NewInt(0)}},
+				},
+			}},
+		}
+	)
+
+	It("adds a node for each transaction's trace", func() {
+		mockAdder := ipfs.NewMockAdder()
+		dagPutter := eth_tx_trace.NewTraceDagPutter(mockAdder)
+		traces := fakeTraces
+		traces.Transactions = append(traces.Transactions, fakeTraces.Transactions[0])
+
+		results, err := dagPutter.DagPutTraces(context.Background(), 1, traces)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(len(results)).To(Equal(2))
+		mockAdder.AssertAddCalled(2, &cbornode.Node{})
+	})
+
+	It("links each trace to its transaction", func() {
+		rawTransaction, err := rlp.EncodeToBytes(fakeTransaction)
+		Expect(err).NotTo(HaveOccurred())
+		transactionCid, err := util.RawToCid(eth_block_transactions.EthBlockTransactionCode, rawTransaction)
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder := ipfs.NewMockAdder()
+		dagPutter := eth_tx_trace.NewTraceDagPutter(mockAdder)
+
+		_, err = dagPutter.DagPutTraces(context.Background(), 1, fakeTraces)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder.AssertAddedNodeLinksTo(transactionCid)
+	})
+
+	It("links each trace to the block header", func() {
+		header := &types.Header{Number: big.NewInt(1)}
+		rawHeader, err := rlp.EncodeToBytes(header)
+		Expect(err).NotTo(HaveOccurred())
+		headerCid, err := util.RawToCid(eth_block_header.EthBlockHeaderCode, rawHeader)
+		Expect(err).NotTo(HaveOccurred())
+		traces := fakeTraces
+		traces.BlockHash = header.Hash()
+		mockAdder := ipfs.NewMockAdder()
+		dagPutter := eth_tx_trace.NewTraceDagPutter(mockAdder)
+
+		_, err = dagPutter.DagPutTraces(context.Background(), 1, traces)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockAdder.AssertAddedNodeLinksTo(headerCid)
+	})
+
+	It("returns error if adding to ipfs fails", func() {
+		mockAdder := ipfs.NewMockAdder()
+		mockAdder.SetError(test_helpers.FakeError)
+		dagPutter := eth_tx_trace.NewTraceDagPutter(mockAdder)
+
+		_, err := dagPutter.DagPutTraces(context.Background(), 1, fakeTraces)
+
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+
+	It("returns results describing the published nodes", func() {
+		dagPutter := eth_tx_trace.NewTraceDagPutter(ipfs.NewMockAdder())
+
+		results, err := dagPutter.DagPutTraces(context.Background(), 123, fakeTraces)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results[0].Codec).To(Equal(uint64(cid.DagCBOR)))
+		Expect(results[0].BlockNumber).To(Equal(int64(123)))
+	})
+})
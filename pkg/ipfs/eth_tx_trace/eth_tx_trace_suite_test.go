@@ -0,0 +1,13 @@
+package eth_tx_trace_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEthTxTrace(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "EthTxTrace Suite")
+}
@@ -0,0 +1,16 @@
+package ipfs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ipfs/go-cid"
+)
+
+// ErrNotPublished is returned for IPLDs that are not in the repo
+var ErrNotPublished = errors.New("IPLD not published")
+
+type Getter interface {
+	// Get returns the raw data of the IPLD with c
+	Get(ctx context.Context, c cid.Cid) ([]byte, error)
+}
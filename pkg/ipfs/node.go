@@ -2,20 +2,66 @@ package ipfs
 
 import (
 	"context"
+	"time"
 
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-ipfs-blockstore"
 	"github.com/ipfs/go-ipfs/core"
 	"github.com/ipfs/go-ipfs/repo/fsrepo"
 
 	ipld "github.com/ipfs/go-ipld-format"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
 )
 
 type IPFS struct {
-	n   *core.IpfsNode
-	ctx context.Context
+	n *core.IpfsNode
+}
+
+// Add stores node unless the repo already has it, which is counted as a
+// dedup hit
+func (ipfs IPFS) Add(ctx context.Context, node ipld.Node) error {
+	defer metrics.IpfsAddSeconds.ObserveSince(time.Now())
+	codec := cid.CodecToStr[node.Cid().Type()]
+	span, ctx := opentracing.StartSpanFromContext(ctx, "ipfs.add", opentracing.Tag{Key: "codec", Value: codec})
+	defer span.Finish()
+	has, err := ipfs.n.Blockstore.Has(node.Cid())
+	if err == nil && has {
+		metrics.DedupHits.With(codec).Inc()
+		span.SetTag("dedup", true)
+		return nil
+	}
+	if err == nil {
+		err = ipfs.n.DAG.Add(ctx, node)
+	}
+	if err != nil {
+		metrics.Errors.With(metrics.IpfsAddError).Inc()
+		ext.Error.Set(span, true)
+		return err
+	}
+	metrics.NodesPublished.With(codec).Inc()
+	metrics.BytesWritten.With(codec).Add(float64(len(node.RawData())))
+	return nil
+}
+
+// Get returns the raw data of the IPLD with c, or ErrNotPublished if the repo
+// does not have it
+func (ipfs IPFS) Get(ctx context.Context, c cid.Cid) ([]byte, error) {
+	block, err := ipfs.n.Blockstore.Get(c)
+	if err == blockstore.ErrNotFound {
+		return nil, ErrNotPublished
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block.RawData(), nil
 }
 
-func (ipfs IPFS) Add(node ipld.Node) error {
-	return ipfs.n.DAG.Add(ipfs.n.Context(), node)
+// Close flushes the node's datastore and releases its repo
+func (ipfs IPFS) Close() error {
+	return ipfs.n.Close()
 }
 
 func InitIPFSNode(repoPath string) (*IPFS, error) {
@@ -23,14 +69,13 @@ func InitIPFSNode(repoPath string) (*IPFS, error) {
 	if err != nil {
 		return nil, err
 	}
-	ctx := context.Background()
 	cfg := &core.BuildCfg{
 		Online: false,
 		Repo:   r,
 	}
-	ipfsNode, err := core.NewNode(ctx, cfg)
+	ipfsNode, err := core.NewNode(context.Background(), cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &IPFS{n: ipfsNode, ctx: ctx}, nil
+	return &IPFS{n: ipfsNode}, nil
 }
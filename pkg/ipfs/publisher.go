@@ -1,6 +1,13 @@
 package ipfs
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
 
 type Error struct {
 	msg string
@@ -11,22 +18,146 @@ func (ie Error) Error() string {
 	return fmt.Sprintf("%s: %s", ie.msg, ie.err.Error())
 }
 
-type Publisher interface {
-	Write(input interface{}) ([]string, error)
+type CodePublisher interface {
+	WriteCode(ctx context.Context, blockNumber int64, code []byte) (Result, error)
+}
+
+type HeaderPublisher interface {
+	WriteHeader(ctx context.Context, blockNumber int64, header []byte) (Result, error)
+}
+
+type BodyPublisher interface {
+	WriteBody(ctx context.Context, blockNumber int64, body *types.Body) ([]Result, error)
+}
+
+type ReceiptsPublisher interface {
+	WriteReceipts(ctx context.Context, blockNumber int64, receipts types.Receipts) ([]Result, error)
+}
+
+type StateTrieNodePublisher interface {
+	WriteStateTrieNode(ctx context.Context, blockNumber int64, node []byte) (Result, error)
+}
+
+type StorageTrieNodePublisher interface {
+	WriteStorageTrieNode(ctx context.Context, blockNumber int64, node []byte) (Result, error)
+}
+
+type StateDiffPublisher interface {
+	WriteStateDiff(ctx context.Context, blockNumber int64, diff db.StateDiff) (Result, error)
+}
+
+type TracesPublisher interface {
+	WriteTraces(ctx context.Context, blockNumber int64, traces db.BlockTraces) ([]Result, error)
+}
+
+type WitnessPublisher interface {
+	WriteWitness(ctx context.Context, blockNumber int64, witness level.Witness) (Result, error)
+}
+
+type ContractCodePublisher struct {
+	CodeDagPutter
+}
+
+func NewCodePublisher(dagPutter CodeDagPutter) *ContractCodePublisher {
+	return &ContractCodePublisher{CodeDagPutter: dagPutter}
+}
+
+func (ip *ContractCodePublisher) WriteCode(ctx context.Context, blockNumber int64, code []byte) (Result, error) {
+	return ip.CodeDagPutter.DagPutCode(ctx, blockNumber, code)
+}
+
+type BlockHeaderPublisher struct {
+	HeaderDagPutter
+}
+
+func NewHeaderPublisher(dagPutter HeaderDagPutter) *BlockHeaderPublisher {
+	return &BlockHeaderPublisher{HeaderDagPutter: dagPutter}
+}
+
+func (ip *BlockHeaderPublisher) WriteHeader(ctx context.Context, blockNumber int64, header []byte) (Result, error) {
+	return ip.HeaderDagPutter.DagPutHeader(ctx, blockNumber, header)
+}
+
+type BlockBodyPublisher struct {
+	BodyDagPutter
+}
+
+func NewBodyPublisher(dagPutter BodyDagPutter) *BlockBodyPublisher {
+	return &BlockBodyPublisher{BodyDagPutter: dagPutter}
+}
+
+func (ip *BlockBodyPublisher) WriteBody(ctx context.Context, blockNumber int64, body *types.Body) ([]Result, error) {
+	return ip.BodyDagPutter.DagPutBody(ctx, blockNumber, body)
+}
+
+type BlockReceiptsPublisher struct {
+	ReceiptsDagPutter
+}
+
+func NewReceiptsPublisher(dagPutter ReceiptsDagPutter) *BlockReceiptsPublisher {
+	return &BlockReceiptsPublisher{ReceiptsDagPutter: dagPutter}
+}
+
+func (ip *BlockReceiptsPublisher) WriteReceipts(ctx context.Context, blockNumber int64, receipts types.Receipts) ([]Result, error) {
+	return ip.ReceiptsDagPutter.DagPutReceipts(ctx, blockNumber, receipts)
+}
+
+type StateTriePublisher struct {
+	StateTrieNodeDagPutter
+}
+
+func NewStateTriePublisher(dagPutter StateTrieNodeDagPutter) *StateTriePublisher {
+	return &StateTriePublisher{StateTrieNodeDagPutter: dagPutter}
+}
+
+func (ip *StateTriePublisher) WriteStateTrieNode(ctx context.Context, blockNumber int64, node []byte) (Result, error) {
+	return ip.StateTrieNodeDagPutter.DagPutStateTrieNode(ctx, blockNumber, node)
+}
+
+type StorageTriePublisher struct {
+	StorageTrieNodeDagPutter
+}
+
+func NewStorageTriePublisher(dagPutter StorageTrieNodeDagPutter) *StorageTriePublisher {
+	return &StorageTriePublisher{StorageTrieNodeDagPutter: dagPutter}
+}
+
+func (ip *StorageTriePublisher) WriteStorageTrieNode(ctx context.Context, blockNumber int64, node []byte) (Result, error) {
+	return ip.StorageTrieNodeDagPutter.DagPutStorageTrieNode(ctx, blockNumber, node)
+}
+
+type BlockStateDiffPublisher struct {
+	StateDiffDagPutter
+}
+
+func NewStateDiffPublisher(dagPutter StateDiffDagPutter) *BlockStateDiffPublisher {
+	return &BlockStateDiffPublisher{StateDiffDagPutter: dagPutter}
+}
+
+func (ip *BlockStateDiffPublisher) WriteStateDiff(ctx context.Context, blockNumber int64, diff db.StateDiff) (Result, error) {
+	return ip.StateDiffDagPutter.DagPutStateDiff(ctx, blockNumber, diff)
+}
+
+type BlockWitnessPublisher struct {
+	WitnessDagPutter
+}
+
+func NewWitnessPublisher(dagPutter WitnessDagPutter) *BlockWitnessPublisher {
+	return &BlockWitnessPublisher{WitnessDagPutter: dagPutter}
+}
+
+func (ip *BlockWitnessPublisher) WriteWitness(ctx context.Context, blockNumber int64, witness level.Witness) (Result, error) {
+	return ip.WitnessDagPutter.DagPutWitness(ctx, blockNumber, witness)
 }
 
-type BlockDataPublisher struct {
-	DagPutter
+type BlockTracesPublisher struct {
+	TracesDagPutter
 }
 
-func NewIpfsPublisher(dagPutter DagPutter) *BlockDataPublisher {
-	return &BlockDataPublisher{DagPutter: dagPutter}
+func NewTracesPublisher(dagPutter TracesDagPutter) *BlockTracesPublisher {
+	return &BlockTracesPublisher{TracesDagPutter: dagPutter}
 }
 
-func (ip *BlockDataPublisher) Write(input interface{}) ([]string, error) {
-	cids, err := ip.DagPutter.DagPut(input)
-	if err != nil {
-		return nil, err
-	}
-	return cids, nil
+func (ip *BlockTracesPublisher) WriteTraces(ctx context.Context, blockNumber int64, traces db.BlockTraces) ([]Result, error) {
+	return ip.TracesDagPutter.DagPutTraces(ctx, blockNumber, traces)
 }
@@ -1,6 +1,9 @@
 package ipfs_test
 
 import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
@@ -11,24 +14,60 @@ import (
 var _ = Describe("IPFS publisher", func() {
 	It("calls dag put with the passed data", func() {
 		mockDagPutter := ipfs_wrapper.NewMockDagPutter()
-		publisher := ipfs.NewIpfsPublisher(mockDagPutter)
+		publisher := ipfs.NewHeaderPublisher(mockDagPutter)
 		fakeBytes := []byte{1, 2, 3, 4, 5}
 
-		_, err := publisher.DagPut(fakeBytes)
+		_, err := publisher.WriteHeader(context.Background(), 123, fakeBytes)
 
 		Expect(err).NotTo(HaveOccurred())
 		Expect(mockDagPutter.Called).To(BeTrue())
+		Expect(mockDagPutter.PassedBlockNumber).To(Equal(int64(123)))
 		Expect(mockDagPutter.PassedInterface).To(Equal(fakeBytes))
 	})
 
+	It("passes the context to dag put", func() {
+		mockDagPutter := ipfs_wrapper.NewMockDagPutter()
+		publisher := ipfs.NewHeaderPublisher(mockDagPutter)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		_, err := publisher.WriteHeader(ctx, 123, []byte{1, 2, 3, 4, 5})
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mockDagPutter.PassedContext).To(Equal(ctx))
+	})
+
 	It("returns error if dag put fails", func() {
 		mockDagPutter := ipfs_wrapper.NewMockDagPutter()
 		mockDagPutter.SetError(test_helpers.FakeError)
-		publisher := ipfs.NewIpfsPublisher(mockDagPutter)
+		publisher := ipfs.NewHeaderPublisher(mockDagPutter)
 
-		_, err := publisher.DagPut([]byte{1, 2, 3, 4, 5})
+		_, err := publisher.WriteHeader(context.Background(), 123, []byte{1, 2, 3, 4, 5})
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
 	})
+
+	It("calls typed dag put for each kind of block data", func() {
+		mockDagPutter := ipfs_wrapper.NewMockDagPutter()
+		fakeBody := &types.Body{}
+		fakeReceipts := types.Receipts{}
+		fakeNode := []byte{1, 2, 3, 4, 5}
+
+		_, err := ipfs.NewBodyPublisher(mockDagPutter).WriteBody(context.Background(), 1, fakeBody)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mockDagPutter.PassedInterface).To(Equal(fakeBody))
+
+		_, err = ipfs.NewReceiptsPublisher(mockDagPutter).WriteReceipts(context.Background(), 2, fakeReceipts)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mockDagPutter.PassedInterface).To(Equal(fakeReceipts))
+
+		_, err = ipfs.NewStateTriePublisher(mockDagPutter).WriteStateTrieNode(context.Background(), 3, fakeNode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mockDagPutter.PassedBlockNumber).To(Equal(int64(3)))
+
+		_, err = ipfs.NewStorageTriePublisher(mockDagPutter).WriteStorageTrieNode(context.Background(), 4, fakeNode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mockDagPutter.PassedBlockNumber).To(Equal(int64(4)))
+	})
 })
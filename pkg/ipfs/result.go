@@ -0,0 +1,27 @@
+package ipfs
+
+import (
+	"github.com/ipfs/go-cid"
+	ipld "github.com/ipfs/go-ipld-format"
+)
+
+// Result describes an IPLD node written to IPFS.
+type Result struct {
+	Cid         cid.Cid
+	Codec       uint64
+	Size        int
+	BlockNumber int64
+}
+
+func NewResult(node ipld.Node, blockNumber int64) Result {
+	return Result{
+		Cid:         node.Cid(),
+		Codec:       node.Cid().Type(),
+		Size:        len(node.RawData()),
+		BlockNumber: blockNumber,
+	}
+}
+
+func (r Result) String() string {
+	return r.Cid.String()
+}
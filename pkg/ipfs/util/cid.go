@@ -17,3 +17,13 @@ func RawToCid(codec uint64, raw []byte) (cid.Cid, error) {
 	}
 	return c, nil
 }
+
+// HashToCid returns the CID of an object whose keccak-256 hash is already known,
+// e.g. a block header from its block hash
+func HashToCid(codec uint64, hash []byte) (cid.Cid, error) {
+	multihash, err := mh.Encode(hash, mh.KECCAK_256)
+	if err != nil {
+		return cid.Cid{}, err
+	}
+	return cid.NewCidV1(codec, multihash), nil
+}
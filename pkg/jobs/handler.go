@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// NewHandler returns the HTTP API of manager:
+//
+//	POST   /jobs       submits the job in the request body, which reads from
+//	                   and publishes to the manager's default sources
+//	GET    /jobs       lists every job
+//	GET    /jobs/<id>  returns a job's status
+//	DELETE /jobs/<id>  cancels a job
+func NewHandler(manager *Manager) http.Handler {
+	h := handler{manager: manager}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/jobs", h.jobs)
+	mux.HandleFunc("/jobs/", h.job)
+	return mux
+}
+
+type handler struct {
+	manager *Manager
+}
+
+func (h handler) jobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, h.manager.List())
+	case http.MethodPost:
+		var job Job
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		err := decoder.Decode(&job)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		// clients cannot choose which directories the server opens
+		if job.LevelDbPath != "" || job.IpfsPath != "" {
+			writeError(w, http.StatusBadRequest, ErrSourcePaths)
+			return
+		}
+		status, err := h.manager.Submit(job)
+		if err == ErrStopping {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		w.Header().Set("Location", "/jobs/"+status.ID)
+		writeJSON(w, http.StatusCreated, status)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPost)
+	}
+}
+
+func (h handler) job(w http.ResponseWriter, r *http.Request) {
+	id := strings.TrimPrefix(r.URL.Path, "/jobs/")
+	var status Status
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		status, err = h.manager.Get(id)
+	case http.MethodDelete:
+		status, err = h.manager.Cancel(id)
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		return
+	}
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusOK, status)
+	case ErrUnknownJob:
+		writeError(w, http.StatusNotFound, err)
+	case ErrJobFinished:
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "Method not allowed"})
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
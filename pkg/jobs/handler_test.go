@@ -0,0 +1,135 @@
+package jobs_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/jobs"
+)
+
+var _ = Describe("Job API", func() {
+	var (
+		cancel  context.CancelFunc
+		manager *jobs.Manager
+		release chan struct{}
+		handler http.Handler
+	)
+
+	BeforeEach(func() {
+		var ctx context.Context
+		ctx, cancel = context.WithCancel(context.Background())
+		release = make(chan struct{})
+		manager = jobs.NewManager(ctx, 1, jobs.Job{LevelDbPath: "chaindata", IpfsPath: "ipfs"}, func(ctx context.Context, job jobs.Job, progress func(blockNumber, done, total int64)) error {
+			select {
+			case <-release:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		handler = jobs.NewHandler(manager)
+	})
+
+	AfterEach(func() {
+		cancel()
+		manager.Wait()
+	})
+
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return recorder
+	}
+
+	decodeStatus := func(recorder *httptest.ResponseRecorder) jobs.Status {
+		var status jobs.Status
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &status)).To(Succeed())
+		return status
+	}
+
+	It("submits jobs", func() {
+		response := request("POST", "/jobs", `{"name": "headers", "types": ["header"], "startingBlockNumber": 1, "endingBlockNumber": 10}`)
+
+		Expect(response.Code).To(Equal(http.StatusCreated))
+		Expect(response.Header().Get("Location")).To(Equal("/jobs/1"))
+		Expect(response.Header().Get("Content-Type")).To(Equal("application/json"))
+		status := decodeStatus(response)
+		Expect(status.ID).To(Equal("1"))
+		Expect(status.Job).To(Equal(jobs.Job{
+			Name:                "headers",
+			LevelDbPath:         "chaindata",
+			IpfsPath:            "ipfs",
+			Types:               []string{"header"},
+			StartingBlockNumber: 1,
+			EndingBlockNumber:   10,
+		}))
+		Expect(status.Total).To(Equal(int64(10)))
+	})
+
+	It("rejects malformed and invalid jobs", func() {
+		Expect(request("POST", "/jobs", `{"types": "header"`).Code).To(Equal(http.StatusBadRequest))
+		Expect(request("POST", "/jobs", `{"typo": 1}`).Code).To(Equal(http.StatusBadRequest))
+
+		response := request("POST", "/jobs", `{"ipfsPath": "/other/ipfs", "endingBlockNumber": 1}`)
+
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+		Expect(response.Body.String()).To(ContainSubstring(jobs.ErrSourcePaths.Error()))
+
+		response = request("POST", "/jobs", `{"startingBlockNumber": 10, "endingBlockNumber": 1}`)
+
+		Expect(response.Code).To(Equal(http.StatusBadRequest))
+		Expect(response.Body.String()).To(ContainSubstring("ending block number must be greater than or equal to starting block number"))
+	})
+
+	It("lists and gets jobs", func() {
+		request("POST", "/jobs", `{"endingBlockNumber": 1}`)
+		request("POST", "/jobs", `{"endingBlockNumber": 2}`)
+
+		response := request("GET", "/jobs", "")
+
+		Expect(response.Code).To(Equal(http.StatusOK))
+		var statuses []jobs.Status
+		Expect(json.Unmarshal(response.Body.Bytes(), &statuses)).To(Succeed())
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses[1].Job.EndingBlockNumber).To(Equal(int64(2)))
+		status := decodeStatus(request("GET", "/jobs/2", ""))
+		Expect(status.ID).To(Equal("2"))
+		Expect(status.State).To(Equal(jobs.Queued))
+	})
+
+	It("cancels jobs", func() {
+		request("POST", "/jobs", `{"endingBlockNumber": 1}`)
+
+		response := request("DELETE", "/jobs/1", "")
+
+		Expect(response.Code).To(Equal(http.StatusOK))
+		Expect(decodeStatus(response).State).To(Equal(jobs.Cancelling))
+		manager.Wait()
+		Expect(decodeStatus(request("GET", "/jobs/1", "")).State).To(Equal(jobs.Cancelled))
+		Expect(request("DELETE", "/jobs/1", "").Code).To(Equal(http.StatusConflict))
+	})
+
+	It("returns not found for unknown jobs", func() {
+		Expect(request("GET", "/jobs/1", "").Code).To(Equal(http.StatusNotFound))
+		Expect(request("DELETE", "/jobs/1", "").Code).To(Equal(http.StatusNotFound))
+	})
+
+	It("rejects other methods", func() {
+		response := request("PUT", "/jobs", "")
+
+		Expect(response.Code).To(Equal(http.StatusMethodNotAllowed))
+		Expect(response.Header().Get("Allow")).To(Equal("GET, POST"))
+	})
+
+	It("rejects jobs once stopping", func() {
+		cancel()
+
+		Expect(request("POST", "/jobs", `{"endingBlockNumber": 1}`).Code).To(Equal(http.StatusServiceUnavailable))
+	})
+})
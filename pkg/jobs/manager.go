@@ -0,0 +1,233 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// States of a job submitted to a Manager
+const (
+	Queued     = "queued"
+	Running    = "running"
+	Cancelling = "cancelling"
+	Succeeded  = "succeeded"
+	Failed     = "failed"
+	Cancelled  = "cancelled"
+)
+
+var (
+	ErrUnknownJob  = errors.New("Unknown job")
+	ErrJobFinished = errors.New("Job already finished")
+	ErrStopping    = errors.New("Not accepting jobs while stopping")
+	ErrSourcePaths = errors.New("Jobs cannot set levelDbPath or ipfsPath, which are those of the server's config")
+)
+
+// Executor runs job until it is done or ctx is cancelled, calling progress
+// each time a block is done
+type Executor func(ctx context.Context, job Job, progress func(blockNumber, done, total int64)) error
+
+// Status is the state and progress of a job submitted to a Manager
+type Status struct {
+	ID    string `json:"id"`
+	Job   Job    `json:"job"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+	// LastBlock is the last block done, if any
+	LastBlock *int64     `json:"lastBlock,omitempty"`
+	Done      int64      `json:"done"`
+	Total     int64      `json:"total"`
+	Submitted time.Time  `json:"submitted"`
+	Started   *time.Time `json:"started,omitempty"`
+	Finished  *time.Time `json:"finished,omitempty"`
+}
+
+// Manager runs submitted jobs in the order they were submitted, at most
+// parallel at once, and keeps the status of every job it was given.
+// Cancelling ctx cancels every job.
+type Manager struct {
+	sync.Mutex
+	ctx      context.Context
+	defaults Job
+	execute  Executor
+	ids      []string
+	jobs     map[string]*managedJob
+	parallel int
+	queue    []*managedJob
+	running  int
+	wg       sync.WaitGroup
+}
+
+type managedJob struct {
+	status Status
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewManager returns a manager running jobs with execute. Submitted jobs
+// without a chaindata directory or IPFS repo use those of defaults.
+func NewManager(ctx context.Context, parallel int, defaults Job, execute Executor) *Manager {
+	if parallel < 1 {
+		parallel = 1
+	}
+	m := &Manager{
+		ctx:      ctx,
+		defaults: defaults,
+		execute:  execute,
+		jobs:     make(map[string]*managedJob),
+		parallel: parallel,
+	}
+	go m.cancelQueuedWhenDone()
+	return m
+}
+
+// Submit queues job to run once fewer than parallel jobs are running
+func (m *Manager) Submit(job Job) (Status, error) {
+	m.Lock()
+	defer m.Unlock()
+	if m.ctx.Err() != nil {
+		return Status{}, ErrStopping
+	}
+	id := strconv.Itoa(len(m.ids) + 1)
+	job = job.withDefaults(m.defaults, "job "+id)
+	err := job.validate()
+	if err != nil {
+		return Status{}, err
+	}
+	ctx, cancel := context.WithCancel(m.ctx)
+	managed := &managedJob{
+		status: Status{
+			ID:        id,
+			Job:       job,
+			State:     Queued,
+			Total:     job.EndingBlockNumber - job.StartingBlockNumber + 1,
+			Submitted: time.Now(),
+		},
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	m.ids = append(m.ids, id)
+	m.jobs[id] = managed
+	m.queue = append(m.queue, managed)
+	m.wg.Add(1)
+	m.startQueued()
+	return managed.status, nil
+}
+
+// Get returns the status of the job with id
+func (m *Manager) Get(id string) (Status, error) {
+	m.Lock()
+	defer m.Unlock()
+	managed, ok := m.jobs[id]
+	if !ok {
+		return Status{}, ErrUnknownJob
+	}
+	return managed.status, nil
+}
+
+// List returns the status of every job, in the order they were submitted
+func (m *Manager) List() []Status {
+	m.Lock()
+	defer m.Unlock()
+	statuses := make([]Status, 0, len(m.ids))
+	for _, id := range m.ids {
+		statuses = append(statuses, m.jobs[id].status)
+	}
+	return statuses
+}
+
+// Cancel stops the job with id. A queued job is never started, and a running
+// job stops after the block in flight.
+func (m *Manager) Cancel(id string) (Status, error) {
+	m.Lock()
+	defer m.Unlock()
+	managed, ok := m.jobs[id]
+	if !ok {
+		return Status{}, ErrUnknownJob
+	}
+	if managed.status.Finished != nil {
+		return managed.status, ErrJobFinished
+	}
+	managed.cancel()
+	if managed.status.State == Queued {
+		m.dequeue(managed)
+		m.finish(managed, context.Canceled)
+	} else {
+		managed.status.State = Cancelling
+	}
+	return managed.status, nil
+}
+
+// Wait returns once every job has finished
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// startQueued starts queued jobs while fewer than parallel are running. The
+// caller holds the lock.
+func (m *Manager) startQueued() {
+	for m.running < m.parallel && len(m.queue) > 0 {
+		managed := m.queue[0]
+		m.queue = m.queue[1:]
+		started := time.Now()
+		managed.status.State = Running
+		managed.status.Started = &started
+		m.running++
+		go m.run(managed, managed.status.Job)
+	}
+}
+
+func (m *Manager) run(managed *managedJob, job Job) {
+	err := m.execute(managed.ctx, job, func(blockNumber, done, total int64) {
+		m.Lock()
+		defer m.Unlock()
+		managed.status.LastBlock = &blockNumber
+		managed.status.Done = done
+		managed.status.Total = total
+	})
+	m.Lock()
+	defer m.Unlock()
+	managed.cancel()
+	m.running--
+	m.finish(managed, err)
+	m.startQueued()
+}
+
+// cancelQueuedWhenDone cancels the queued jobs once the manager's context is
+// done, since they will never start
+func (m *Manager) cancelQueuedWhenDone() {
+	<-m.ctx.Done()
+	m.Lock()
+	defer m.Unlock()
+	for _, managed := range m.queue {
+		m.finish(managed, m.ctx.Err())
+	}
+	m.queue = nil
+}
+
+func (m *Manager) dequeue(managed *managedJob) {
+	for i, queued := range m.queue {
+		if queued == managed {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// finish records the outcome of a job. The caller holds the lock.
+func (m *Manager) finish(managed *managedJob, err error) {
+	finished := time.Now()
+	managed.status.Finished = &finished
+	switch {
+	case err == nil:
+		managed.status.State = Succeeded
+	case err == context.Canceled:
+		managed.status.State = Cancelled
+	default:
+		managed.status.State = Failed
+		managed.status.Error = err.Error()
+	}
+	m.wg.Done()
+}
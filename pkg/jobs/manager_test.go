@@ -0,0 +1,192 @@
+package jobs_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/jobs"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+)
+
+var _ = Describe("Job manager", func() {
+	var (
+		ctx      context.Context
+		cancel   context.CancelFunc
+		defaults jobs.Job
+		job      jobs.Job
+		manager  *jobs.Manager
+		release  chan error
+		started  chan string
+	)
+
+	// execute reports the first block done, then waits to be released or cancelled
+	execute := func(ctx context.Context, job jobs.Job, progress func(blockNumber, done, total int64)) error {
+		started <- job.Name
+		progress(job.StartingBlockNumber, 1, job.EndingBlockNumber-job.StartingBlockNumber+1)
+		select {
+		case err := <-release:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		defaults = jobs.Job{LevelDbPath: "chaindata", IpfsPath: "ipfs"}
+		job = jobs.Job{StartingBlockNumber: 5, EndingBlockNumber: 8}
+		release = make(chan error)
+		started = make(chan string, 10)
+		manager = jobs.NewManager(ctx, 1, defaults, execute)
+	})
+
+	AfterEach(func() {
+		cancel()
+		manager.Wait()
+	})
+
+	It("applies defaults to submitted jobs", func() {
+		status, err := manager.Submit(job)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(status.ID).To(Equal("1"))
+		Expect(status.Job.Name).To(Equal("job 1"))
+		Expect(status.Job.LevelDbPath).To(Equal("chaindata"))
+		Expect(status.Job.IpfsPath).To(Equal("ipfs"))
+		Expect(status.Job.Types).To(Equal(jobs.ExtractTypes))
+		Expect(status.Total).To(Equal(int64(4)))
+	})
+
+	It("rejects invalid jobs", func() {
+		job.Types = []string{"bogus"}
+
+		_, err := manager.Submit(job)
+
+		Expect(err).To(MatchError("Unknown type to extract: bogus"))
+		Expect(manager.List()).To(BeEmpty())
+	})
+
+	It("reports progress and success", func() {
+		status, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(started).Should(Receive())
+
+		Eventually(func() int64 {
+			status, _ := manager.Get(status.ID)
+			return status.Done
+		}).Should(Equal(int64(1)))
+		running, _ := manager.Get(status.ID)
+		Expect(running.State).To(Equal(jobs.Running))
+		Expect(*running.LastBlock).To(Equal(int64(5)))
+		Expect(running.Started).NotTo(BeNil())
+
+		release <- nil
+		manager.Wait()
+		finished, _ := manager.Get(status.ID)
+		Expect(finished.State).To(Equal(jobs.Succeeded))
+		Expect(finished.Finished).NotTo(BeNil())
+	})
+
+	It("reports failures", func() {
+		status, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(started).Should(Receive())
+
+		release <- test_helpers.FakeError
+		manager.Wait()
+
+		failed, _ := manager.Get(status.ID)
+		Expect(failed.State).To(Equal(jobs.Failed))
+		Expect(failed.Error).To(Equal(test_helpers.FakeError.Error()))
+	})
+
+	It("runs at most parallel jobs at once", func() {
+		_, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(started).Should(Receive(Equal("job 1")))
+
+		Consistently(started).ShouldNot(Receive())
+		queued, _ := manager.Get(second.ID)
+		Expect(queued.State).To(Equal(jobs.Queued))
+
+		release <- nil
+		Eventually(started).Should(Receive(Equal("job 2")))
+		release <- nil
+		manager.Wait()
+	})
+
+	It("cancels running jobs", func() {
+		status, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(started).Should(Receive())
+
+		cancelling, err := manager.Cancel(status.ID)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cancelling.State).To(Equal(jobs.Cancelling))
+		manager.Wait()
+		cancelled, _ := manager.Get(status.ID)
+		Expect(cancelled.State).To(Equal(jobs.Cancelled))
+	})
+
+	It("never starts cancelled queued jobs", func() {
+		_, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(started).Should(Receive(Equal("job 1")))
+
+		_, err = manager.Cancel(second.ID)
+		Expect(err).NotTo(HaveOccurred())
+		release <- nil
+		manager.Wait()
+
+		Expect(started).NotTo(Receive())
+		cancelled, _ := manager.Get(second.ID)
+		Expect(cancelled.State).To(Equal(jobs.Cancelled))
+		Expect(cancelled.Started).To(BeNil())
+	})
+
+	It("does not cancel finished jobs", func() {
+		status, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(started).Should(Receive())
+		release <- nil
+		manager.Wait()
+
+		_, err = manager.Cancel(status.ID)
+
+		Expect(err).To(MatchError(jobs.ErrJobFinished))
+	})
+
+	It("returns an error for unknown jobs", func() {
+		_, err := manager.Get("1")
+		Expect(err).To(MatchError(jobs.ErrUnknownJob))
+		_, err = manager.Cancel("1")
+		Expect(err).To(MatchError(jobs.ErrUnknownJob))
+	})
+
+	It("cancels every job and rejects new ones when stopped", func() {
+		first, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := manager.Submit(job)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(started).Should(Receive())
+
+		cancel()
+		manager.Wait()
+
+		statuses := manager.List()
+		Expect(statuses).To(HaveLen(2))
+		Expect(statuses[0].ID).To(Equal(first.ID))
+		Expect(statuses[0].State).To(Equal(jobs.Cancelled))
+		Expect(statuses[1].ID).To(Equal(second.ID))
+		Expect(statuses[1].State).To(Equal(jobs.Cancelled))
+		_, err = manager.Submit(job)
+		Expect(err).To(MatchError(jobs.ErrStopping))
+	})
+})
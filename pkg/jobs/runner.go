@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of running a job
+type Result struct {
+	Job      Job
+	Duration time.Duration
+	Err      error
+}
+
+// Run executes jobs, at most parallel at once, and returns their results in
+// the order of jobs. A failed job does not stop the others.
+func Run(jobs []Job, parallel int, execute func(Job) error) []Result {
+	if parallel < 1 {
+		parallel = 1
+	}
+	results := make([]Result, len(jobs))
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, job Job) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			started := time.Now()
+			err := execute(job)
+			results[i] = Result{Job: job, Duration: time.Since(started), Err: err}
+		}(i, job)
+	}
+	wg.Wait()
+	return results
+}
+
+// WriteSummary writes a line per result and a total, and returns the number
+// of failed jobs
+func WriteSummary(w io.Writer, results []Result) int {
+	failed := 0
+	for _, result := range results {
+		status := "ok"
+		if result.Err != nil {
+			status = fmt.Sprintf("failed: %s", result.Err)
+			failed++
+		}
+		fmt.Fprintf(w, "%s: blocks %d-%d %v in %s, %s\n", result.Job.Name, result.Job.StartingBlockNumber, result.Job.EndingBlockNumber, result.Job.Types, result.Duration.Round(time.Millisecond), status)
+	}
+	fmt.Fprintf(w, "%d of %d jobs succeeded\n", len(results)-failed, len(results))
+	return failed
+}
@@ -0,0 +1,94 @@
+package jobs_test
+
+import (
+	"bytes"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/jobs"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+)
+
+var _ = Describe("Job runner", func() {
+	var specJobs []jobs.Job
+
+	BeforeEach(func() {
+		specJobs = []jobs.Job{{Name: "first"}, {Name: "second"}, {Name: "third"}}
+	})
+
+	It("runs every job and returns results in job order", func() {
+		var mutex sync.Mutex
+		var executed []string
+
+		results := jobs.Run(specJobs, 2, func(job jobs.Job) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			executed = append(executed, job.Name)
+			return nil
+		})
+
+		Expect(executed).To(ConsistOf("first", "second", "third"))
+		Expect(results).To(HaveLen(3))
+		for i, result := range results {
+			Expect(result.Job).To(Equal(specJobs[i]))
+			Expect(result.Err).NotTo(HaveOccurred())
+		}
+	})
+
+	It("runs at most parallel jobs at once", func() {
+		var mutex sync.Mutex
+		running, maxRunning := 0, 0
+		release := make(chan struct{})
+		go func() {
+			for range specJobs {
+				release <- struct{}{}
+			}
+		}()
+
+		jobs.Run(specJobs, 2, func(job jobs.Job) error {
+			mutex.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mutex.Unlock()
+			<-release
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return nil
+		})
+
+		Expect(maxRunning).To(BeNumerically("<=", 2))
+	})
+
+	It("keeps running after a job fails", func() {
+		results := jobs.Run(specJobs, 1, func(job jobs.Job) error {
+			if job.Name == "first" {
+				return test_helpers.FakeError
+			}
+			return nil
+		})
+
+		Expect(results[0].Err).To(MatchError(test_helpers.FakeError))
+		Expect(results[1].Err).NotTo(HaveOccurred())
+		Expect(results[2].Err).NotTo(HaveOccurred())
+	})
+
+	It("summarizes results and counts failures", func() {
+		results := []jobs.Result{
+			{Job: jobs.Job{Name: "first", StartingBlockNumber: 1, EndingBlockNumber: 2, Types: []string{"header"}}},
+			{Job: jobs.Job{Name: "second", StartingBlockNumber: 3, EndingBlockNumber: 4, Types: []string{"state"}}, Err: test_helpers.FakeError},
+		}
+		var summary bytes.Buffer
+
+		failed := jobs.WriteSummary(&summary, results)
+
+		Expect(failed).To(Equal(1))
+		Expect(summary.String()).To(Equal("first: blocks 1-2 [header] in 0s, ok\n" +
+			"second: blocks 3-4 [state] in 0s, failed: " + test_helpers.FakeError.Error() + "\n" +
+			"1 of 2 jobs succeeded\n"))
+	})
+})
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/spf13/viper"
+)
+
+// ExtractTypes are the types of data a job can extract
+var ExtractTypes = []string{"header", "txs", "receipts", "state"}
+
+// Spec is a file of extraction jobs, run by the run command
+type Spec struct {
+	// Parallel is the number of jobs run at once
+	Parallel int `mapstructure:"parallel"`
+	// Workers is the number of goroutines reading each state trie concurrently,
+	// by default one per CPU as with the --workers flag
+	Workers int   `mapstructure:"workers"`
+	Jobs    []Job `mapstructure:"jobs"`
+}
+
+// Job extracts some types of data for a range of blocks from one chaindata
+// directory into one IPFS repo. The chain is whichever the chaindata directory
+// holds, and the IPFS repo is the only sink, so a job has no keys for either.
+type Job struct {
+	Name                string   `mapstructure:"name" json:"name"`
+	LevelDbPath         string   `mapstructure:"levelDbPath" json:"levelDbPath"`
+	IpfsPath            string   `mapstructure:"ipfsPath" json:"ipfsPath"`
+	Types               []string `mapstructure:"types" json:"types"`
+	StartingBlockNumber int64    `mapstructure:"startingBlockNumber" json:"startingBlockNumber"`
+	EndingBlockNumber   int64    `mapstructure:"endingBlockNumber" json:"endingBlockNumber"`
+}
+
+// LoadSpec reads a TOML, YAML or JSON job spec, rejecting keys that are not
+// fields of a Spec or Job. Jobs without a chaindata directory or IPFS repo use
+// those of defaults, and jobs without types extract every type.
+func LoadSpec(path string, defaults Job) (Spec, error) {
+	v := viper.New()
+	v.SetConfigFile(path)
+	err := v.ReadInConfig()
+	if err != nil {
+		return Spec{}, err
+	}
+	var spec Spec
+	err = v.UnmarshalExact(&spec)
+	if err != nil {
+		return Spec{}, fmt.Errorf("Error decoding %s: %s", path, err)
+	}
+	if spec.Parallel < 1 {
+		spec.Parallel = 1
+	}
+	if spec.Workers < 1 {
+		spec.Workers = runtime.NumCPU()
+	}
+	if len(spec.Jobs) == 0 {
+		return Spec{}, fmt.Errorf("No jobs in %s", path)
+	}
+	for i := range spec.Jobs {
+		spec.Jobs[i] = spec.Jobs[i].withDefaults(defaults, fmt.Sprintf("job %d", i+1))
+		err = spec.Jobs[i].validate()
+		if err != nil {
+			return Spec{}, err
+		}
+	}
+	return spec, nil
+}
+
+// withDefaults returns the job with the chaindata directory and IPFS repo of
+// defaults if it has none, every type if it has none, and name if unnamed
+func (job Job) withDefaults(defaults Job, name string) Job {
+	if job.Name == "" {
+		job.Name = name
+	}
+	if job.LevelDbPath == "" {
+		job.LevelDbPath = defaults.LevelDbPath
+	}
+	if job.IpfsPath == "" {
+		job.IpfsPath = defaults.IpfsPath
+	}
+	if len(job.Types) == 0 {
+		job.Types = ExtractTypes
+	}
+	return job
+}
+
+func (job Job) validate() error {
+	if job.LevelDbPath == "" {
+		return fmt.Errorf("%s: no levelDbPath", job.Name)
+	}
+	if job.IpfsPath == "" {
+		return fmt.Errorf("%s: no ipfsPath", job.Name)
+	}
+	if job.EndingBlockNumber < job.StartingBlockNumber {
+		return fmt.Errorf("%s: ending block number must be greater than or equal to starting block number", job.Name)
+	}
+	return ValidateTypes(job.Types)
+}
+
+// ValidateTypes returns an error naming the first type that is not one of
+// ExtractTypes
+func ValidateTypes(types []string) error {
+	for _, extractType := range types {
+		known := false
+		for _, knownType := range ExtractTypes {
+			if extractType == knownType {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("Unknown type to extract: %s", extractType)
+		}
+	}
+	return nil
+}
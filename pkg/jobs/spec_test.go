@@ -0,0 +1,133 @@
+package jobs_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/jobs"
+)
+
+var _ = Describe("Job spec", func() {
+	var (
+		dir      string
+		defaults jobs.Job
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "jobs")
+		Expect(err).NotTo(HaveOccurred())
+		defaults = jobs.Job{LevelDbPath: "/default/chaindata", IpfsPath: "/default/ipfs"}
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	writeSpec := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		Expect(ioutil.WriteFile(path, []byte(contents), 0644)).To(Succeed())
+		return path
+	}
+
+	It("loads jobs from TOML", func() {
+		path := writeSpec("jobs.toml", `
+parallel = 2
+workers = 4
+
+[[jobs]]
+name = "headers"
+levelDbPath = "/chaindata"
+ipfsPath = "/ipfs"
+types = ["header", "txs"]
+startingBlockNumber = 10
+endingBlockNumber = 20
+`)
+
+		spec, err := jobs.LoadSpec(path, defaults)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec).To(Equal(jobs.Spec{
+			Parallel: 2,
+			Workers:  4,
+			Jobs: []jobs.Job{{
+				Name:                "headers",
+				LevelDbPath:         "/chaindata",
+				IpfsPath:            "/ipfs",
+				Types:               []string{"header", "txs"},
+				StartingBlockNumber: 10,
+				EndingBlockNumber:   20,
+			}},
+		}))
+	})
+
+	It("loads jobs from YAML and fills in defaults", func() {
+		path := writeSpec("jobs.yaml", `
+jobs:
+  - startingBlockNumber: 1
+    endingBlockNumber: 2
+`)
+
+		spec, err := jobs.LoadSpec(path, defaults)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(spec).To(Equal(jobs.Spec{
+			Parallel: 1,
+			Workers:  runtime.NumCPU(),
+			Jobs: []jobs.Job{{
+				Name:                "job 1",
+				LevelDbPath:         "/default/chaindata",
+				IpfsPath:            "/default/ipfs",
+				Types:               jobs.ExtractTypes,
+				StartingBlockNumber: 1,
+				EndingBlockNumber:   2,
+			}},
+		}))
+	})
+
+	It("returns error if there are no jobs", func() {
+		path := writeSpec("jobs.toml", "parallel = 2\n")
+
+		_, err := jobs.LoadSpec(path, defaults)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns error for unknown keys", func() {
+		path := writeSpec("jobs.toml", "[[jobs]]\nchain = \"ropsten\"\nendingBlockNumber = 1\n")
+
+		_, err := jobs.LoadSpec(path, defaults)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("chain"))
+	})
+
+	It("returns error for an unknown type", func() {
+		path := writeSpec("jobs.toml", "[[jobs]]\ntypes = [\"logs\"]\n")
+
+		_, err := jobs.LoadSpec(path, defaults)
+
+		Expect(err).To(MatchError("Unknown type to extract: logs"))
+	})
+
+	It("returns error for an invalid range", func() {
+		path := writeSpec("jobs.toml", "[[jobs]]\nstartingBlockNumber = 2\nendingBlockNumber = 1\n")
+
+		_, err := jobs.LoadSpec(path, defaults)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns error if a job has no chaindata directory", func() {
+		path := writeSpec("jobs.toml", "[[jobs]]\nendingBlockNumber = 1\n")
+
+		_, err := jobs.LoadSpec(path, jobs.Job{IpfsPath: "/ipfs"})
+
+		Expect(err).To(MatchError("job 1: no levelDbPath"))
+	})
+})
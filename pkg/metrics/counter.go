@@ -0,0 +1,74 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Counter is a value that only increases
+type Counter struct {
+	sync.Mutex
+	name  string
+	help  string
+	value float64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+func (c *Counter) Add(delta float64) {
+	c.Lock()
+	defer c.Unlock()
+	c.value += delta
+}
+
+func (c *Counter) Value() float64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.value
+}
+
+func (c *Counter) write(w io.Writer) {
+	writeHeader(w, c.name, c.help, "counter")
+	fmt.Fprintf(w, "%s %s\n", c.name, formatFloat(c.Value()))
+}
+
+// CounterVec is a set of counters partitioned by the value of one label
+type CounterVec struct {
+	sync.Mutex
+	name     string
+	help     string
+	label    string
+	counters map[string]*Counter
+}
+
+// With returns the counter for labelValue, creating it at zero if needed
+func (v *CounterVec) With(labelValue string) *Counter {
+	v.Lock()
+	defer v.Unlock()
+	counter, ok := v.counters[labelValue]
+	if !ok {
+		counter = &Counter{name: v.name, help: v.help}
+		v.counters[labelValue] = counter
+	}
+	return counter
+}
+
+func (v *CounterVec) write(w io.Writer) {
+	v.Lock()
+	labelValues := make([]string, 0, len(v.counters))
+	for labelValue := range v.counters {
+		labelValues = append(labelValues, labelValue)
+	}
+	v.Unlock()
+	sort.Strings(labelValues)
+
+	writeHeader(w, v.name, v.help, "counter")
+	for _, labelValue := range labelValues {
+		fmt.Fprintf(w, "%s{%s=%s} %s\n", v.name, v.label, strconv.Quote(labelValue), formatFloat(v.With(labelValue).Value()))
+	}
+}
@@ -0,0 +1,23 @@
+package metrics
+
+// Error types counted by Errors
+const (
+	DatabaseReadError = "database_read"
+	IpfsAddError      = "ipfs_add"
+	MissingDataError  = "missing_data"
+)
+
+// LatencyBuckets are the upper bounds, in seconds, of the latency histograms
+var LatencyBuckets = []float64{.0001, .0005, .001, .005, .01, .05, .1, .5, 1, 5, 10}
+
+var DefaultRegistry = NewRegistry()
+
+var (
+	BlocksProcessed     = DefaultRegistry.NewCounter("eth_block_extractor_blocks_processed_total", "Blocks whose data was published.")
+	NodesPublished      = DefaultRegistry.NewCounterVec("eth_block_extractor_nodes_published_total", "IPLD nodes added to IPFS, by codec.", "codec")
+	BytesWritten        = DefaultRegistry.NewCounterVec("eth_block_extractor_bytes_written_total", "Bytes of IPLD node data added to IPFS, by codec.", "codec")
+	DedupHits           = DefaultRegistry.NewCounterVec("eth_block_extractor_dedup_hits_total", "IPLD nodes already stored in IPFS when added, by codec.", "codec")
+	Errors              = DefaultRegistry.NewCounterVec("eth_block_extractor_errors_total", "Errors reading block data or adding it to IPFS, by type.", "type")
+	DatabaseReadSeconds = DefaultRegistry.NewHistogram("eth_block_extractor_database_read_seconds", "Latency of reading a block's data from the database.", LatencyBuckets)
+	IpfsAddSeconds      = DefaultRegistry.NewHistogram("eth_block_extractor_ipfs_add_seconds", "Latency of adding an IPLD node to IPFS.", LatencyBuckets)
+)
@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// collector writes its metrics in the Prometheus text exposition format
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry holds metrics and serves them to Prometheus
+type Registry struct {
+	sync.Mutex
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	counter := &Counter{name: name, help: help}
+	r.register(counter)
+	return counter
+}
+
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	vec := &CounterVec{name: name, help: help, label: label, counters: make(map[string]*Counter)}
+	r.register(vec)
+	return vec
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	histogram := &Histogram{name: name, help: help, buckets: buckets, counts: make([]uint64, len(buckets))}
+	r.register(histogram)
+	return histogram
+}
+
+func (r *Registry) register(c collector) {
+	r.Lock()
+	defer r.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// ServeHTTP writes every metric in the registry, in registration order
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var buf bytes.Buffer
+	r.Lock()
+	for _, c := range r.collectors {
+		c.write(&buf)
+	}
+	r.Unlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Write(buf.Bytes())
+}
+
+// Serve exposes the default registry at /metrics on addr. It returns once
+// addr is listened on, serving in the background.
+func Serve(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", DefaultRegistry)
+	go http.Serve(listener, mux)
+	return nil
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
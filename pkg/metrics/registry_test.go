@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
+)
+
+var _ = Describe("Metrics registry", func() {
+	var registry *metrics.Registry
+
+	BeforeEach(func() {
+		registry = metrics.NewRegistry()
+	})
+
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(recorder.Header().Get("Content-Type")).To(Equal("text/plain; version=0.0.4"))
+		return recorder.Body.String()
+	}
+
+	It("writes counters", func() {
+		counter := registry.NewCounter("blocks_total", "Blocks.")
+		counter.Inc()
+		counter.Add(2)
+
+		Expect(scrape()).To(Equal("# HELP blocks_total Blocks.\n# TYPE blocks_total counter\nblocks_total 3\n"))
+	})
+
+	It("writes a counter per label value in sorted order", func() {
+		vec := registry.NewCounterVec("nodes_total", "Nodes.", "codec")
+		vec.With("eth-state-trie").Inc()
+		vec.With("eth-block").Add(2)
+		vec.With("eth-state-trie").Inc()
+
+		Expect(scrape()).To(Equal(`# HELP nodes_total Nodes.
+# TYPE nodes_total counter
+nodes_total{codec="eth-block"} 2
+nodes_total{codec="eth-state-trie"} 2
+`))
+	})
+
+	It("writes histograms with cumulative buckets", func() {
+		histogram := registry.NewHistogram("read_seconds", "Reads.", []float64{.1, 1})
+		histogram.Observe(.05)
+		histogram.Observe(.5)
+		histogram.Observe(2)
+
+		Expect(scrape()).To(Equal(`# HELP read_seconds Reads.
+# TYPE read_seconds histogram
+read_seconds_bucket{le="0.1"} 1
+read_seconds_bucket{le="1"} 2
+read_seconds_bucket{le="+Inf"} 3
+read_seconds_sum 2.55
+read_seconds_count 3
+`))
+		Expect(histogram.Count()).To(Equal(uint64(3)))
+	})
+
+	It("writes metrics in registration order", func() {
+		registry.NewCounter("b_total", "B.")
+		registry.NewCounter("a_total", "A.")
+
+		Expect(scrape()).To(Equal("# HELP b_total B.\n# TYPE b_total counter\nb_total 0\n# HELP a_total A.\n# TYPE a_total counter\na_total 0\n"))
+	})
+})
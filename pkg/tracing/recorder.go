@@ -0,0 +1,132 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Recorder receives finished spans
+type Recorder interface {
+	Record(span Span)
+	// Close writes any spans not yet written
+	Close() error
+}
+
+// FileRecorder writes each span as a line of JSON
+type FileRecorder struct {
+	sync.Mutex
+	encoder *json.Encoder
+	err     error
+}
+
+func NewFileRecorder(writer io.Writer) *FileRecorder {
+	return &FileRecorder{encoder: json.NewEncoder(writer)}
+}
+
+func (r *FileRecorder) Record(span Span) {
+	r.Lock()
+	defer r.Unlock()
+	if r.err == nil {
+		r.err = r.encoder.Encode(span)
+	}
+}
+
+// Close returns the first error writing a span, after which no more are written
+func (r *FileRecorder) Close() error {
+	r.Lock()
+	defer r.Unlock()
+	return r.err
+}
+
+// CollectorRecorder posts spans in batches to a Zipkin v2 spans endpoint,
+// e.g. http://localhost:9411/api/v2/spans. Spans are posted in the background,
+// and dropped if they are recorded faster than the collector accepts them.
+type CollectorRecorder struct {
+	url       string
+	client    *http.Client
+	batchSize int
+	spans     chan Span
+	done      chan struct{}
+
+	sync.Mutex
+	dropped int
+	err     error
+}
+
+func NewCollectorRecorder(url string, client *http.Client, batchSize int) *CollectorRecorder {
+	r := &CollectorRecorder{
+		url:       url,
+		client:    client,
+		batchSize: batchSize,
+		spans:     make(chan Span, 100*batchSize),
+		done:      make(chan struct{}),
+	}
+	go r.post()
+	return r
+}
+
+func (r *CollectorRecorder) Record(span Span) {
+	select {
+	case r.spans <- span:
+	default:
+		r.Lock()
+		r.dropped++
+		r.Unlock()
+	}
+}
+
+// Close posts the remaining spans, and returns the first error posting spans
+// or an error if any were dropped
+func (r *CollectorRecorder) Close() error {
+	close(r.spans)
+	<-r.done
+	r.Lock()
+	defer r.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	if r.dropped > 0 {
+		return fmt.Errorf("dropped %d spans recorded faster than %s accepted them", r.dropped, r.url)
+	}
+	return nil
+}
+
+func (r *CollectorRecorder) post() {
+	defer close(r.done)
+	batch := make([]Span, 0, r.batchSize)
+	for span := range r.spans {
+		batch = append(batch, span)
+		if len(batch) == r.batchSize || len(r.spans) == 0 {
+			r.postBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		r.postBatch(batch)
+	}
+}
+
+func (r *CollectorRecorder) postBatch(batch []Span) {
+	body, err := json.Marshal(batch)
+	if err == nil {
+		var response *http.Response
+		response, err = r.client.Post(r.url, "application/json", bytes.NewReader(body))
+		if err == nil {
+			response.Body.Close()
+			if response.StatusCode/100 != 2 {
+				err = fmt.Errorf("collector %s responded %s", r.url, response.Status)
+			}
+		}
+	}
+	if err != nil {
+		r.Lock()
+		if r.err == nil {
+			r.err = err
+		}
+		r.Unlock()
+	}
+}
@@ -0,0 +1,69 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/tracing"
+)
+
+var _ = Describe("Collector recorder", func() {
+	var (
+		mutex    sync.Mutex
+		received []tracing.Span
+		status   int
+		server   *httptest.Server
+	)
+
+	BeforeEach(func() {
+		received = nil
+		status = http.StatusAccepted
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer GinkgoRecover()
+			Expect(req.Method).To(Equal("POST"))
+			Expect(req.Header.Get("Content-Type")).To(Equal("application/json"))
+			var batch []tracing.Span
+			Expect(json.NewDecoder(req.Body).Decode(&batch)).To(Succeed())
+			Expect(len(batch)).To(BeNumerically("<=", 2))
+			mutex.Lock()
+			received = append(received, batch...)
+			mutex.Unlock()
+			w.WriteHeader(status)
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("posts every recorded span in batches by close", func() {
+		recorder := tracing.NewCollectorRecorder(server.URL, server.Client(), 2)
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			recorder.Record(tracing.Span{Name: name})
+		}
+
+		err := recorder.Close()
+
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, span := range received {
+			names = append(names, span.Name)
+		}
+		Expect(names).To(Equal([]string{"a", "b", "c", "d", "e"}))
+	})
+
+	It("returns an error on close if the collector rejected spans", func() {
+		status = http.StatusBadRequest
+		recorder := tracing.NewCollectorRecorder(server.URL, server.Client(), 2)
+		recorder.Record(tracing.Span{Name: "a"})
+
+		err := recorder.Close()
+
+		Expect(err).To(MatchError(ContainSubstring("400 Bad Request")))
+	})
+})
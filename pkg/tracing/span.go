@@ -0,0 +1,167 @@
+package tracing
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+)
+
+// Span is a finished span in the Zipkin v2 JSON format, which Zipkin and
+// Jaeger collectors accept. Times are in microseconds.
+type Span struct {
+	TraceID       string            `json:"traceId"`
+	ID            string            `json:"id"`
+	ParentID      string            `json:"parentId,omitempty"`
+	Name          string            `json:"name"`
+	Timestamp     int64             `json:"timestamp"`
+	Duration      int64             `json:"duration"`
+	LocalEndpoint Endpoint          `json:"localEndpoint"`
+	Tags          map[string]string `json:"tags,omitempty"`
+	Annotations   []Annotation      `json:"annotations,omitempty"`
+}
+
+type Endpoint struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type Annotation struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+type spanContext struct {
+	traceID uint64
+	spanID  uint64
+}
+
+func (spanContext) ForeachBaggageItem(handler func(k, v string) bool) {}
+
+type span struct {
+	sync.Mutex
+	tracer        *Tracer
+	context       spanContext
+	parentID      uint64
+	operationName string
+	start         time.Time
+	tags          map[string]interface{}
+	logs          []opentracing.LogRecord
+}
+
+func (s *span) Finish() {
+	s.FinishWithOptions(opentracing.FinishOptions{})
+}
+
+func (s *span) FinishWithOptions(opts opentracing.FinishOptions) {
+	finish := opts.FinishTime
+	if finish.IsZero() {
+		finish = time.Now()
+	}
+	s.Lock()
+	s.logs = append(s.logs, opts.LogRecords...)
+	finished := s.finished(finish)
+	s.Unlock()
+	s.tracer.record(finished)
+}
+
+// finished converts the span to a Span ending at finish
+func (s *span) finished(finish time.Time) Span {
+	finished := Span{
+		TraceID:       fmt.Sprintf("%016x", s.context.traceID),
+		ID:            fmt.Sprintf("%016x", s.context.spanID),
+		Name:          s.operationName,
+		Timestamp:     microseconds(s.start),
+		Duration:      int64(finish.Sub(s.start) / time.Microsecond),
+		LocalEndpoint: Endpoint{ServiceName: s.tracer.serviceName},
+	}
+	if s.parentID != 0 {
+		finished.ParentID = fmt.Sprintf("%016x", s.parentID)
+	}
+	// collectors drop spans without a duration
+	if finished.Duration < 1 {
+		finished.Duration = 1
+	}
+	if len(s.tags) > 0 {
+		finished.Tags = make(map[string]string, len(s.tags))
+		for key, value := range s.tags {
+			finished.Tags[key] = fmt.Sprint(value)
+		}
+	}
+	for _, record := range s.logs {
+		fields := make([]string, 0, len(record.Fields))
+		for _, field := range record.Fields {
+			fields = append(fields, fmt.Sprintf("%s=%v", field.Key(), field.Value()))
+		}
+		finished.Annotations = append(finished.Annotations, Annotation{
+			Timestamp: microseconds(record.Timestamp),
+			Value:     strings.Join(fields, " "),
+		})
+	}
+	return finished
+}
+
+func (s *span) Context() opentracing.SpanContext {
+	return s.context
+}
+
+func (s *span) SetOperationName(operationName string) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	s.operationName = operationName
+	return s
+}
+
+func (s *span) SetTag(key string, value interface{}) opentracing.Span {
+	s.Lock()
+	defer s.Unlock()
+	s.tags[key] = value
+	return s
+}
+
+func (s *span) LogFields(fields ...log.Field) {
+	s.Lock()
+	defer s.Unlock()
+	s.logs = append(s.logs, opentracing.LogRecord{Timestamp: time.Now(), Fields: fields})
+}
+
+func (s *span) LogKV(alternatingKeyValues ...interface{}) {
+	fields, err := log.InterleavedKVToFields(alternatingKeyValues...)
+	if err != nil {
+		fields = []log.Field{log.Error(err)}
+	}
+	s.LogFields(fields...)
+}
+
+func (s *span) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	return s
+}
+
+func (s *span) BaggageItem(restrictedKey string) string {
+	return ""
+}
+
+func (s *span) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+func (s *span) LogEvent(event string) {
+	s.LogFields(log.String("event", event))
+}
+
+func (s *span) LogEventWithPayload(event string, payload interface{}) {
+	s.LogFields(log.String("event", event), log.Object("payload", payload))
+}
+
+func (s *span) Log(data opentracing.LogData) {
+	record := data.ToLogRecord()
+	s.Lock()
+	defer s.Unlock()
+	s.logs = append(s.logs, record)
+}
+
+func microseconds(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Microsecond)
+}
@@ -0,0 +1,74 @@
+package tracing
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/opentracing/opentracing-go"
+)
+
+// Tracer is an OpenTracing tracer that passes each finished span to its
+// recorders. Spans are only propagated within the process, through contexts,
+// so injecting and extracting span contexts is unsupported.
+type Tracer struct {
+	serviceName string
+	recorders   []Recorder
+}
+
+func NewTracer(serviceName string, recorders ...Recorder) *Tracer {
+	return &Tracer{serviceName: serviceName, recorders: recorders}
+}
+
+func (t *Tracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	var options opentracing.StartSpanOptions
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+	s := &span{
+		tracer:        t,
+		operationName: operationName,
+		start:         options.StartTime,
+		tags:          make(map[string]interface{}, len(options.Tags)),
+	}
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+	for _, ref := range options.References {
+		if parent, ok := ref.ReferencedContext.(spanContext); ok {
+			s.context.traceID = parent.traceID
+			s.parentID = parent.spanID
+			break
+		}
+	}
+	if s.context.traceID == 0 {
+		s.context.traceID = newID()
+	}
+	s.context.spanID = newID()
+	for key, value := range options.Tags {
+		s.tags[key] = value
+	}
+	return s
+}
+
+func (t *Tracer) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	return opentracing.ErrUnsupportedFormat
+}
+
+func (t *Tracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	return nil, opentracing.ErrUnsupportedFormat
+}
+
+func (t *Tracer) record(s Span) {
+	for _, recorder := range t.recorders {
+		recorder.Record(s)
+	}
+}
+
+// newID returns a random non-zero span or trace ID
+func newID() uint64 {
+	for {
+		if id := rand.Uint64(); id != 0 {
+			return id
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/log"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/tracing"
+)
+
+var _ = Describe("Tracer", func() {
+	var (
+		output bytes.Buffer
+		tracer *tracing.Tracer
+	)
+
+	BeforeEach(func() {
+		output.Reset()
+		tracer = tracing.NewTracer("test", tracing.NewFileRecorder(&output))
+	})
+
+	recorded := func() []tracing.Span {
+		var spans []tracing.Span
+		decoder := json.NewDecoder(&output)
+		for decoder.More() {
+			var span tracing.Span
+			Expect(decoder.Decode(&span)).To(Succeed())
+			spans = append(spans, span)
+		}
+		return spans
+	}
+
+	It("records finished spans with their tags", func() {
+		start := time.Unix(1500000000, 0)
+		span := tracer.StartSpan("block", opentracing.StartTime(start), opentracing.Tag{Key: "block.number", Value: 5})
+		span.SetTag("nodes", 3)
+		span.FinishWithOptions(opentracing.FinishOptions{FinishTime: start.Add(2 * time.Millisecond)})
+
+		spans := recorded()
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].Name).To(Equal("block"))
+		Expect(spans[0].ParentID).To(BeEmpty())
+		Expect(spans[0].TraceID).To(HaveLen(16))
+		Expect(spans[0].Timestamp).To(Equal(int64(1500000000000000)))
+		Expect(spans[0].Duration).To(Equal(int64(2000)))
+		Expect(spans[0].LocalEndpoint.ServiceName).To(Equal("test"))
+		Expect(spans[0].Tags).To(Equal(map[string]string{"block.number": "5", "nodes": "3"}))
+	})
+
+	It("records spans started from a context as children of its span", func() {
+		parent := tracer.StartSpan("block")
+		ctx := opentracing.ContextWithSpan(context.Background(), parent)
+		child, _ := opentracing.StartSpanFromContextWithTracer(ctx, tracer, "ipfs.add")
+		child.Finish()
+		parent.Finish()
+
+		spans := recorded()
+		Expect(spans).To(HaveLen(2))
+		Expect(spans[0].Name).To(Equal("ipfs.add"))
+		Expect(spans[0].TraceID).To(Equal(spans[1].TraceID))
+		Expect(spans[0].ParentID).To(Equal(spans[1].ID))
+		Expect(spans[0].ID).NotTo(Equal(spans[1].ID))
+	})
+
+	It("records logged fields as annotations", func() {
+		span := tracer.StartSpan("block")
+		span.LogFields(log.Error(errors.New("failed")), log.Int("attempt", 2))
+		span.Finish()
+
+		spans := recorded()
+		Expect(spans[0].Annotations).To(HaveLen(1))
+		Expect(spans[0].Annotations[0].Value).To(Equal("error=failed attempt=2"))
+	})
+
+	It("does not propagate span contexts across processes", func() {
+		span := tracer.StartSpan("block")
+
+		err := tracer.Inject(span.Context(), opentracing.HTTPHeaders, opentracing.HTTPHeadersCarrier{})
+
+		Expect(err).To(MatchError(opentracing.ErrUnsupportedFormat))
+	})
+})
@@ -0,0 +1,46 @@
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
+)
+
+// AccountSelection restricts state extraction to the given accounts: only the
+// state trie nodes on the path to each account are published, along with the
+// account's full storage trie and its contract code.
+type AccountSelection struct {
+	addresses     []common.Address
+	codePublisher ipfs.CodePublisher
+}
+
+func NewAccountSelection(addresses []common.Address, codePublisher ipfs.CodePublisher) *AccountSelection {
+	return &AccountSelection{
+		addresses:     addresses,
+		codePublisher: codePublisher,
+	}
+}
+
+func (s *AccountSelection) getTrieNodes(ctx context.Context, database db.Database, blockNumber int64, root common.Hash, summary *blockSummary) (stateTrieNodes [][]byte, storageTrieNodes []level.StorageTrieNode, err error) {
+	start := time.Now()
+	stateTrieNodes, storageTrieNodes, codes, err := database.GetAccountTrieNodes(ctx, root, s.addresses)
+	metrics.DatabaseReadSeconds.ObserveSince(start)
+	if err != nil {
+		metrics.Errors.With(metrics.DatabaseReadError).Inc()
+		return nil, nil, fmt.Errorf("Error fetching selected accounts for block %d: %s\n", blockNumber, err)
+	}
+	for _, code := range codes {
+		output, err := s.codePublisher.WriteCode(ctx, blockNumber, code)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Error writing contract code to ipfs: %s\n", err)
+		}
+		summary.add(output)
+	}
+	return stateTrieNodes, storageTrieNodes, nil
+}
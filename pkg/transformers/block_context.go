@@ -0,0 +1,35 @@
+package transformers
+
+import (
+	"context"
+	"time"
+)
+
+// blockContext returns the context that blocks are read and published with.
+// Transformers check ctx before starting each block, but the block context is
+// not cancelled with it, so that a block in flight when ctx is cancelled is
+// still published whole. It does keep ctx's deadline and values.
+func blockContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := detachedContext{Context: ctx}
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(detached, deadline)
+	}
+	return context.WithCancel(detached)
+}
+
+// detachedContext carries a context's values without its cancellation
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
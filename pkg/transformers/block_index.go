@@ -0,0 +1,58 @@
+package transformers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// BlockIndexEntry locates the published header, uncles, transactions and
+// receipts of a block, which may be on a side chain rather than the canonical
+// one. Uncles are in the order of the block's body, receipts are in the order
+// of the transactions, and receipts are only indexed when published.
+type BlockIndexEntry struct {
+	BlockNumber     int64       `json:"blockNumber"`
+	Hash            common.Hash `json:"hash"`
+	Canonical       bool        `json:"canonical"`
+	HeaderCid       string      `json:"headerCid"`
+	UncleCids       []string    `json:"uncleCids,omitempty"`
+	TransactionCids []string    `json:"transactionCids"`
+	ReceiptCids     []string    `json:"receiptCids,omitempty"`
+}
+
+// BlockIndex writes a BlockIndexEntry per published block to writer as one
+// JSON object per line
+type BlockIndex struct {
+	writer io.Writer
+}
+
+func NewBlockIndex(writer io.Writer) *BlockIndex {
+	return &BlockIndex{writer: writer}
+}
+
+func (i *BlockIndex) record(blockNumber int64, hash common.Hash, canonical bool, headerOutput ipfs.Result, uncleOutputs, transactionOutputs, receiptOutputs []ipfs.Result) error {
+	entry := BlockIndexEntry{
+		BlockNumber:     blockNumber,
+		Hash:            hash,
+		Canonical:       canonical,
+		HeaderCid:       headerOutput.String(),
+		TransactionCids: make([]string, 0, len(transactionOutputs)),
+	}
+	for _, output := range uncleOutputs {
+		entry.UncleCids = append(entry.UncleCids, output.String())
+	}
+	for _, output := range transactionOutputs {
+		entry.TransactionCids = append(entry.TransactionCids, output.String())
+	}
+	for _, output := range receiptOutputs {
+		entry.ReceiptCids = append(entry.ReceiptCids, output.String())
+	}
+	err := json.NewEncoder(i.writer).Encode(entry)
+	if err != nil {
+		return fmt.Errorf("Error writing block index for block %d: %s", blockNumber, err)
+	}
+	return nil
+}
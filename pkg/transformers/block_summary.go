@@ -0,0 +1,78 @@
+package transformers
+
+import (
+	"context"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
+)
+
+// blockSummary counts the IPLDs published for a block, which are logged one by
+// one only at debug level, so that a block is logged as a single record. It
+// also holds the block's span, which the reads and adds for the block are
+// traced under.
+type blockSummary struct {
+	blockNumber int64
+	start       time.Time
+	span        opentracing.Span
+	nodes       int
+	bytes       int
+}
+
+// newBlockSummary starts the block's span, returning a context carrying it
+// for the block's work
+func newBlockSummary(ctx context.Context, blockNumber int64) (*blockSummary, context.Context) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "block", opentracing.Tag{Key: "block.number", Value: blockNumber})
+	return &blockSummary{blockNumber: blockNumber, start: time.Now(), span: span}, ctx
+}
+
+func (s *blockSummary) add(results ...ipfs.Result) {
+	debug := log.IsLevelEnabled(log.DebugLevel)
+	for _, result := range results {
+		s.nodes++
+		s.bytes += result.Size
+		if debug {
+			log.WithFields(log.Fields{
+				"block": result.BlockNumber,
+				"cid":   result.Cid.String(),
+				"codec": cid.CodecToStr[result.Codec],
+				"bytes": result.Size,
+			}).Debug("Created IPLD")
+		}
+	}
+}
+
+// done logs the summary, counts the block as processed and finishes its span
+func (s *blockSummary) done() {
+	metrics.BlocksProcessed.Inc()
+	log.WithFields(log.Fields{
+		"block":    s.blockNumber,
+		"nodes":    s.nodes,
+		"bytes":    s.bytes,
+		"duration": time.Since(s.start).String(),
+	}).Info("Published block")
+	s.span.SetTag("nodes", s.nodes)
+	s.span.SetTag("bytes", s.bytes)
+	s.span.Finish()
+}
+
+// skipped finishes the span of a block skipped for missing data
+func (s *blockSummary) skipped() {
+	s.span.SetTag("skipped", true)
+	s.span.Finish()
+}
+
+// fail records err on the block's span and finishes it, returning err
+func (s *blockSummary) fail(err error) error {
+	ext.Error.Set(s.span, true)
+	s.span.LogFields(otlog.Error(err))
+	s.span.Finish()
+	return err
+}
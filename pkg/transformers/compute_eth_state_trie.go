@@ -1,11 +1,15 @@
 package transformers
 
 import (
+	"context"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
-	"log"
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
+	"time"
 )
 
 const (
@@ -15,81 +19,202 @@ const (
 
 type ComputeEthStateTrieTransformer struct {
 	database             db.Database
-	stateTriePublisher   ipfs.Publisher
-	storageTriePublisher ipfs.Publisher
+	manifest             *ShardManifest
+	diffs                *StateDiffExporter
+	policy               MissingDataPolicy
+	selection            *AccountSelection
+	stateTriePublisher   ipfs.StateTrieNodePublisher
+	storageIndex         *StorageIndex
+	storageTriePublisher ipfs.StorageTrieNodePublisher
+	traces               *TraceExporter
+	validation           StateRootValidation
 }
 
-func NewComputeEthStateTrieTransformer(database db.Database, stateTriePublisher, storageTriePublisher ipfs.Publisher) *ComputeEthStateTrieTransformer {
+func NewComputeEthStateTrieTransformer(database db.Database, stateTriePublisher ipfs.StateTrieNodePublisher, storageTriePublisher ipfs.StorageTrieNodePublisher, policy MissingDataPolicy, validation StateRootValidation, diffs *StateDiffExporter, traces *TraceExporter, selection *AccountSelection, manifest *ShardManifest, storageIndex *StorageIndex) *ComputeEthStateTrieTransformer {
 	return &ComputeEthStateTrieTransformer{
 		database:             database,
+		manifest:             manifest,
+		diffs:                diffs,
+		policy:               policy,
+		selection:            selection,
 		stateTriePublisher:   stateTriePublisher,
+		storageIndex:         storageIndex,
 		storageTriePublisher: storageTriePublisher,
+		traces:               traces,
+		validation:           validation,
 	}
 }
 
-func (t ComputeEthStateTrieTransformer) Execute(endingBlockNumber int64) error {
-	root, err := t.getStateRootForBlock(GenesisBlockNumber)
+func (t ComputeEthStateTrieTransformer) Execute(ctx context.Context, endingBlockNumber int64) error {
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
+	genesisSummary, genesisCtx := newBlockSummary(rangeCtx, GenesisBlockNumber)
+	genesisHeader, err := t.getHeader(ctx, genesisCtx, GenesisBlockNumber)
 	if err != nil {
-		return err
+		return genesisSummary.fail(err)
 	}
+	root := genesisHeader.Root
 	// ignore storage trie node return val for genesis block
-	stateTrieNodes, _, err := t.database.GetStateAndStorageTrieNodes(root)
+	stateTrieNodes, _, err := t.getTrieNodes(genesisCtx, GenesisBlockNumber, root, genesisSummary)
 	if err != nil {
-		return fmt.Errorf("Error fetching state trie for genesis block: %s\n", err)
+		return genesisSummary.fail(err)
 	}
-	err = t.writeStateTrieNodesToIpfs(stateTrieNodes)
+	stateTrieOutputs, err := t.writeStateTrieNodesToIpfs(genesisCtx, GenesisBlockNumber, stateTrieNodes)
 	if err != nil {
-		return err
+		return genesisSummary.fail(err)
+	}
+	genesisSummary.add(stateTrieOutputs...)
+	err = t.recordShard(GenesisBlockNumber, root, stateTrieOutputs, nil)
+	if err != nil {
+		return genesisSummary.fail(err)
+	}
+	err = t.exportStateDiff(genesisCtx, GenesisBlockNumber, genesisHeader.Hash(), db.EmptyTrieRoot, root, genesisSummary)
+	if err != nil {
+		return genesisSummary.fail(err)
 	}
+	genesisSummary.done()
+	// each block is applied to the state computed for its parent, which only
+	// differs from the parent's header root after a tolerated mismatch
+	parentRoot := root
 	for n := FirstBlockToCompute; n <= endingBlockNumber; n++ {
-		currentBlock := t.database.GetBlockByBlockNumber(n)
-		parentBlock := t.database.GetBlockByBlockNumber(n - 1)
-		stateRoot, err := t.database.ComputeBlockStateTrie(currentBlock, parentBlock)
-		if err != nil {
+		if err := ctx.Err(); err != nil {
 			return err
 		}
-		nextStateTrieNodes, nextStorageTrieNodes, err := t.database.GetStateAndStorageTrieNodes(stateRoot)
+		summary, blockCtx := newBlockSummary(rangeCtx, n)
+		block, err := t.getBlock(ctx, blockCtx, n)
 		if err != nil {
-			return err
+			return summary.fail(err)
 		}
-		err = t.writeStateTrieNodesToIpfs(nextStateTrieNodes)
+		stateRoot, traces, err := t.computeStateTrie(blockCtx, block, parentRoot)
 		if err != nil {
-			return err
+			return summary.fail(err)
 		}
-		err = t.writeStorageTrieNodesToIpfs(nextStorageTrieNodes)
+		err = t.validation.validate(blockCtx, t.database, block, parentRoot, stateRoot)
 		if err != nil {
-			return err
+			return summary.fail(err)
+		}
+		nextStateTrieNodes, nextStorageTrieNodes, err := t.getTrieNodes(blockCtx, n, stateRoot, summary)
+		if err != nil {
+			return summary.fail(err)
 		}
+		nextStateTrieOutputs, err := t.writeStateTrieNodesToIpfs(blockCtx, n, nextStateTrieNodes)
+		if err != nil {
+			return summary.fail(err)
+		}
+		summary.add(nextStateTrieOutputs...)
+		nextStorageTrieOutputs, err := t.writeStorageTrieNodesToIpfs(blockCtx, n, nextStorageTrieNodes)
+		if err != nil {
+			return summary.fail(err)
+		}
+		summary.add(nextStorageTrieOutputs...)
+		err = t.recordShard(n, stateRoot, nextStateTrieOutputs, nextStorageTrieOutputs)
+		if err != nil {
+			return summary.fail(err)
+		}
+		err = t.exportStateDiff(blockCtx, n, block.Hash(), parentRoot, stateRoot, summary)
+		if err != nil {
+			return summary.fail(err)
+		}
+		err = t.exportTraces(blockCtx, n, block.Hash(), traces, summary)
+		if err != nil {
+			return summary.fail(err)
+		}
+		parentRoot = stateRoot
+		summary.done()
 	}
 	return nil
 }
 
-func (t ComputeEthStateTrieTransformer) getStateRootForBlock(blockNumber int64) (root common.Hash, err error) {
-	header := t.database.GetBlockHeaderByBlockNumber(blockNumber)
+// blocks cannot be skipped when computing state, since each block's state is
+// built on its parent's. Retries wait on ctx, while the read itself uses
+// blockCtx so an interrupt lets it finish.
+func (t ComputeEthStateTrieTransformer) getBlock(ctx, blockCtx context.Context, blockNumber int64) (block *types.Block, err error) {
+	err = t.policy.fetchRequired(ctx, blockNumber, func() (err error) {
+		block, err = t.database.GetBlockByBlockNumber(blockCtx, blockNumber)
+		return err
+	})
+	return block, err
+}
+
+func (t ComputeEthStateTrieTransformer) getHeader(ctx, blockCtx context.Context, blockNumber int64) (header *types.Header, err error) {
+	err = t.policy.fetchRequired(ctx, blockNumber, func() (err error) {
+		header, err = t.database.GetBlockHeaderByBlockNumber(blockCtx, blockNumber)
+		return err
+	})
+	return header, err
+}
+
+// computeStateTrie only traces the block's transactions if traces are exported
+func (t ComputeEthStateTrieTransformer) computeStateTrie(ctx context.Context, block *types.Block, parentRoot common.Hash) (common.Hash, []level.TransactionTrace, error) {
+	if t.traces == nil {
+		stateRoot, err := t.database.ComputeBlockStateTrie(ctx, block, parentRoot)
+		return stateRoot, nil, err
+	}
+	return t.database.ComputeBlockStateTrieWithTraces(ctx, block, parentRoot)
+}
+
+// getTrieNodes fetches the whole state unless an account selection was given
+func (t ComputeEthStateTrieTransformer) getTrieNodes(ctx context.Context, blockNumber int64, root common.Hash, summary *blockSummary) (stateTrieNodes [][]byte, storageTrieNodes []level.StorageTrieNode, err error) {
+	if t.selection != nil {
+		return t.selection.getTrieNodes(ctx, t.database, blockNumber, root, summary)
+	}
+	start := time.Now()
+	stateTrieNodes, storageTrieNodes, err = t.database.GetStateAndStorageTrieNodes(ctx, root)
+	metrics.DatabaseReadSeconds.ObserveSince(start)
 	if err != nil {
-		return root, err
+		metrics.Errors.With(metrics.DatabaseReadError).Inc()
+		return nil, nil, fmt.Errorf("Error fetching state trie for block %d: %s\n", blockNumber, err)
+	}
+	return stateTrieNodes, storageTrieNodes, nil
+}
+
+func (t ComputeEthStateTrieTransformer) exportStateDiff(ctx context.Context, blockNumber int64, blockHash, parentRoot, root common.Hash, summary *blockSummary) error {
+	if t.diffs == nil {
+		return nil
 	}
-	return header.Root, err
+	return t.diffs.export(ctx, t.database, blockNumber, blockHash, parentRoot, root, summary)
 }
 
-func (t ComputeEthStateTrieTransformer) writeStateTrieNodesToIpfs(stateTrieNodes [][]byte) error {
+func (t ComputeEthStateTrieTransformer) exportTraces(ctx context.Context, blockNumber int64, blockHash common.Hash, traces []level.TransactionTrace, summary *blockSummary) error {
+	if t.traces == nil {
+		return nil
+	}
+	return t.traces.export(ctx, blockNumber, blockHash, traces, summary)
+}
+
+func (t ComputeEthStateTrieTransformer) recordShard(blockNumber int64, root common.Hash, stateTrieOutputs, storageTrieOutputs []ipfs.Result) error {
+	if t.manifest == nil {
+		return nil
+	}
+	return t.manifest.record(blockNumber, root, stateTrieOutputs, storageTrieOutputs)
+}
+
+func (t ComputeEthStateTrieTransformer) writeStateTrieNodesToIpfs(ctx context.Context, blockNumber int64, stateTrieNodes [][]byte) ([]ipfs.Result, error) {
+	outputs := make([]ipfs.Result, 0, len(stateTrieNodes))
 	for _, node := range stateTrieNodes {
-		output, err := t.stateTriePublisher.Write(node)
+		output, err := t.stateTriePublisher.WriteStateTrieNode(ctx, blockNumber, node)
 		if err != nil {
-			return fmt.Errorf("Error writing state trie node to ipfs: %s\n", err)
+			return nil, fmt.Errorf("Error writing state trie node to ipfs: %s\n", err)
 		}
-		log.Println("Created ipld: ", output)
+		outputs = append(outputs, output)
 	}
-	return nil
+	return outputs, nil
 }
 
-func (t ComputeEthStateTrieTransformer) writeStorageTrieNodesToIpfs(storageTrieNodes [][]byte) error {
+func (t ComputeEthStateTrieTransformer) writeStorageTrieNodesToIpfs(ctx context.Context, blockNumber int64, storageTrieNodes []level.StorageTrieNode) ([]ipfs.Result, error) {
+	outputs := make([]ipfs.Result, 0, len(storageTrieNodes))
 	for _, node := range storageTrieNodes {
-		output, err := t.storageTriePublisher.Write(node)
+		output, err := t.storageTriePublisher.WriteStorageTrieNode(ctx, blockNumber, node.Node)
 		if err != nil {
-			return fmt.Errorf("Error writing storage trie node to ipfs: %s\n", err.Error())
+			return nil, fmt.Errorf("Error writing storage trie node to ipfs: %s\n", err.Error())
 		}
-		log.Println("Created ipld: ", output)
+		outputs = append(outputs, output)
 	}
-	return nil
+	if t.storageIndex != nil {
+		err := t.storageIndex.record(blockNumber, storageTrieNodes, outputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
 }
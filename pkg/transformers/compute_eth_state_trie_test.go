@@ -1,13 +1,20 @@
 package transformers_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"io/ioutil"
-	"log"
+	"math/big"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
 
+	eth_db "github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
 	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
 	"github.com/vulcanize/eth-block-extractor/test_helpers"
 	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
@@ -23,9 +30,9 @@ var _ = Describe("Compute eth state trie transformer", func() {
 		It("fetches state trie root for genesis block", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher())
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(0)
+			err := transformer.Execute(context.Background(), 0)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockDB.AssertGetBlockHeaderByBlockNumberCalledWith([]int64{0})
@@ -35,9 +42,9 @@ var _ = Describe("Compute eth state trie transformer", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: test_helpers.FakeHash})
 			storageTriePublisher := ipfs.NewMockPublisher()
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), storageTriePublisher)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), storageTriePublisher, transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(0)
+			err := transformer.Execute(context.Background(), 0)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockDB.AssertGetStateTrieNodesCalledWith(test_helpers.FakeHash)
@@ -47,9 +54,9 @@ var _ = Describe("Compute eth state trie transformer", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
 			mockDB.SetGetStateAndStorageTrieNodesError(test_helpers.FakeError)
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher())
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(0)
+			err := transformer.Execute(context.Background(), 0)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
@@ -61,9 +68,9 @@ var _ = Describe("Compute eth state trie transformer", func() {
 			fakeStateTrieNodes := [][]byte{{6, 7, 8, 9, 0}}
 			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes(fakeStateTrieNodes)
 			stateTriePublisher := ipfs.NewMockPublisher()
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, stateTriePublisher, ipfs.NewMockPublisher())
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, stateTriePublisher, ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(0)
+			err := transformer.Execute(context.Background(), 0)
 
 			Expect(err).NotTo(HaveOccurred())
 			stateTriePublisher.AssertWriteCalledWithBytes(fakeStateTrieNodes)
@@ -76,9 +83,9 @@ var _ = Describe("Compute eth state trie transformer", func() {
 			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes(fakeStateTrieNodes)
 			stateTriePublisher := ipfs.NewMockPublisher()
 			stateTriePublisher.SetError(test_helpers.FakeError)
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, stateTriePublisher, ipfs.NewMockPublisher())
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, stateTriePublisher, ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(0)
+			err := transformer.Execute(context.Background(), 0)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
@@ -86,42 +93,50 @@ var _ = Describe("Compute eth state trie transformer", func() {
 	})
 
 	Describe("computing and publishing the state trie for subsequent blocks", func() {
-		It("fetches the current and parent block", func() {
+		var (
+			fakeBlock   = types.NewBlockWithHeader(&types.Header{Root: test_helpers.FakeHash, Number: big.NewInt(1)})
+			genesisRoot = common.HexToHash("0x456")
+		)
+
+		It("fetches each block after genesis", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
 			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes([][]byte{{6, 7, 8, 9, 0}})
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher())
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(4)
+			err := transformer.Execute(context.Background(), 4)
 
 			Expect(err).NotTo(HaveOccurred())
-			mockDB.AssertGetBlockByBlockNumberCalledwith([]int64{0, 1, 2, 3, 4})
+			mockDB.AssertGetBlockByBlockNumberCalledwith([]int64{1, 2, 3, 4})
 		})
 
-		It("computes state and storage trie nodes for current block", func() {
+		It("computes state for each block on top of its parent's state root", func() {
 			mockDB := db.NewMockDatabase()
-			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
-			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes([][]byte{{6, 7, 8, 9, 0}})
-			fakeBlock := &types.Block{}
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: genesisRoot})
 			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher())
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes([][]byte{{6, 7, 8, 9, 0}})
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(1)
+			err := transformer.Execute(context.Background(), 1)
 
 			Expect(err).NotTo(HaveOccurred())
-			mockDB.AssertComputeBlockStateTrieCalledWith(fakeBlock, fakeBlock)
+			mockDB.AssertComputeBlockStateTrieCalledWith(fakeBlock, genesisRoot)
 		})
 
 		It("publishes state trie nodes to IPFS", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
 			fakeStateTrieNodes := [][]byte{{0, 0, 0, 0, 0}, {1, 1, 1, 1, 1}}
 			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes(fakeStateTrieNodes)
-			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
 			stateTriePublisher := ipfs.NewMockPublisher()
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, stateTriePublisher, ipfs.NewMockPublisher())
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, stateTriePublisher, ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(1)
+			err := transformer.Execute(context.Background(), 1)
 
 			Expect(err).NotTo(HaveOccurred())
 			stateTriePublisher.AssertWriteCalledWithBytes(fakeStateTrieNodes)
@@ -130,13 +145,14 @@ var _ = Describe("Compute eth state trie transformer", func() {
 		It("returns error if publishing state trie nodes fails", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
-			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes([][]byte{{6, 7, 8, 9, 0}})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
 			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes([][]byte{{6, 7, 8, 9, 0}})
 			stateTriePublisher := ipfs.NewMockPublisher()
 			stateTriePublisher.SetError(test_helpers.FakeError)
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, stateTriePublisher, ipfs.NewMockPublisher())
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, stateTriePublisher, ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(1)
+			err := transformer.Execute(context.Background(), 1)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
@@ -145,14 +161,15 @@ var _ = Describe("Compute eth state trie transformer", func() {
 		It("publishes storage trie nodes to IPFS", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
 			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes(test_helpers.FakeTrieNodes)
 			fakeStorageTrieNodes := [][]byte{{2, 2, 2, 2, 2}}
-			mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieBytes(fakeStorageTrieNodes)
-			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieNodes(storageTrieNodes(fakeStorageTrieNodes))
 			storageTriePublisher := ipfs.NewMockPublisher()
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), storageTriePublisher)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), storageTriePublisher, transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
 
-			err := transformer.Execute(1)
+			err := transformer.Execute(context.Background(), 1)
 
 			Expect(err).NotTo(HaveOccurred())
 			storageTriePublisher.AssertWriteCalledWithBytes(fakeStorageTrieNodes)
@@ -161,17 +178,255 @@ var _ = Describe("Compute eth state trie transformer", func() {
 		It("returns error if publishing storage trie nodes fails", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
-			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes(test_helpers.FakeTrieNodes)
-			mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieBytes(test_helpers.FakeTrieNodes)
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
 			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes(test_helpers.FakeTrieNodes)
+			mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieNodes(storageTrieNodes(test_helpers.FakeTrieNodes))
 			storageTriePublisher := ipfs.NewMockPublisher()
 			storageTriePublisher.SetError(test_helpers.FakeError)
-			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), storageTriePublisher)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), storageTriePublisher, transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+		})
+	})
+
+	Describe("validating computed state roots", func() {
+		var (
+			fakeBlock    = types.NewBlockWithHeader(&types.Header{Root: test_helpers.FakeHash, Number: big.NewInt(1)})
+			genesisRoot  = common.HexToHash("0x456")
+			computedRoot = common.HexToHash("0x789")
+			fakeDiffs    = []level.AccountDiff{{AddressHash: common.HexToHash("0xabc")}}
+		)
+
+		It("diffs computed state against expected state on mismatch", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: genesisRoot})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(computedRoot)
+			mockDB.SetDiffStateTriesReturnDiffs(fakeDiffs)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
 
-			err := transformer.Execute(1)
+			Expect(err).To(HaveOccurred())
+			mismatchErr, ok := err.(*transformers.StateRootMismatchError)
+			Expect(ok).To(BeTrue())
+			Expect(mismatchErr.Report).To(Equal(transformers.StateRootMismatchReport{
+				BlockNumber:  1,
+				ExpectedRoot: test_helpers.FakeHash,
+				ComputedRoot: computedRoot,
+				AccountDiffs: fakeDiffs,
+			}))
+			mockDB.AssertDiffStateTriesCalledWith([][2]common.Hash{{test_helpers.FakeHash, computedRoot}})
+		})
+
+		It("diffs computed state against parent state if expected state is unavailable", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: genesisRoot})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(computedRoot)
+			mockDB.SetDiffStateTriesError(test_helpers.FakeError)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+			mockDB.AssertDiffStateTriesCalledWith([][2]common.Hash{{test_helpers.FakeHash, computedRoot}, {genesisRoot, computedRoot}})
+		})
+
+		It("does not publish mismatched state when stopping", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: genesisRoot})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(computedRoot)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).To(HaveOccurred())
+			mockDB.AssertGetStateTrieNodesCalledWith(genesisRoot)
+		})
+
+		It("publishes mismatched state and builds on it when continuing", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: genesisRoot})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(computedRoot)
+			validation := transformers.NewStateRootValidation(transformers.ContinueOnStateRootMismatch, nil)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, validation, nil, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 2)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockDB.AssertGetStateTrieNodesCalledWith(computedRoot)
+			mockDB.AssertComputeBlockStateTrieCalledWith(fakeBlock, computedRoot)
+		})
+
+		It("writes a JSON report for each mismatch", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: genesisRoot})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(computedRoot)
+			mockDB.SetDiffStateTriesReturnDiffs(fakeDiffs)
+			report := &bytes.Buffer{}
+			validation := transformers.NewStateRootValidation(transformers.ContinueOnStateRootMismatch, report)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, validation, nil, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			var written transformers.StateRootMismatchReport
+			Expect(json.Unmarshal(report.Bytes(), &written)).To(Succeed())
+			Expect(written.BlockNumber).To(Equal(int64(1)))
+			Expect(written.ComputedRoot).To(Equal(computedRoot))
+			Expect(written.AccountDiffs).To(Equal(fakeDiffs))
+		})
+
+		It("does not diff state when computed root matches header", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: genesisRoot})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockDB.AssertDiffStateTriesCalledWith(nil)
+		})
+	})
+
+	Describe("exporting state diffs", func() {
+		It("exports the diff for genesis and each computed block", func() {
+			fakeBlock := types.NewBlockWithHeader(&types.Header{Root: test_helpers.FakeHash, Number: big.NewInt(1)})
+			genesisRoot := common.HexToHash("0x456")
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: genesisRoot})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			publisher := ipfs.NewMockPublisher()
+			diffs := transformers.NewStateDiffExporter(nil, publisher)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, diffs, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockDB.AssertDiffStateTriesCalledWith([][2]common.Hash{{eth_db.EmptyTrieRoot, genesisRoot}, {genesisRoot, test_helpers.FakeHash}})
+			publisher.AssertWriteCalledWithBlockNumbers([]int64{0, 1})
+		})
+	})
+
+	Describe("exporting transaction traces", func() {
+		var (
+			fakeBlock  = types.NewBlockWithHeader(&types.Header{Root: test_helpers.FakeHash, Number: big.NewInt(1)})
+			fakeTraces = []level.TransactionTrace{{TxHash: common.HexToHash("0xabc"), CallFrame: &level.CallFrame{Type: "CALL"}}}
+		)
+
+		newMockDB := func() *db.MockDatabase {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: common.HexToHash("0x456")})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			mockDB.SetComputeBlockStateTrieReturnTraces(fakeTraces)
+			return mockDB
+		}
+
+		It("does not trace blocks unless traces are exported", func() {
+			mockDB := newMockDB()
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockDB.AssertComputeBlockStateTrieTraced(false)
+		})
+
+		It("writes each computed block's traces as a JSON line", func() {
+			mockDB := newMockDB()
+			var out bytes.Buffer
+			traces := transformers.NewTraceExporter(&out, nil)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, traces, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockDB.AssertComputeBlockStateTrieTraced(true)
+			var written eth_db.BlockTraces
+			Expect(json.Unmarshal(out.Bytes(), &written)).To(Succeed())
+			Expect(written.BlockNumber).To(Equal(int64(1)))
+			Expect(written.BlockHash).To(Equal(fakeBlock.Hash()))
+			Expect(written.Transactions).To(Equal(fakeTraces))
+		})
+
+		It("publishes each computed block's traces", func() {
+			publisher := ipfs.NewMockPublisher()
+			traces := transformers.NewTraceExporter(nil, publisher)
+			transformer := transformers.NewComputeEthStateTrieTransformer(newMockDB(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, traces, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			publisher.AssertWriteCalledWithInterfaces([]interface{}{eth_db.BlockTraces{BlockNumber: 1, BlockHash: fakeBlock.Hash(), Transactions: fakeTraces}})
+		})
+
+		It("returns error if publishing traces fails", func() {
+			publisher := ipfs.NewMockPublisher()
+			publisher.SetError(test_helpers.FakeError)
+			traces := transformers.NewTraceExporter(nil, publisher)
+			transformer := transformers.NewComputeEthStateTrieTransformer(newMockDB(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, traces, nil, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+		})
+	})
+
+	Describe("with an account selection", func() {
+		It("fetches only the selected accounts' trie nodes for genesis and computed blocks", func() {
+			addresses := []common.Address{common.HexToAddress("0xabc")}
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Root: test_helpers.FakeHash}))
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			fakeCodes := [][]byte{{7, 8, 9}}
+			mockDB.SetGetAccountTrieNodesReturnCodes(fakeCodes)
+			mockCodePublisher := ipfs.NewMockPublisher()
+			selection := transformers.NewAccountSelection(addresses, mockCodePublisher)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, selection, nil, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockDB.AssertGetAccountTrieNodesCalledWith(test_helpers.FakeHash, addresses)
+			mockCodePublisher.AssertWriteCalledWithBytes(fakeCodes)
+		})
+	})
+
+	Describe("with a shard manifest", func() {
+		It("writes an entry for genesis and each computed block", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			mockDB.SetGetBlockByBlockNumberReturnBlock(types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1), Root: test_helpers.FakeHash}))
+			mockDB.SetComputeBlockStateTrieReturnHash(test_helpers.FakeHash)
+			var out bytes.Buffer
+			manifest := transformers.NewShardManifest(&out, level.FullKeySpace)
+			transformer := transformers.NewComputeEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, transformers.DefaultStateRootValidation, nil, nil, nil, manifest, nil)
+
+			err := transformer.Execute(context.Background(), 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			decoder := json.NewDecoder(&out)
+			var genesis, computed transformers.ShardManifestEntry
+			Expect(decoder.Decode(&genesis)).To(Succeed())
+			Expect(decoder.Decode(&computed)).To(Succeed())
+			Expect(genesis.BlockNumber).To(Equal(transformers.GenesisBlockNumber))
+			Expect(computed.BlockNumber).To(Equal(int64(1)))
+			Expect(computed.StateRoot).To(Equal(test_helpers.FakeHash))
 		})
 	})
 })
@@ -24,3 +24,9 @@ func NewExecuteError(msg string, err error) *ExecuteError {
 func (ee ExecuteError) Error() string {
 	return fmt.Sprintf("%s: %s", ee.msg, ee.err.Error())
 }
+
+// Unwrap returns the error that failed the transformer, so that callers can
+// classify it, e.g. with level.IsNotFound
+func (ee ExecuteError) Unwrap() error {
+	return ee.err
+}
@@ -1,7 +1,7 @@
 package transformers
 
 import (
-	"log"
+	"context"
 
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
@@ -9,24 +9,43 @@ import (
 
 type EthBlockHeaderTransformer struct {
 	database  db.Database
-	publisher ipfs.Publisher
+	policy    MissingDataPolicy
+	publisher ipfs.HeaderPublisher
 }
 
-func NewEthBlockHeaderTransformer(ethDB db.Database, publisher ipfs.Publisher) *EthBlockHeaderTransformer {
-	return &EthBlockHeaderTransformer{database: ethDB, publisher: publisher}
+func NewEthBlockHeaderTransformer(ethDB db.Database, publisher ipfs.HeaderPublisher, policy MissingDataPolicy) *EthBlockHeaderTransformer {
+	return &EthBlockHeaderTransformer{database: ethDB, policy: policy, publisher: publisher}
 }
 
-func (t EthBlockHeaderTransformer) Execute(startingBlockNumber int64, endingBlockNumber int64) error {
+func (t EthBlockHeaderTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
 	if endingBlockNumber < startingBlockNumber {
 		return ErrInvalidRange
 	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
 	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
-		blockData := t.database.GetRawBlockHeaderByBlockNumber(i)
-		output, err := t.publisher.Write(blockData)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, blockCtx := newBlockSummary(rangeCtx, i)
+		var blockData []byte
+		skip, err := t.policy.fetch(ctx, i, func() (err error) {
+			blockData, err = t.database.GetRawBlockHeaderByBlockNumber(blockCtx, i)
+			return err
+		})
+		if err != nil {
+			return summary.fail(err)
+		}
+		if skip {
+			summary.skipped()
+			continue
+		}
+		output, err := t.publisher.WriteHeader(blockCtx, i, blockData)
 		if err != nil {
-			return NewExecuteError(PutIpldErr, err)
+			return summary.fail(NewExecuteError(PutIpldErr, err))
 		}
-		log.Printf("Created IPLD: %s", output)
+		summary.add(output)
+		summary.done()
 	}
 	return nil
 }
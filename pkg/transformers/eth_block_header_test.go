@@ -1,12 +1,15 @@
 package transformers_test
 
 import (
+	"context"
 	"io/ioutil"
-	"log"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
 	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
 	"github.com/vulcanize/eth-block-extractor/test_helpers"
 	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
@@ -27,32 +30,31 @@ var _ = Describe("Eth block header transformer", func() {
 			blockNumber = 54321
 			fakeBytes = []byte{6, 7, 8, 9, 0}
 			mockDB.SetGetRawBlockHeaderByBlockNumberReturnBytes([][]byte{fakeBytes})
-			mockPublisher.SetReturnStrings([][]string{{"cid_one", "cid_two"}})
 			log.SetOutput(ioutil.Discard)
 		})
 
 		It("returns error if ending block number is less than starting block number", func() {
-			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 
-			err := transformer.Execute(1, 0)
+			err := transformer.Execute(context.Background(), 1, 0)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(transformers.ErrInvalidRange))
 		})
 
 		It("Fetches RLP data from ethereum db", func() {
-			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 
-			err := transformer.Execute(blockNumber, blockNumber)
+			err := transformer.Execute(context.Background(), blockNumber, blockNumber)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockDB.AssertGetRawBlockHeaderByBlockNumberCalledWith([]int64{blockNumber})
 		})
 
 		It("Persists block RLP data to IPFS", func() {
-			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 
-			err := transformer.Execute(blockNumber, blockNumber)
+			err := transformer.Execute(context.Background(), blockNumber, blockNumber)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockPublisher.AssertWriteCalledWithBytes([][]byte{fakeBytes})
@@ -60,9 +62,9 @@ var _ = Describe("Eth block header transformer", func() {
 
 		It("Returns err if persisting block RLP data to IPFS fails", func() {
 			mockPublisher.SetError(test_helpers.FakeError)
-			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 
-			err := transformer.Execute(blockNumber, blockNumber)
+			err := transformer.Execute(context.Background(), blockNumber, blockNumber)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(transformers.NewExecuteError(transformers.PutIpldErr, test_helpers.FakeError)))
@@ -83,28 +85,131 @@ var _ = Describe("Eth block header transformer", func() {
 			endingBlockNumber = 54322
 			fakeRlpBytes = []byte{6, 7, 8, 9, 0}
 			mockDB.SetGetRawBlockHeaderByBlockNumberReturnBytes([][]byte{fakeRlpBytes, fakeRlpBytes})
-			fakeOutputString := []string{"cid_one", "cid_two"}
-			mockPublisher.SetReturnStrings([][]string{fakeOutputString, fakeOutputString})
 			log.SetOutput(ioutil.Discard)
 		})
 
 		It("Fetches block RLP data from ethereum db for every block in range", func() {
-			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 
-			err := transformer.Execute(startingBlockNumber, endingBlockNumber)
+			err := transformer.Execute(context.Background(), startingBlockNumber, endingBlockNumber)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockDB.AssertGetRawBlockHeaderByBlockNumberCalledWith([]int64{startingBlockNumber, endingBlockNumber})
 		})
 
 		It("Persists block RLP data to IPFS for every block in range", func() {
-			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 
-			err := transformer.Execute(startingBlockNumber, endingBlockNumber)
+			err := transformer.Execute(context.Background(), startingBlockNumber, endingBlockNumber)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockPublisher.AssertWriteCalledWithBytes([][]byte{fakeRlpBytes, fakeRlpBytes})
 		})
+
+		It("counts processed blocks and times their reads", func() {
+			processed := metrics.BlocksProcessed.Value()
+			reads := metrics.DatabaseReadSeconds.Count()
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
+
+			err := transformer.Execute(context.Background(), startingBlockNumber, endingBlockNumber)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(metrics.BlocksProcessed.Value()).To(Equal(processed + 2))
+			Expect(metrics.DatabaseReadSeconds.Count()).To(Equal(reads + 2))
+		})
 	})
 
+	Describe("Handling missing block data", func() {
+		var fakeBytes []byte
+		var missingDataErr error
+
+		BeforeEach(func() {
+			mockDB = db.NewMockDatabase()
+			mockPublisher = ipfs.NewMockPublisher()
+			fakeBytes = []byte{6, 7, 8, 9, 0}
+			missingDataErr = level.NewBlockDataError(1, level.BlockHeader, level.ErrNotFound)
+			log.SetOutput(ioutil.Discard)
+		})
+
+		It("returns error by default", func() {
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnErrors([]error{missingDataErr})
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
+
+			err := transformer.Execute(context.Background(), 1, 1)
+
+			Expect(err).To(MatchError(transformers.NewExecuteError(transformers.GetBlockRlpErr, missingDataErr)))
+			Expect(level.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("skips blocks with missing data if policy is to skip", func() {
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnErrors([]error{missingDataErr, nil})
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnBytes([][]byte{fakeBytes})
+			policy := transformers.NewMissingDataPolicy(transformers.SkipMissingData, 0, 0)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, policy)
+
+			err := transformer.Execute(context.Background(), 1, 2)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockPublisher.AssertWriteCalledWithBytes([][]byte{fakeBytes})
+		})
+
+		It("counts missing data and other read errors by type", func() {
+			missing := metrics.Errors.With(metrics.MissingDataError).Value()
+			failed := metrics.Errors.With(metrics.DatabaseReadError).Value()
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnErrors([]error{missingDataErr, test_helpers.FakeError})
+			policy := transformers.NewMissingDataPolicy(transformers.SkipMissingData, 0, 0)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, policy)
+
+			err := transformer.Execute(context.Background(), 1, 2)
+
+			Expect(err).To(HaveOccurred())
+			Expect(metrics.Errors.With(metrics.MissingDataError).Value()).To(Equal(missing + 1))
+			Expect(metrics.Errors.With(metrics.DatabaseReadError).Value()).To(Equal(failed + 1))
+		})
+
+		It("returns a cancelled read's error as is, without counting it", func() {
+			failed := metrics.Errors.With(metrics.DatabaseReadError).Value()
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnErrors([]error{context.Canceled})
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
+
+			err := transformer.Execute(context.Background(), 1, 1)
+
+			Expect(err).To(Equal(context.Canceled))
+			Expect(metrics.Errors.With(metrics.DatabaseReadError).Value()).To(Equal(failed))
+		})
+
+		It("does not skip errors that are not missing data", func() {
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnErrors([]error{test_helpers.FakeError})
+			policy := transformers.NewMissingDataPolicy(transformers.SkipMissingData, 0, 0)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, policy)
+
+			err := transformer.Execute(context.Background(), 1, 1)
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("retries blocks with missing data if policy is to retry", func() {
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnErrors([]error{missingDataErr, nil})
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnBytes([][]byte{fakeBytes})
+			policy := transformers.NewMissingDataPolicy(transformers.RetryMissingData, 1, 0)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, policy)
+
+			err := transformer.Execute(context.Background(), 1, 1)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockDB.AssertGetRawBlockHeaderByBlockNumberCalledWith([]int64{1, 1})
+			mockPublisher.AssertWriteCalledWithBytes([][]byte{fakeBytes})
+		})
+
+		It("returns error once retries are exhausted", func() {
+			mockDB.SetGetRawBlockHeaderByBlockNumberReturnErrors([]error{missingDataErr, missingDataErr})
+			policy := transformers.NewMissingDataPolicy(transformers.RetryMissingData, 1, 0)
+			transformer := transformers.NewEthBlockHeaderTransformer(mockDB, mockPublisher, policy)
+
+			err := transformer.Execute(context.Background(), 1, 1)
+
+			Expect(err).To(HaveOccurred())
+			mockDB.AssertGetRawBlockHeaderByBlockNumberCalledWith([]int64{1, 1})
+		})
+	})
 })
@@ -1,34 +1,56 @@
 package transformers
 
 import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
-	"log"
 )
 
 type EthBlockReceiptTransformer struct {
 	database  db.Database
-	publisher ipfs.Publisher
+	policy    MissingDataPolicy
+	publisher ipfs.ReceiptsPublisher
 }
 
-func NewEthBlockReceiptTransformer(database db.Database, publisher ipfs.Publisher) *EthBlockReceiptTransformer {
+func NewEthBlockReceiptTransformer(database db.Database, publisher ipfs.ReceiptsPublisher, policy MissingDataPolicy) *EthBlockReceiptTransformer {
 	return &EthBlockReceiptTransformer{
 		database:  database,
+		policy:    policy,
 		publisher: publisher,
 	}
 }
 
-func (transformer EthBlockReceiptTransformer) Execute(startingBlockNumber int64, endingBlockNumber int64) error {
+func (transformer EthBlockReceiptTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
 	if endingBlockNumber < startingBlockNumber {
 		return ErrInvalidRange
 	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
 	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
-		receipts := transformer.database.GetBlockReceipts(i)
-		cids, err := transformer.publisher.Write(receipts)
-		if err != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, blockCtx := newBlockSummary(rangeCtx, i)
+		var receipts types.Receipts
+		skip, err := transformer.policy.fetch(ctx, i, func() (err error) {
+			receipts, err = transformer.database.GetBlockReceipts(blockCtx, i)
 			return err
+		})
+		if err != nil {
+			return summary.fail(err)
+		}
+		if skip {
+			summary.skipped()
+			continue
+		}
+		cids, err := transformer.publisher.WriteReceipts(blockCtx, i, receipts)
+		if err != nil {
+			return summary.fail(err)
 		}
-		log.Println("Generated IPLDs: ", cids)
+		summary.add(cids...)
+		summary.done()
 	}
 	return nil
 }
@@ -1,15 +1,17 @@
 package transformers_test
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
 	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
 	"github.com/vulcanize/eth-block-extractor/test_helpers"
 	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
 	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
 	"io/ioutil"
-	"log"
 )
 
 var _ = Describe("Eth block receipts transformer", func() {
@@ -18,9 +20,9 @@ var _ = Describe("Eth block receipts transformer", func() {
 	})
 
 	It("returns error if ending block number is less than starting block number", func() {
-		transformer := transformers.NewEthBlockReceiptTransformer(db.NewMockDatabase(), ipfs.NewMockPublisher())
+		transformer := transformers.NewEthBlockReceiptTransformer(db.NewMockDatabase(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
 
-		err := transformer.Execute(1, 0)
+		err := transformer.Execute(context.Background(), 1, 0)
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(transformers.ErrInvalidRange))
@@ -28,9 +30,9 @@ var _ = Describe("Eth block receipts transformer", func() {
 
 	It("fetches blocks' receipts from database", func() {
 		mockDatabase := db.NewMockDatabase()
-		transformer := transformers.NewEthBlockReceiptTransformer(mockDatabase, ipfs.NewMockPublisher())
+		transformer := transformers.NewEthBlockReceiptTransformer(mockDatabase, ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
 
-		err := transformer.Execute(0, 1)
+		err := transformer.Execute(context.Background(), 0, 1)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockDatabase.AssertGetBlockReceiptsCalledWith([]int64{0, 1})
@@ -44,14 +46,24 @@ var _ = Describe("Eth block receipts transformer", func() {
 		}
 		mockDatabase.SetGetBlockReceiptsReturnReceipts(fakeReceipts)
 		mockPublisher := ipfs.NewMockPublisher()
-		transformer := transformers.NewEthBlockReceiptTransformer(mockDatabase, mockPublisher)
+		transformer := transformers.NewEthBlockReceiptTransformer(mockDatabase, mockPublisher, transformers.DefaultMissingDataPolicy)
 
-		err := transformer.Execute(0, 0)
+		err := transformer.Execute(context.Background(), 0, 0)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockPublisher.AssertWriteCalledWithInterfaces([]interface{}{fakeReceipts})
 	})
 
+	It("publishes receipts with their block number", func() {
+		mockPublisher := ipfs.NewMockPublisher()
+		transformer := transformers.NewEthBlockReceiptTransformer(db.NewMockDatabase(), mockPublisher, transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 5, 6)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockPublisher.AssertWriteCalledWithBlockNumbers([]int64{5, 6})
+	})
+
 	It("returns error if publishing block receipts fails", func() {
 		mockDatabase := db.NewMockDatabase()
 		fakeReceipts := types.Receipts{
@@ -61,9 +73,9 @@ var _ = Describe("Eth block receipts transformer", func() {
 		mockDatabase.SetGetBlockReceiptsReturnReceipts(fakeReceipts)
 		mockPublisher := ipfs.NewMockPublisher()
 		mockPublisher.SetError(test_helpers.FakeError)
-		transformer := transformers.NewEthBlockReceiptTransformer(mockDatabase, mockPublisher)
+		transformer := transformers.NewEthBlockReceiptTransformer(mockDatabase, mockPublisher, transformers.DefaultMissingDataPolicy)
 
-		err := transformer.Execute(0, 0)
+		err := transformer.Execute(context.Background(), 0, 0)
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(test_helpers.FakeError))
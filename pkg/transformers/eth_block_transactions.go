@@ -1,31 +1,52 @@
 package transformers
 
 import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
-	"log"
 )
 
 type EthBlockTransactionsTransformer struct {
 	database  db.Database
-	publisher ipfs.Publisher
+	policy    MissingDataPolicy
+	publisher ipfs.BodyPublisher
 }
 
-func NewEthBlockTransactionsTransformer(db db.Database, publisher ipfs.Publisher) *EthBlockTransactionsTransformer {
-	return &EthBlockTransactionsTransformer{database: db, publisher: publisher}
+func NewEthBlockTransactionsTransformer(db db.Database, publisher ipfs.BodyPublisher, policy MissingDataPolicy) *EthBlockTransactionsTransformer {
+	return &EthBlockTransactionsTransformer{database: db, policy: policy, publisher: publisher}
 }
 
-func (t EthBlockTransactionsTransformer) Execute(startingBlockNumber int64, endingBlockNumber int64) error {
+func (t EthBlockTransactionsTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
 	if endingBlockNumber < startingBlockNumber {
 		return ErrInvalidRange
 	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
 	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
-		body := t.database.GetBlockBodyByBlockNumber(i)
-		res, err := t.publisher.Write(body)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, blockCtx := newBlockSummary(rangeCtx, i)
+		var body *types.Body
+		skip, err := t.policy.fetch(ctx, i, func() (err error) {
+			body, err = t.database.GetBlockBodyByBlockNumber(blockCtx, i)
+			return err
+		})
+		if err != nil {
+			return summary.fail(err)
+		}
+		if skip {
+			summary.skipped()
+			continue
+		}
+		res, err := t.publisher.WriteBody(blockCtx, i, body)
 		if err != nil {
-			return NewExecuteError(PutIpldErr, err)
+			return summary.fail(NewExecuteError(PutIpldErr, err))
 		}
-		log.Println("Created CIDs: ", res)
+		summary.add(res...)
+		summary.done()
 	}
 	return nil
 }
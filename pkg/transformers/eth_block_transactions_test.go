@@ -1,11 +1,12 @@
 package transformers_test
 
 import (
+	"context"
 	"io/ioutil"
-	"log"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
 
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
@@ -21,9 +22,9 @@ var _ = Describe("Eth block transactions transformer", func() {
 		})
 
 		It("returns error if ending block number is less than starting block number", func() {
-			transformer := transformers.NewEthBlockTransactionsTransformer(db.NewMockDatabase(), ipfs.NewMockPublisher())
+			transformer := transformers.NewEthBlockTransactionsTransformer(db.NewMockDatabase(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
 
-			err := transformer.Execute(1, 0)
+			err := transformer.Execute(context.Background(), 1, 0)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(transformers.ErrInvalidRange))
@@ -33,11 +34,10 @@ var _ = Describe("Eth block transactions transformer", func() {
 			mockDB := db.NewMockDatabase()
 			mockDB.SetGetBlockBodyByBlockNumberReturnBody([]*types.Body{{}})
 			mockPublisher := ipfs.NewMockPublisher()
-			mockPublisher.SetReturnStrings([][]string{{"cid"}})
-			transformer := transformers.NewEthBlockTransactionsTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockTransactionsTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 			blockNumber := int64(1234567)
 
-			err := transformer.Execute(blockNumber, blockNumber)
+			err := transformer.Execute(context.Background(), blockNumber, blockNumber)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockDB.AssertGetBlockBodyByBlockNumberCalledWith([]int64{blockNumber})
@@ -48,11 +48,10 @@ var _ = Describe("Eth block transactions transformer", func() {
 			fakeRawData := []*types.Body{{}}
 			mockDB.SetGetBlockBodyByBlockNumberReturnBody(fakeRawData)
 			mockPublisher := ipfs.NewMockPublisher()
-			mockPublisher.SetReturnStrings([][]string{{"cid"}})
-			transformer := transformers.NewEthBlockTransactionsTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockTransactionsTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 			blockNumber := int64(1234567)
 
-			err := transformer.Execute(blockNumber, blockNumber)
+			err := transformer.Execute(context.Background(), blockNumber, blockNumber)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockPublisher.AssertWriteCalledWithBodies(fakeRawData)
@@ -64,10 +63,10 @@ var _ = Describe("Eth block transactions transformer", func() {
 			mockDB.SetGetBlockBodyByBlockNumberReturnBody(fakeRawData)
 			mockPublisher := ipfs.NewMockPublisher()
 			mockPublisher.SetError(test_helpers.FakeError)
-			transformer := transformers.NewEthBlockTransactionsTransformer(mockDB, mockPublisher)
+			transformer := transformers.NewEthBlockTransactionsTransformer(mockDB, mockPublisher, transformers.DefaultMissingDataPolicy)
 			blockNumber := int64(1234567)
 
-			err := transformer.Execute(blockNumber, blockNumber)
+			err := transformer.Execute(context.Background(), blockNumber, blockNumber)
 
 			Expect(err).To(HaveOccurred())
 			Expect(err).To(MatchError(transformers.NewExecuteError(transformers.PutIpldErr, test_helpers.FakeError)))
@@ -79,12 +78,11 @@ var _ = Describe("Eth block transactions transformer", func() {
 			mockDatabase := db.NewMockDatabase()
 			mockDatabase.SetGetBlockBodyByBlockNumberReturnBody([]*types.Body{{}, {}})
 			mockPublisher := ipfs.NewMockPublisher()
-			mockPublisher.SetReturnStrings([][]string{{"cid_one"}, {"cid_two"}})
-			transformer := transformers.NewEthBlockTransactionsTransformer(mockDatabase, mockPublisher)
+			transformer := transformers.NewEthBlockTransactionsTransformer(mockDatabase, mockPublisher, transformers.DefaultMissingDataPolicy)
 			startingBlockNumber := int64(1234567)
 			endingBlockNumber := int64(1234568)
 
-			err := transformer.Execute(startingBlockNumber, endingBlockNumber)
+			err := transformer.Execute(context.Background(), startingBlockNumber, endingBlockNumber)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockDatabase.AssertGetBlockBodyByBlockNumberCalledWith([]int64{startingBlockNumber, endingBlockNumber})
@@ -95,12 +93,11 @@ var _ = Describe("Eth block transactions transformer", func() {
 			fakeRawData := []*types.Body{{}, {}}
 			mockDatabase.SetGetBlockBodyByBlockNumberReturnBody(fakeRawData)
 			mockPublisher := ipfs.NewMockPublisher()
-			mockPublisher.SetReturnStrings([][]string{{"cid_one"}, {"cid_two"}})
-			transformer := transformers.NewEthBlockTransactionsTransformer(mockDatabase, mockPublisher)
+			transformer := transformers.NewEthBlockTransactionsTransformer(mockDatabase, mockPublisher, transformers.DefaultMissingDataPolicy)
 			startingBlockNumber := int64(1234567)
 			endingBlockNumber := int64(1234568)
 
-			err := transformer.Execute(startingBlockNumber, endingBlockNumber)
+			err := transformer.Execute(context.Background(), startingBlockNumber, endingBlockNumber)
 
 			Expect(err).NotTo(HaveOccurred())
 			mockPublisher.AssertWriteCalledWithBodies(fakeRawData)
@@ -0,0 +1,111 @@
+package transformers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// EthBlockWitnessTransformer publishes, for each block in a range, the state
+// and storage trie nodes read while executing it, along with an IPLD listing
+// them. Each block is executed against its parent's state, which must be
+// stored (e.g. on an archive node).
+type EthBlockWitnessTransformer struct {
+	database             db.Database
+	policy               MissingDataPolicy
+	stateTriePublisher   ipfs.StateTrieNodePublisher
+	storageTriePublisher ipfs.StorageTrieNodePublisher
+	witnessPublisher     ipfs.WitnessPublisher
+}
+
+func NewEthBlockWitnessTransformer(database db.Database, stateTriePublisher ipfs.StateTrieNodePublisher, storageTriePublisher ipfs.StorageTrieNodePublisher, witnessPublisher ipfs.WitnessPublisher, policy MissingDataPolicy) *EthBlockWitnessTransformer {
+	return &EthBlockWitnessTransformer{
+		database:             database,
+		policy:               policy,
+		stateTriePublisher:   stateTriePublisher,
+		storageTriePublisher: storageTriePublisher,
+		witnessPublisher:     witnessPublisher,
+	}
+}
+
+func (t EthBlockWitnessTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
+	if endingBlockNumber < startingBlockNumber {
+		return ErrInvalidRange
+	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
+	if startingBlockNumber == GenesisBlockNumber {
+		log.Info("Genesis block is not executed and has no witness. Starting at block 1.")
+		startingBlockNumber = FirstBlockToCompute
+	}
+	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, blockCtx := newBlockSummary(rangeCtx, i)
+		var block *types.Block
+		var parentRoot common.Hash
+		skip, err := t.policy.fetch(ctx, i, func() (err error) {
+			block, parentRoot, err = t.getBlockAndParentRoot(blockCtx, i)
+			return err
+		})
+		if err != nil {
+			return summary.fail(err)
+		}
+		if skip {
+			summary.skipped()
+			continue
+		}
+		witness, err := t.database.ComputeBlockWitness(blockCtx, block, parentRoot)
+		if err != nil {
+			return summary.fail(fmt.Errorf("Error computing witness for block %d: %s", i, err))
+		}
+		err = t.writeWitnessToIpfs(blockCtx, i, witness, summary)
+		if err != nil {
+			return summary.fail(err)
+		}
+		summary.done()
+	}
+	return nil
+}
+
+func (t EthBlockWitnessTransformer) getBlockAndParentRoot(ctx context.Context, blockNumber int64) (*types.Block, common.Hash, error) {
+	block, err := t.database.GetBlockByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	parentHeader, err := t.database.GetBlockHeaderByBlockNumber(ctx, blockNumber-1)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	return block, parentHeader.Root, nil
+}
+
+func (t EthBlockWitnessTransformer) writeWitnessToIpfs(ctx context.Context, blockNumber int64, witness level.Witness, summary *blockSummary) error {
+	for _, node := range witness.StateTrieNodes {
+		output, err := t.stateTriePublisher.WriteStateTrieNode(ctx, blockNumber, node)
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(output)
+	}
+	for _, node := range witness.StorageTrieNodes {
+		output, err := t.storageTriePublisher.WriteStorageTrieNode(ctx, blockNumber, node)
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(output)
+	}
+	output, err := t.witnessPublisher.WriteWitness(ctx, blockNumber, witness)
+	if err != nil {
+		return NewExecuteError(PutIpldErr, err)
+	}
+	summary.add(output)
+	return nil
+}
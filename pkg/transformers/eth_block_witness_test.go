@@ -0,0 +1,108 @@
+package transformers_test
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Eth block witness transformer", func() {
+	var (
+		fakeBlock   = types.NewBlockWithHeader(&types.Header{Number: big.NewInt(1)})
+		parentRoot  = common.HexToHash("0x456")
+		fakeWitness = level.Witness{
+			BlockHash:        fakeBlock.Hash(),
+			StateTrieNodes:   [][]byte{{1, 1, 1}, {2, 2, 2}},
+			StorageTrieNodes: [][]byte{{3, 3, 3}},
+		}
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+	})
+
+	newMockDB := func() *db.MockDatabase {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockByBlockNumberReturnBlock(fakeBlock)
+		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: parentRoot})
+		mockDB.SetComputeBlockWitnessReturnWitness(fakeWitness)
+		return mockDB
+	}
+
+	It("returns error if ending block number is less than starting block number", func() {
+		transformer := transformers.NewEthBlockWitnessTransformer(newMockDB(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 2, 1)
+
+		Expect(err).To(MatchError(transformers.ErrInvalidRange))
+	})
+
+	It("computes each block's witness against its parent's state root", func() {
+		mockDB := newMockDB()
+		transformer := transformers.NewEthBlockWitnessTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertGetBlockHeaderByBlockNumberCalledWith([]int64{0, 1})
+		mockDB.AssertComputeBlockWitnessCalledWith([]*types.Block{fakeBlock, fakeBlock}, []common.Hash{parentRoot, parentRoot})
+	})
+
+	It("starts after the genesis block", func() {
+		mockDB := newMockDB()
+		transformer := transformers.NewEthBlockWitnessTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 0, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertGetBlockByBlockNumberCalledwith([]int64{1})
+	})
+
+	It("publishes witness trie nodes and the witness", func() {
+		stateTriePublisher := ipfs.NewMockPublisher()
+		storageTriePublisher := ipfs.NewMockPublisher()
+		witnessPublisher := ipfs.NewMockPublisher()
+		transformer := transformers.NewEthBlockWitnessTransformer(newMockDB(), stateTriePublisher, storageTriePublisher, witnessPublisher, transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		stateTriePublisher.AssertWriteCalledWithBytes(fakeWitness.StateTrieNodes)
+		storageTriePublisher.AssertWriteCalledWithBytes(fakeWitness.StorageTrieNodes)
+		witnessPublisher.AssertWriteCalledWithInterfaces([]interface{}{fakeWitness})
+	})
+
+	It("returns error if computing witness fails", func() {
+		mockDB := newMockDB()
+		mockDB.SetComputeBlockWitnessError(test_helpers.FakeError)
+		transformer := transformers.NewEthBlockWitnessTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+	})
+
+	It("returns error if publishing witness fails", func() {
+		witnessPublisher := ipfs.NewMockPublisher()
+		witnessPublisher.SetError(test_helpers.FakeError)
+		transformer := transformers.NewEthBlockWitnessTransformer(newMockDB(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), witnessPublisher, transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+	})
+})
@@ -0,0 +1,128 @@
+package transformers
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// EthBlocksTransformer publishes the header and transactions of each block in
+// a range. If nonCanonical is set, side-chain blocks stored at each height
+// are published too.
+type EthBlocksTransformer struct {
+	blockHash       *common.Hash
+	bodyPublisher   ipfs.BodyPublisher
+	database        db.Database
+	headerPublisher ipfs.HeaderPublisher
+	index           *BlockIndex
+	nonCanonical    bool
+	policy          MissingDataPolicy
+}
+
+func NewEthBlocksTransformer(database db.Database, headerPublisher ipfs.HeaderPublisher, bodyPublisher ipfs.BodyPublisher, policy MissingDataPolicy, nonCanonical bool, index *BlockIndex) *EthBlocksTransformer {
+	return &EthBlocksTransformer{
+		bodyPublisher:   bodyPublisher,
+		database:        database,
+		headerPublisher: headerPublisher,
+		index:           index,
+		nonCanonical:    nonCanonical,
+		policy:          policy,
+	}
+}
+
+// WithBlockHash restricts the transformer to the block with hash, so that a
+// side-chain block can be published without the others at its height
+func (t *EthBlocksTransformer) WithBlockHash(hash common.Hash) *EthBlocksTransformer {
+	t.blockHash = &hash
+	return t
+}
+
+func (t EthBlocksTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
+	if endingBlockNumber < startingBlockNumber {
+		return ErrInvalidRange
+	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
+	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, blockCtx := newBlockSummary(rangeCtx, i)
+		var blocks []level.StoredBlock
+		skip, err := t.policy.fetch(ctx, i, func() (err error) {
+			blocks, err = t.database.GetAllBlocksByBlockNumber(blockCtx, i)
+			return err
+		})
+		if err != nil {
+			return summary.fail(err)
+		}
+		if skip {
+			summary.skipped()
+			continue
+		}
+		for _, block := range blocks {
+			if !block.Canonical && !t.nonCanonical {
+				continue
+			}
+			if t.blockHash != nil && block.Hash != *t.blockHash {
+				continue
+			}
+			err = t.publishBlock(blockCtx, i, block, summary)
+			if err != nil {
+				return summary.fail(err)
+			}
+		}
+		summary.done()
+	}
+	return nil
+}
+
+// publishBlock publishes a block's header, and its transactions and uncles if
+// its body was stored
+func (t EthBlocksTransformer) publishBlock(ctx context.Context, blockNumber int64, block level.StoredBlock, summary *blockSummary) error {
+	headerOutput, err := t.headerPublisher.WriteHeader(ctx, blockNumber, block.Header)
+	if err != nil {
+		return NewExecuteError(PutIpldErr, err)
+	}
+	summary.add(headerOutput)
+	var uncleOutputs, transactionOutputs []ipfs.Result
+	if block.Body != nil {
+		transactionOutputs, err = t.bodyPublisher.WriteBody(ctx, blockNumber, block.Body)
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(transactionOutputs...)
+		uncleOutputs, err = publishUncles(ctx, t.headerPublisher, blockNumber, block.Body.Uncles)
+		if err != nil {
+			return err
+		}
+		summary.add(uncleOutputs...)
+	}
+	if t.index == nil {
+		return nil
+	}
+	return t.index.record(blockNumber, block.Hash, block.Canonical, headerOutput, uncleOutputs, transactionOutputs, nil)
+}
+
+// publishUncles publishes the headers of a block's uncles, which are not in
+// the IPLDs of its transactions
+func publishUncles(ctx context.Context, publisher ipfs.HeaderPublisher, blockNumber int64, uncles []*types.Header) ([]ipfs.Result, error) {
+	outputs := make([]ipfs.Result, 0, len(uncles))
+	for _, uncle := range uncles {
+		raw, err := rlp.EncodeToBytes(uncle)
+		if err != nil {
+			return nil, err
+		}
+		output, err := publisher.WriteHeader(ctx, blockNumber, raw)
+		if err != nil {
+			return nil, NewExecuteError(PutIpldErr, err)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,156 @@
+package transformers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	eth_ipfs "github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_header"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_block_transactions"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Eth blocks transformer", func() {
+	var (
+		mockDB               *db.MockDatabase
+		mockHeaderPublisher  *ipfs.MockPublisher
+		mockBodyPublisher    *ipfs.MockPublisher
+		canonicalBlock       level.StoredBlock
+		sideBlock            level.StoredBlock
+		sideBlockWithoutBody level.StoredBlock
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+		mockDB = db.NewMockDatabase()
+		mockHeaderPublisher = ipfs.NewMockPublisher()
+		mockBodyPublisher = ipfs.NewMockPublisher()
+		canonicalBlock = level.StoredBlock{Hash: test_helpers.FakeHash, Canonical: true, Header: []byte{1}, Body: &types.Body{}}
+		sideBlock = level.StoredBlock{Hash: common.HexToHash("0x2"), Header: []byte{2}, Body: &types.Body{Uncles: []*types.Header{{}}}}
+		sideBlockWithoutBody = level.StoredBlock{Hash: common.HexToHash("0x3"), Header: []byte{3}}
+		mockDB.SetGetAllBlocksByBlockNumberReturnBlocks([]level.StoredBlock{sideBlock, canonicalBlock, sideBlockWithoutBody})
+	})
+
+	It("returns error if ending block number is less than starting block number", func() {
+		transformer := transformers.NewEthBlocksTransformer(mockDB, mockHeaderPublisher, mockBodyPublisher, transformers.DefaultMissingDataPolicy, false, nil)
+
+		err := transformer.Execute(context.Background(), 1, 0)
+
+		Expect(err).To(MatchError(transformers.ErrInvalidRange))
+	})
+
+	It("fetches every block stored at each height", func() {
+		transformer := transformers.NewEthBlocksTransformer(mockDB, mockHeaderPublisher, mockBodyPublisher, transformers.DefaultMissingDataPolicy, false, nil)
+
+		err := transformer.Execute(context.Background(), 1, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertGetAllBlocksByBlockNumberCalledWith([]int64{1, 2})
+	})
+
+	It("only publishes canonical blocks by default", func() {
+		transformer := transformers.NewEthBlocksTransformer(mockDB, mockHeaderPublisher, mockBodyPublisher, transformers.DefaultMissingDataPolicy, false, nil)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockHeaderPublisher.AssertWriteCalledWithBytes([][]byte{canonicalBlock.Header})
+		mockBodyPublisher.AssertWriteCalledWithInterfaces([]interface{}{canonicalBlock.Body})
+	})
+
+	It("publishes side-chain blocks if non-canonical blocks are included", func() {
+		transformer := transformers.NewEthBlocksTransformer(mockDB, mockHeaderPublisher, mockBodyPublisher, transformers.DefaultMissingDataPolicy, true, nil)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		uncle, err := rlp.EncodeToBytes(sideBlock.Body.Uncles[0])
+		Expect(err).NotTo(HaveOccurred())
+		mockHeaderPublisher.AssertWriteCalledWithBytes([][]byte{sideBlock.Header, uncle, canonicalBlock.Header, sideBlockWithoutBody.Header})
+		mockBodyPublisher.AssertWriteCalledWithInterfaces([]interface{}{sideBlock.Body, canonicalBlock.Body})
+	})
+
+	It("only publishes the block with the hash it is restricted to", func() {
+		transformer := transformers.NewEthBlocksTransformer(mockDB, mockHeaderPublisher, mockBodyPublisher, transformers.DefaultMissingDataPolicy, true, nil).WithBlockHash(sideBlockWithoutBody.Hash)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockHeaderPublisher.AssertWriteCalledWithBytes([][]byte{sideBlockWithoutBody.Header})
+		mockBodyPublisher.AssertWriteCalledWithInterfaces(nil)
+	})
+
+	It("records whether each published block is canonical in the block index", func() {
+		headerCid, err := util.RawToCid(eth_block_header.EthBlockHeaderCode, canonicalBlock.Header)
+		Expect(err).NotTo(HaveOccurred())
+		transactionCid, err := util.RawToCid(eth_block_transactions.EthBlockTransactionCode, []byte{4})
+		Expect(err).NotTo(HaveOccurred())
+		uncleCid, err := util.RawToCid(eth_block_header.EthBlockHeaderCode, []byte{5})
+		Expect(err).NotTo(HaveOccurred())
+		sideHeaderCid, err := util.RawToCid(eth_block_header.EthBlockHeaderCode, sideBlockWithoutBody.Header)
+		Expect(err).NotTo(HaveOccurred())
+		canonicalBlock.Body.Uncles = []*types.Header{{}}
+		mockDB.SetGetAllBlocksByBlockNumberReturnBlocks([]level.StoredBlock{canonicalBlock, sideBlockWithoutBody})
+		mockHeaderPublisher.SetReturnResults([][]eth_ipfs.Result{{{Cid: headerCid}}, {{Cid: uncleCid}}, {{Cid: sideHeaderCid}}})
+		mockBodyPublisher.SetReturnResults([][]eth_ipfs.Result{{{Cid: transactionCid}}})
+		var out bytes.Buffer
+		transformer := transformers.NewEthBlocksTransformer(mockDB, mockHeaderPublisher, mockBodyPublisher, transformers.DefaultMissingDataPolicy, true, transformers.NewBlockIndex(&out))
+
+		err = transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		decoder := json.NewDecoder(&out)
+		var entry transformers.BlockIndexEntry
+		Expect(decoder.Decode(&entry)).To(Succeed())
+		Expect(entry).To(Equal(transformers.BlockIndexEntry{
+			BlockNumber:     1,
+			Hash:            canonicalBlock.Hash,
+			Canonical:       true,
+			HeaderCid:       headerCid.String(),
+			UncleCids:       []string{uncleCid.String()},
+			TransactionCids: []string{transactionCid.String()},
+		}))
+		entry = transformers.BlockIndexEntry{}
+		Expect(decoder.Decode(&entry)).To(Succeed())
+		Expect(entry).To(Equal(transformers.BlockIndexEntry{
+			BlockNumber:     1,
+			Hash:            sideBlockWithoutBody.Hash,
+			Canonical:       false,
+			HeaderCid:       sideHeaderCid.String(),
+			TransactionCids: []string{},
+		}))
+	})
+
+	It("skips heights with no stored blocks if the policy allows", func() {
+		mockDB.SetGetAllBlocksByBlockNumberError(level.NewBlockDataError(1, level.BlockHeader, level.ErrNotFound))
+		transformer := transformers.NewEthBlocksTransformer(mockDB, mockHeaderPublisher, mockBodyPublisher, transformers.NewMissingDataPolicy(transformers.SkipMissingData, 0, 0), false, nil)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockHeaderPublisher.AssertWriteCalledWithBytes(nil)
+	})
+
+	It("returns error if publishing fails", func() {
+		mockHeaderPublisher.SetError(test_helpers.FakeError)
+		transformer := transformers.NewEthBlocksTransformer(mockDB, mockHeaderPublisher, mockBodyPublisher, transformers.DefaultMissingDataPolicy, false, nil)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).To(MatchError(transformers.NewExecuteError(transformers.PutIpldErr, test_helpers.FakeError)))
+	})
+})
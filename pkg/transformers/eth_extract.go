@@ -0,0 +1,175 @@
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
+)
+
+// ExtractPublishers holds a publisher for each type of data to extract. Types
+// without a publisher are not read.
+type ExtractPublishers struct {
+	Header      ipfs.HeaderPublisher
+	Body        ipfs.BodyPublisher
+	Receipts    ipfs.ReceiptsPublisher
+	StateTrie   ipfs.StateTrieNodePublisher
+	StorageTrie ipfs.StorageTrieNodePublisher
+}
+
+// EthExtractTransformer extracts several types of data for each block in a
+// range in a single pass, reading each block once for all of them
+type EthExtractTransformer struct {
+	database   db.Database
+	index      *BlockIndex
+	policy     MissingDataPolicy
+	progress   *ProgressTracker
+	publishers ExtractPublishers
+}
+
+func NewEthExtractTransformer(database db.Database, publishers ExtractPublishers, policy MissingDataPolicy, progress *ProgressTracker) *EthExtractTransformer {
+	return &EthExtractTransformer{
+		database:   database,
+		policy:     policy,
+		progress:   progress,
+		publishers: publishers,
+	}
+}
+
+// WithBlockIndex records each block whose header is extracted in index, with
+// its transactions and receipts if they are extracted
+func (t *EthExtractTransformer) WithBlockIndex(index *BlockIndex) *EthExtractTransformer {
+	t.index = index
+	return t
+}
+
+func (t EthExtractTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
+	if endingBlockNumber < startingBlockNumber {
+		return ErrInvalidRange
+	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
+	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, blockCtx := newBlockSummary(rangeCtx, i)
+		var block *types.Block
+		var receipts types.Receipts
+		skip, err := t.policy.fetch(ctx, i, func() (err error) {
+			block, receipts, err = t.getBlockData(blockCtx, i)
+			return err
+		})
+		if err != nil {
+			return summary.fail(err)
+		}
+		if !skip {
+			err = t.publishBlockData(blockCtx, i, block, receipts, summary)
+			if err != nil {
+				return summary.fail(err)
+			}
+			summary.done()
+		} else {
+			summary.skipped()
+		}
+		if t.progress != nil {
+			t.progress.blockDone(i)
+		}
+	}
+	return nil
+}
+
+// getBlockData reads the block if its header, transactions or state are
+// extracted, and its receipts if they are
+func (t EthExtractTransformer) getBlockData(ctx context.Context, blockNumber int64) (block *types.Block, receipts types.Receipts, err error) {
+	if t.publishers.Header != nil || t.publishers.Body != nil || t.publishers.StateTrie != nil {
+		block, err = t.database.GetBlockByBlockNumber(ctx, blockNumber)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if t.publishers.Receipts != nil {
+		receipts, err = t.database.GetBlockReceipts(ctx, blockNumber)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return block, receipts, nil
+}
+
+func (t EthExtractTransformer) publishBlockData(ctx context.Context, blockNumber int64, block *types.Block, receipts types.Receipts, summary *blockSummary) error {
+	var headerOutput ipfs.Result
+	var uncleOutputs, transactionOutputs, receiptOutputs []ipfs.Result
+	if t.publishers.Header != nil {
+		header, err := rlp.EncodeToBytes(block.Header())
+		if err != nil {
+			return err
+		}
+		headerOutput, err = t.publishers.Header.WriteHeader(ctx, blockNumber, header)
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(headerOutput)
+		uncleOutputs, err = publishUncles(ctx, t.publishers.Header, blockNumber, block.Uncles())
+		if err != nil {
+			return err
+		}
+		summary.add(uncleOutputs...)
+	}
+	if t.publishers.Body != nil {
+		var err error
+		transactionOutputs, err = t.publishers.Body.WriteBody(ctx, blockNumber, block.Body())
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(transactionOutputs...)
+	}
+	if t.publishers.Receipts != nil {
+		var err error
+		receiptOutputs, err = t.publishers.Receipts.WriteReceipts(ctx, blockNumber, receipts)
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(receiptOutputs...)
+	}
+	if t.publishers.StateTrie != nil {
+		err := t.publishState(ctx, blockNumber, block, summary)
+		if err != nil {
+			return err
+		}
+	}
+	if t.index == nil || t.publishers.Header == nil {
+		return nil
+	}
+	return t.index.record(blockNumber, block.Hash(), true, headerOutput, uncleOutputs, transactionOutputs, receiptOutputs)
+}
+
+func (t EthExtractTransformer) publishState(ctx context.Context, blockNumber int64, block *types.Block, summary *blockSummary) error {
+	start := time.Now()
+	stateTrieNodes, storageTrieNodes, err := t.database.GetStateAndStorageTrieNodes(ctx, block.Root())
+	metrics.DatabaseReadSeconds.ObserveSince(start)
+	if err != nil {
+		metrics.Errors.With(metrics.DatabaseReadError).Inc()
+		return fmt.Errorf("Error fetching state trie for block %d: %s\n", blockNumber, err)
+	}
+	for _, node := range stateTrieNodes {
+		output, err := t.publishers.StateTrie.WriteStateTrieNode(ctx, blockNumber, node)
+		if err != nil {
+			return fmt.Errorf("Error writing state trie node to ipfs: %s\n", err)
+		}
+		summary.add(output)
+	}
+	for _, node := range storageTrieNodes {
+		output, err := t.publishers.StorageTrie.WriteStorageTrieNode(ctx, blockNumber, node.Node)
+		if err != nil {
+			return fmt.Errorf("Error writing storage trie node to ipfs: %s\n", err)
+		}
+		summary.add(output)
+	}
+	return nil
+}
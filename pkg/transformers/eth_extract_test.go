@@ -0,0 +1,342 @@
+package transformers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ipfs/go-cid"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/opentracing/opentracing-go"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	eth_ipfs "github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
+	"github.com/vulcanize/eth-block-extractor/pkg/tracing"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+// cancellingDatabase cancels a context when a block is read, as an interrupt
+// arriving while the block is in flight would
+type cancellingDatabase struct {
+	*db.MockDatabase
+	cancel context.CancelFunc
+}
+
+func (d cancellingDatabase) GetBlockByBlockNumber(ctx context.Context, blockNumber int64) (*types.Block, error) {
+	d.cancel()
+	return d.MockDatabase.GetBlockByBlockNumber(ctx, blockNumber)
+}
+
+// spanRecorder keeps the spans traced by a transformer
+type spanRecorder struct {
+	spans []tracing.Span
+}
+
+func (r *spanRecorder) Record(span tracing.Span) {
+	r.spans = append(r.spans, span)
+}
+
+func (r *spanRecorder) Close() error {
+	return nil
+}
+
+var _ = Describe("Eth extract transformer", func() {
+	var (
+		mockDB                   *db.MockDatabase
+		mockHeaderPublisher      *ipfs.MockPublisher
+		mockBodyPublisher        *ipfs.MockPublisher
+		mockReceiptsPublisher    *ipfs.MockPublisher
+		mockStateTriePublisher   *ipfs.MockPublisher
+		mockStorageTriePublisher *ipfs.MockPublisher
+		allPublishers            transformers.ExtractPublishers
+		block                    *types.Block
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+		mockDB = db.NewMockDatabase()
+		block = types.NewBlockWithHeader(&types.Header{Root: test_helpers.FakeHash})
+		mockDB.SetGetBlockByBlockNumberReturnBlock(block)
+		mockHeaderPublisher = ipfs.NewMockPublisher()
+		mockBodyPublisher = ipfs.NewMockPublisher()
+		mockReceiptsPublisher = ipfs.NewMockPublisher()
+		mockStateTriePublisher = ipfs.NewMockPublisher()
+		mockStorageTriePublisher = ipfs.NewMockPublisher()
+		allPublishers = transformers.ExtractPublishers{
+			Header:      mockHeaderPublisher,
+			Body:        mockBodyPublisher,
+			Receipts:    mockReceiptsPublisher,
+			StateTrie:   mockStateTriePublisher,
+			StorageTrie: mockStorageTriePublisher,
+		}
+	})
+
+	It("returns error if ending block number is less than starting block number", func() {
+		transformer := transformers.NewEthExtractTransformer(mockDB, allPublishers, transformers.DefaultMissingDataPolicy, nil)
+
+		err := transformer.Execute(context.Background(), 1, 0)
+
+		Expect(err).To(MatchError(transformers.ErrInvalidRange))
+	})
+
+	It("reads each block once for its header, transactions and state", func() {
+		transformer := transformers.NewEthExtractTransformer(mockDB, allPublishers, transformers.DefaultMissingDataPolicy, nil)
+
+		err := transformer.Execute(context.Background(), 1, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertGetBlockByBlockNumberCalledwith([]int64{1, 2})
+		mockDB.AssertGetBlockReceiptsCalledWith([]int64{1, 2})
+	})
+
+	It("publishes every selected type for each block", func() {
+		fakeReceipts := types.Receipts{&types.Receipt{}}
+		mockDB.SetGetBlockReceiptsReturnReceipts(fakeReceipts)
+		mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes([][]byte{{1, 2, 3}})
+		mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieNodes([]level.StorageTrieNode{{Node: []byte{4, 5, 6}}})
+		transformer := transformers.NewEthExtractTransformer(mockDB, allPublishers, transformers.DefaultMissingDataPolicy, nil)
+
+		err := transformer.Execute(context.Background(), 3, 3)
+
+		Expect(err).NotTo(HaveOccurred())
+		rawHeader, err := rlp.EncodeToBytes(block.Header())
+		Expect(err).NotTo(HaveOccurred())
+		mockHeaderPublisher.AssertWriteCalledWithBytes([][]byte{rawHeader})
+		mockBodyPublisher.AssertWriteCalledWithBodies([]*types.Body{block.Body()})
+		mockReceiptsPublisher.AssertWriteCalledWithInterfaces([]interface{}{fakeReceipts})
+		mockStateTriePublisher.AssertWriteCalledWithBytes([][]byte{{1, 2, 3}})
+		mockStorageTriePublisher.AssertWriteCalledWithBytes([][]byte{{4, 5, 6}})
+		mockDB.AssertGetStateTrieNodesCalledWith(test_helpers.FakeHash)
+	})
+
+	It("does not read data for types that are not selected", func() {
+		publishers := transformers.ExtractPublishers{Receipts: mockReceiptsPublisher}
+		transformer := transformers.NewEthExtractTransformer(mockDB, publishers, transformers.DefaultMissingDataPolicy, nil)
+
+		err := transformer.Execute(context.Background(), 0, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertGetBlockByBlockNumberCalledwith(nil)
+		mockDB.AssertGetBlockReceiptsCalledWith([]int64{0, 1})
+	})
+
+	It("skips blocks with missing data when the policy says to", func() {
+		mockDB.SetGetBlockByBlockNumberError(level.NewBlockDataError(0, level.BlockBody, level.ErrNotFound))
+		policy := transformers.NewMissingDataPolicy(transformers.SkipMissingData, 0, 0)
+		transformer := transformers.NewEthExtractTransformer(mockDB, allPublishers, policy, nil)
+
+		err := transformer.Execute(context.Background(), 0, 1)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockHeaderPublisher.AssertWriteCalledWithBlockNumbers(nil)
+	})
+
+	It("returns error if publishing fails", func() {
+		mockHeaderPublisher.SetError(test_helpers.FakeError)
+		transformer := transformers.NewEthExtractTransformer(mockDB, allPublishers, transformers.DefaultMissingDataPolicy, nil)
+
+		err := transformer.Execute(context.Background(), 0, 0)
+
+		Expect(err).To(MatchError(transformers.NewExecuteError(transformers.PutIpldErr, test_helpers.FakeError)))
+	})
+
+	It("does not start a block once the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		transformer := transformers.NewEthExtractTransformer(mockDB, allPublishers, transformers.DefaultMissingDataPolicy, nil)
+
+		err := transformer.Execute(ctx, 0, 1)
+
+		Expect(err).To(MatchError(context.Canceled))
+		mockDB.AssertGetBlockByBlockNumberCalledwith(nil)
+	})
+
+	It("publishes the block in flight whole when the context is cancelled", func() {
+		mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes([][]byte{{1, 2, 3}})
+		ctx, cancel := context.WithCancel(context.Background())
+		database := cancellingDatabase{MockDatabase: mockDB, cancel: cancel}
+		transformer := transformers.NewEthExtractTransformer(database, allPublishers, transformers.DefaultMissingDataPolicy, nil)
+
+		err := transformer.Execute(ctx, 1, 2)
+
+		Expect(err).To(MatchError(context.Canceled))
+		mockDB.AssertGetBlockByBlockNumberCalledwith([]int64{1})
+		mockHeaderPublisher.AssertWriteCalledWithBlockNumbers([]int64{1})
+		mockStateTriePublisher.AssertWriteCalledWithBlockNumbers([]int64{1})
+	})
+
+	It("stops waiting to retry missing data when the context is cancelled", func() {
+		mockDB.SetGetBlockByBlockNumberError(level.NewBlockDataError(1, level.BlockBody, level.ErrPruned))
+		ctx, cancel := context.WithCancel(context.Background())
+		database := cancellingDatabase{MockDatabase: mockDB, cancel: cancel}
+		policy := transformers.NewMissingDataPolicy(transformers.RetryMissingData, 1, time.Hour)
+		transformer := transformers.NewEthExtractTransformer(database, allPublishers, policy, nil)
+
+		err := transformer.Execute(ctx, 1, 1)
+
+		Expect(err).To(MatchError(context.Canceled))
+		mockDB.AssertGetBlockByBlockNumberCalledwith([]int64{1})
+	})
+
+	Describe("logging", func() {
+		var output bytes.Buffer
+
+		BeforeEach(func() {
+			output.Reset()
+			log.SetOutput(&output)
+			mockHeaderPublisher.SetReturnResults([][]eth_ipfs.Result{{{BlockNumber: 5, Size: 10}}, {{BlockNumber: 6, Size: 20}}})
+			mockBodyPublisher.SetReturnResults([][]eth_ipfs.Result{{{BlockNumber: 5, Size: 1}, {BlockNumber: 5, Size: 2}}, {}})
+		})
+
+		AfterEach(func() {
+			log.SetLevel(log.InfoLevel)
+		})
+
+		It("logs one summary record per block", func() {
+			publishers := transformers.ExtractPublishers{Header: mockHeaderPublisher, Body: mockBodyPublisher}
+			transformer := transformers.NewEthExtractTransformer(mockDB, publishers, transformers.DefaultMissingDataPolicy, nil)
+
+			err := transformer.Execute(context.Background(), 5, 6)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(output.String()).To(ContainSubstring(`msg="Published block" block=5 bytes=13`))
+			Expect(output.String()).To(ContainSubstring("nodes=3"))
+			Expect(output.String()).To(ContainSubstring(`msg="Published block" block=6 bytes=20`))
+			Expect(output.String()).NotTo(ContainSubstring("Created IPLD"))
+		})
+
+		It("logs each IPLD at debug level", func() {
+			log.SetLevel(log.DebugLevel)
+			publishers := transformers.ExtractPublishers{Header: mockHeaderPublisher, Body: mockBodyPublisher}
+			transformer := transformers.NewEthExtractTransformer(mockDB, publishers, transformers.DefaultMissingDataPolicy, nil)
+
+			err := transformer.Execute(context.Background(), 5, 5)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Count(output.String(), `msg="Created IPLD" block=5`)).To(Equal(3))
+		})
+	})
+
+	It("records each block in the block index", func() {
+		headerCid, err := util.RawToCid(cid.EthBlock, []byte{1})
+		Expect(err).NotTo(HaveOccurred())
+		transactionCid, err := util.RawToCid(cid.EthTx, []byte{2})
+		Expect(err).NotTo(HaveOccurred())
+		receiptCid, err := util.RawToCid(cid.EthTxReceipt, []byte{3})
+		Expect(err).NotTo(HaveOccurred())
+		uncleCid, err := util.RawToCid(cid.EthBlock, []byte{4})
+		Expect(err).NotTo(HaveOccurred())
+		block = block.WithBody(nil, []*types.Header{{}})
+		mockDB.SetGetBlockByBlockNumberReturnBlock(block)
+		mockHeaderPublisher.SetReturnResults([][]eth_ipfs.Result{{{Cid: headerCid}}, {{Cid: uncleCid}}})
+		mockBodyPublisher.SetReturnResults([][]eth_ipfs.Result{{{Cid: transactionCid}}})
+		mockReceiptsPublisher.SetReturnResults([][]eth_ipfs.Result{{{Cid: receiptCid}}})
+		publishers := transformers.ExtractPublishers{Header: mockHeaderPublisher, Body: mockBodyPublisher, Receipts: mockReceiptsPublisher}
+		var out bytes.Buffer
+		transformer := transformers.NewEthExtractTransformer(mockDB, publishers, transformers.DefaultMissingDataPolicy, nil).WithBlockIndex(transformers.NewBlockIndex(&out))
+
+		err = transformer.Execute(context.Background(), 5, 5)
+
+		Expect(err).NotTo(HaveOccurred())
+		var entry transformers.BlockIndexEntry
+		Expect(json.Unmarshal(out.Bytes(), &entry)).To(Succeed())
+		Expect(entry).To(Equal(transformers.BlockIndexEntry{
+			BlockNumber:     5,
+			Hash:            block.Hash(),
+			Canonical:       true,
+			HeaderCid:       headerCid.String(),
+			UncleCids:       []string{uncleCid.String()},
+			TransactionCids: []string{transactionCid.String()},
+			ReceiptCids:     []string{receiptCid.String()},
+		}))
+	})
+
+	It("does not index blocks whose header is not extracted", func() {
+		var out bytes.Buffer
+		transformer := transformers.NewEthExtractTransformer(mockDB, transformers.ExtractPublishers{Body: mockBodyPublisher}, transformers.DefaultMissingDataPolicy, nil).WithBlockIndex(transformers.NewBlockIndex(&out))
+
+		err := transformer.Execute(context.Background(), 5, 5)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(out.Len()).To(BeZero())
+	})
+
+	Describe("tracing", func() {
+		var recorder *spanRecorder
+
+		BeforeEach(func() {
+			recorder = &spanRecorder{}
+			opentracing.SetGlobalTracer(tracing.NewTracer("test", recorder))
+		})
+
+		AfterEach(func() {
+			opentracing.SetGlobalTracer(opentracing.NoopTracer{})
+		})
+
+		It("traces a span per block", func() {
+			mockHeaderPublisher.SetReturnResults([][]eth_ipfs.Result{{{BlockNumber: 5, Size: 10}}, {{BlockNumber: 6, Size: 20}}})
+			publishers := transformers.ExtractPublishers{Header: mockHeaderPublisher}
+			transformer := transformers.NewEthExtractTransformer(mockDB, publishers, transformers.DefaultMissingDataPolicy, nil)
+
+			err := transformer.Execute(context.Background(), 5, 6)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(recorder.spans).To(HaveLen(2))
+			Expect(recorder.spans[0].Name).To(Equal("block"))
+			Expect(recorder.spans[0].Tags).To(Equal(map[string]string{"block.number": "5", "nodes": "1", "bytes": "10"}))
+			Expect(recorder.spans[1].Tags).To(HaveKeyWithValue("block.number", "6"))
+			Expect(recorder.spans[0].TraceID).NotTo(Equal(recorder.spans[1].TraceID))
+		})
+
+		It("marks the span of a failed block as an error", func() {
+			mockHeaderPublisher.SetError(test_helpers.FakeError)
+			publishers := transformers.ExtractPublishers{Header: mockHeaderPublisher}
+			transformer := transformers.NewEthExtractTransformer(mockDB, publishers, transformers.DefaultMissingDataPolicy, nil)
+
+			err := transformer.Execute(context.Background(), 5, 6)
+
+			Expect(err).To(HaveOccurred())
+			Expect(recorder.spans).To(HaveLen(1))
+			Expect(recorder.spans[0].Tags).To(HaveKeyWithValue("error", "true"))
+			Expect(recorder.spans[0].Annotations).To(HaveLen(1))
+		})
+	})
+
+	It("reports progress through the range", func() {
+		var output bytes.Buffer
+		log.SetOutput(&output)
+		progress := transformers.NewProgressTracker(5, 6, time.Hour)
+		transformer := transformers.NewEthExtractTransformer(mockDB, transformers.ExtractPublishers{Header: mockHeaderPublisher}, transformers.DefaultMissingDataPolicy, progress)
+
+		err := transformer.Execute(context.Background(), 5, 6)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.String()).NotTo(ContainSubstring(`msg="Extraction progress" block=5`))
+		Expect(output.String()).To(ContainSubstring(`msg="Extraction progress" block=6`))
+		Expect(output.String()).To(ContainSubstring("done=2 percent=100 total=2"))
+	})
+
+	It("reports each block done to a progress callback", func() {
+		var reported [][3]int64
+		progress := transformers.NewProgressTracker(5, 6, time.Hour).OnBlockDone(func(blockNumber, done, total int64) {
+			reported = append(reported, [3]int64{blockNumber, done, total})
+		})
+		transformer := transformers.NewEthExtractTransformer(mockDB, transformers.ExtractPublishers{Header: mockHeaderPublisher}, transformers.DefaultMissingDataPolicy, progress)
+
+		err := transformer.Execute(context.Background(), 5, 6)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reported).To(Equal([][3]int64{{5, 1, 2}, {6, 2, 2}}))
+	})
+})
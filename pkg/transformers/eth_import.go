@@ -0,0 +1,82 @@
+// Copyright © 2018 Rob Mulholand <rmulholand@8thlight.com>
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package transformers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+)
+
+// BlockSource returns the canonical block at a height with its receipts, as
+// published to IPFS
+type BlockSource interface {
+	GetBlock(ctx context.Context, blockNumber int64) (*types.Block, types.Receipts, error)
+}
+
+// ChainWriter writes a block and its receipts into a node's chaindata, after
+// its parent
+type ChainWriter interface {
+	WriteBlock(block *types.Block, receipts types.Receipts) error
+}
+
+// EthImportTransformer reads a range of blocks back out of IPFS and writes
+// them into chaindata in order, so that a node can start from the blocks
+// archived in IPFS
+type EthImportTransformer struct {
+	source   BlockSource
+	writer   ChainWriter
+	progress *ProgressTracker
+}
+
+func NewEthImportTransformer(source BlockSource, writer ChainWriter, progress *ProgressTracker) *EthImportTransformer {
+	return &EthImportTransformer{source: source, writer: writer, progress: progress}
+}
+
+func (t EthImportTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
+	if endingBlockNumber < startingBlockNumber {
+		return ErrInvalidRange
+	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
+	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		start := time.Now()
+		block, receipts, err := t.source.GetBlock(rangeCtx, i)
+		if err != nil {
+			return fmt.Errorf("Error reading block %d from IPFS: %s", i, err)
+		}
+		err = t.writer.WriteBlock(block, receipts)
+		if err != nil {
+			return fmt.Errorf("Error writing block %d: %s", i, err)
+		}
+		log.WithFields(log.Fields{
+			"block":        i,
+			"hash":         block.Hash().Hex(),
+			"transactions": len(block.Transactions()),
+			"uncles":       len(block.Uncles()),
+			"duration":     time.Since(start).String(),
+		}).Info("Imported block")
+		if t.progress != nil {
+			t.progress.blockDone(i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package transformers_test
+
+import (
+	"context"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	transformer_mocks "github.com/vulcanize/eth-block-extractor/test_helpers/mocks/transformers"
+)
+
+var _ = Describe("Eth import transformer", func() {
+	var (
+		source      *transformer_mocks.MockBlockSource
+		writer      *transformer_mocks.MockChainWriter
+		transformer *transformers.EthImportTransformer
+		blocks      []*types.Block
+		receipts    []types.Receipts
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+		source = transformer_mocks.NewMockBlockSource()
+		writer = transformer_mocks.NewMockChainWriter()
+		transformer = transformers.NewEthImportTransformer(source, writer, nil)
+		blocks, receipts = nil, nil
+		for i := int64(1); i <= 3; i++ {
+			block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(i)})
+			blockReceipts := types.Receipts{types.NewReceipt(nil, false, uint64(i))}
+			source.SetBlock(block, blockReceipts)
+			blocks = append(blocks, block)
+			receipts = append(receipts, blockReceipts)
+		}
+	})
+
+	It("returns an error if ending block number is less than starting block number", func() {
+		err := transformer.Execute(context.Background(), 1, 0)
+
+		Expect(err).To(MatchError(transformers.ErrInvalidRange))
+	})
+
+	It("writes each block in the range with its receipts, in order", func() {
+		err := transformer.Execute(context.Background(), 1, 3)
+
+		Expect(err).NotTo(HaveOccurred())
+		writer.AssertWriteBlockCalledWith(blocks, receipts)
+	})
+
+	It("returns an error when a block cannot be read from IPFS", func() {
+		source.SetError(test_helpers.FakeError)
+
+		err := transformer.Execute(context.Background(), 1, 3)
+
+		Expect(err).To(MatchError("Error reading block 1 from IPFS: " + test_helpers.FakeError.Error()))
+		writer.AssertWriteBlockCalledWith(nil, nil)
+	})
+
+	It("returns an error when a block cannot be written", func() {
+		writer.SetError(test_helpers.FakeError)
+
+		err := transformer.Execute(context.Background(), 1, 3)
+
+		Expect(err).To(MatchError("Error writing block 1: " + test_helpers.FakeError.Error()))
+	})
+
+	It("stops before the next block once cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := transformer.Execute(ctx, 1, 3)
+
+		Expect(err).To(Equal(context.Canceled))
+		writer.AssertWriteBlockCalledWith(nil, nil)
+	})
+})
@@ -0,0 +1,83 @@
+package transformers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// EthProofTransformer proves an account and some of its storage slots at a
+// block's state root, and publishes the proof nodes as state and storage trie
+// IPLDs. The block's state must be stored (e.g. on an archive node).
+type EthProofTransformer struct {
+	database             db.Database
+	policy               MissingDataPolicy
+	stateTriePublisher   ipfs.StateTrieNodePublisher
+	storageTriePublisher ipfs.StorageTrieNodePublisher
+}
+
+func NewEthProofTransformer(database db.Database, stateTriePublisher ipfs.StateTrieNodePublisher, storageTriePublisher ipfs.StorageTrieNodePublisher, policy MissingDataPolicy) *EthProofTransformer {
+	return &EthProofTransformer{
+		database:             database,
+		policy:               policy,
+		stateTriePublisher:   stateTriePublisher,
+		storageTriePublisher: storageTriePublisher,
+	}
+}
+
+func (t EthProofTransformer) Execute(ctx context.Context, blockNumber int64, address common.Address, storageKeys []common.Hash) (level.AccountProof, error) {
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
+	summary, blockCtx := newBlockSummary(rangeCtx, blockNumber)
+	var header *types.Header
+	err := t.policy.fetchRequired(ctx, blockNumber, func() (err error) {
+		header, err = t.database.GetBlockHeaderByBlockNumber(blockCtx, blockNumber)
+		return err
+	})
+	if err != nil {
+		return level.AccountProof{}, summary.fail(err)
+	}
+	proof, err := t.database.ProveAccount(blockCtx, header.Root, address, storageKeys)
+	if err != nil {
+		return level.AccountProof{}, summary.fail(fmt.Errorf("Error proving account at block %d: %s", blockNumber, err))
+	}
+	err = t.writeProofToIpfs(blockCtx, blockNumber, proof, summary)
+	if err != nil {
+		return level.AccountProof{}, summary.fail(err)
+	}
+	summary.done()
+	return proof, nil
+}
+
+// writeProofToIpfs publishes each proof node once, since storage proofs share
+// the nodes near their root
+func (t EthProofTransformer) writeProofToIpfs(ctx context.Context, blockNumber int64, proof level.AccountProof, summary *blockSummary) error {
+	for _, node := range proof.AccountProof {
+		output, err := t.stateTriePublisher.WriteStateTrieNode(ctx, blockNumber, node)
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(output)
+	}
+	published := make(map[string]bool)
+	for _, storageProof := range proof.StorageProof {
+		for _, node := range storageProof.Proof {
+			if published[hexutil.Encode(node)] {
+				continue
+			}
+			published[hexutil.Encode(node)] = true
+			output, err := t.storageTriePublisher.WriteStorageTrieNode(ctx, blockNumber, node)
+			if err != nil {
+				return NewExecuteError(PutIpldErr, err)
+			}
+			summary.add(output)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package transformers_test
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Eth proof transformer", func() {
+	var (
+		address     = common.HexToAddress("0xabc")
+		storageKeys = []common.Hash{common.HexToHash("0x1"), common.HexToHash("0x2")}
+		fakeProof   = level.AccountProof{
+			Address:      address,
+			AccountProof: []hexutil.Bytes{{1, 1}, {2, 2}},
+			StorageProof: []level.StorageProof{
+				{Key: storageKeys[0], Proof: []hexutil.Bytes{{3, 3}, {4, 4}}},
+				{Key: storageKeys[1], Proof: []hexutil.Bytes{{3, 3}, {5, 5}}},
+			},
+		}
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+	})
+
+	newMockDB := func() *db.MockDatabase {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: test_helpers.FakeHash})
+		mockDB.SetProveAccountReturnProof(fakeProof)
+		return mockDB
+	}
+
+	It("proves the account at the block's state root", func() {
+		mockDB := newMockDB()
+		transformer := transformers.NewEthProofTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		proof, err := transformer.Execute(context.Background(), 123, address, storageKeys)
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proof).To(Equal(fakeProof))
+		mockDB.AssertGetBlockHeaderByBlockNumberCalledWith([]int64{123})
+		mockDB.AssertProveAccountCalledWith(test_helpers.FakeHash, address, storageKeys)
+	})
+
+	It("returns error if fetching header fails", func() {
+		mockDB := newMockDB()
+		mockDB.SetGetBlockHeaderByBlockNumberError(test_helpers.FakeError)
+		transformer := transformers.NewEthProofTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		_, err := transformer.Execute(context.Background(), 123, address, storageKeys)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("returns error if proving fails", func() {
+		mockDB := newMockDB()
+		mockDB.SetProveAccountError(test_helpers.FakeError)
+		transformer := transformers.NewEthProofTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		_, err := transformer.Execute(context.Background(), 123, address, storageKeys)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+	})
+
+	It("publishes account proof nodes as state trie nodes", func() {
+		stateTriePublisher := ipfs.NewMockPublisher()
+		transformer := transformers.NewEthProofTransformer(newMockDB(), stateTriePublisher, ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		_, err := transformer.Execute(context.Background(), 123, address, storageKeys)
+
+		Expect(err).NotTo(HaveOccurred())
+		stateTriePublisher.AssertWriteCalledWithBytes([][]byte{{1, 1}, {2, 2}})
+	})
+
+	It("publishes each storage proof node once as a storage trie node", func() {
+		storageTriePublisher := ipfs.NewMockPublisher()
+		transformer := transformers.NewEthProofTransformer(newMockDB(), ipfs.NewMockPublisher(), storageTriePublisher, transformers.DefaultMissingDataPolicy)
+
+		_, err := transformer.Execute(context.Background(), 123, address, storageKeys)
+
+		Expect(err).NotTo(HaveOccurred())
+		storageTriePublisher.AssertWriteCalledWithBytes([][]byte{{3, 3}, {4, 4}, {5, 5}})
+		storageTriePublisher.AssertWriteCalledWithBlockNumbers([]int64{123, 123, 123})
+	})
+
+	It("returns error if publishing fails", func() {
+		stateTriePublisher := ipfs.NewMockPublisher()
+		stateTriePublisher.SetError(test_helpers.FakeError)
+		transformer := transformers.NewEthProofTransformer(newMockDB(), stateTriePublisher, ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy)
+
+		_, err := transformer.Execute(context.Background(), 123, address, storageKeys)
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,75 @@
+package transformers
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+)
+
+// EthStateDiffTransformer exports the state changes made by each block in a
+// range by diffing the state at consecutive header roots, which requires the
+// state for those blocks to be stored (e.g. on an archive node)
+type EthStateDiffTransformer struct {
+	database db.Database
+	exporter *StateDiffExporter
+	policy   MissingDataPolicy
+}
+
+func NewEthStateDiffTransformer(database db.Database, exporter *StateDiffExporter, policy MissingDataPolicy) *EthStateDiffTransformer {
+	return &EthStateDiffTransformer{
+		database: database,
+		exporter: exporter,
+		policy:   policy,
+	}
+}
+
+func (t EthStateDiffTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
+	if endingBlockNumber < startingBlockNumber {
+		return ErrInvalidRange
+	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
+	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, blockCtx := newBlockSummary(rangeCtx, i)
+		var header *types.Header
+		var parentRoot common.Hash
+		skip, err := t.policy.fetch(ctx, i, func() (err error) {
+			header, parentRoot, err = t.getHeaderAndParentRoot(blockCtx, i)
+			return err
+		})
+		if err != nil {
+			return summary.fail(err)
+		}
+		if skip {
+			summary.skipped()
+			continue
+		}
+		err = t.exporter.export(blockCtx, t.database, i, header.Hash(), parentRoot, header.Root, summary)
+		if err != nil {
+			return summary.fail(err)
+		}
+		summary.done()
+	}
+	return nil
+}
+
+// the genesis block's state is diffed against the empty trie
+func (t EthStateDiffTransformer) getHeaderAndParentRoot(ctx context.Context, blockNumber int64) (*types.Header, common.Hash, error) {
+	header, err := t.database.GetBlockHeaderByBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	if blockNumber == GenesisBlockNumber {
+		return header, db.EmptyTrieRoot, nil
+	}
+	parentHeader, err := t.database.GetBlockHeaderByBlockNumber(ctx, blockNumber-1)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	return header, parentHeader.Root, nil
+}
@@ -0,0 +1,139 @@
+package transformers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
+
+	eth_db "github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/ipfs"
+)
+
+var _ = Describe("Eth state diff transformer", func() {
+	var (
+		fakeHeader = &types.Header{Root: test_helpers.FakeHash}
+		fakeDiffs  = []level.AccountDiff{{AddressHash: common.HexToHash("0xabc")}}
+	)
+
+	BeforeEach(func() {
+		log.SetOutput(ioutil.Discard)
+	})
+
+	It("returns error if ending block number is less than starting block number", func() {
+		transformer := transformers.NewEthStateDiffTransformer(db.NewMockDatabase(), transformers.NewStateDiffExporter(nil, nil), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 0)
+
+		Expect(err).To(MatchError(transformers.ErrInvalidRange))
+	})
+
+	It("diffs each block's state root against its parent's", func() {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(fakeHeader)
+		transformer := transformers.NewEthStateDiffTransformer(mockDB, transformers.NewStateDiffExporter(nil, nil), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertGetBlockHeaderByBlockNumberCalledWith([]int64{1, 0, 2, 1})
+		mockDB.AssertDiffStateTriesCalledWith([][2]common.Hash{
+			{test_helpers.FakeHash, test_helpers.FakeHash},
+			{test_helpers.FakeHash, test_helpers.FakeHash},
+		})
+	})
+
+	It("diffs genesis state against the empty trie", func() {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(fakeHeader)
+		transformer := transformers.NewEthStateDiffTransformer(mockDB, transformers.NewStateDiffExporter(nil, nil), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 0, 0)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertDiffStateTriesCalledWith([][2]common.Hash{{eth_db.EmptyTrieRoot, test_helpers.FakeHash}})
+	})
+
+	It("writes each block's state diff as a line of JSON", func() {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(fakeHeader)
+		mockDB.SetDiffStateTriesReturnDiffs(fakeDiffs)
+		output := &bytes.Buffer{}
+		transformer := transformers.NewEthStateDiffTransformer(mockDB, transformers.NewStateDiffExporter(output, nil), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		lines := bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n"))
+		Expect(len(lines)).To(Equal(2))
+		var diff eth_db.StateDiff
+		Expect(json.Unmarshal(lines[1], &diff)).To(Succeed())
+		Expect(diff).To(Equal(eth_db.StateDiff{
+			BlockNumber:     2,
+			BlockHash:       fakeHeader.Hash(),
+			ParentStateRoot: test_helpers.FakeHash,
+			StateRoot:       test_helpers.FakeHash,
+			Accounts:        fakeDiffs,
+		}))
+	})
+
+	It("publishes each block's state diff", func() {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(fakeHeader)
+		mockDB.SetDiffStateTriesReturnDiffs(fakeDiffs)
+		publisher := ipfs.NewMockPublisher()
+		transformer := transformers.NewEthStateDiffTransformer(mockDB, transformers.NewStateDiffExporter(nil, publisher), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		publisher.AssertWriteCalledWithBlockNumbers([]int64{1, 2})
+	})
+
+	It("returns error if publishing fails", func() {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(fakeHeader)
+		publisher := ipfs.NewMockPublisher()
+		publisher.SetError(test_helpers.FakeError)
+		transformer := transformers.NewEthStateDiffTransformer(mockDB, transformers.NewStateDiffExporter(nil, publisher), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+	})
+
+	It("returns error if diffing state fails", func() {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(fakeHeader)
+		mockDB.SetDiffStateTriesError(test_helpers.FakeError)
+		transformer := transformers.NewEthStateDiffTransformer(mockDB, transformers.NewStateDiffExporter(nil, nil), transformers.DefaultMissingDataPolicy)
+
+		err := transformer.Execute(context.Background(), 1, 1)
+
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+	})
+
+	It("skips blocks with missing headers when policy allows", func() {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberError(level.NewBlockDataError(1, level.BlockHeader, level.ErrPruned))
+		policy := transformers.NewMissingDataPolicy(transformers.SkipMissingData, 0, 0)
+		transformer := transformers.NewEthStateDiffTransformer(mockDB, transformers.NewStateDiffExporter(nil, nil), policy)
+
+		err := transformer.Execute(context.Background(), 1, 2)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertDiffStateTriesCalledWith(nil)
+	})
+})
@@ -1,82 +1,145 @@
 package transformers
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
 	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
 )
 
 type EthStateTrieTransformer struct {
 	database             db.Database
-	stateTriePublisher   ipfs.Publisher
-	storageTriePublisher ipfs.Publisher
+	manifest             *ShardManifest
+	policy               MissingDataPolicy
+	selection            *AccountSelection
+	stateTriePublisher   ipfs.StateTrieNodePublisher
+	storageIndex         *StorageIndex
+	storageTriePublisher ipfs.StorageTrieNodePublisher
 }
 
-func NewEthStateTrieTransformer(database db.Database, stateTriePublisher, storageTriePublisher ipfs.Publisher) *EthStateTrieTransformer {
+func NewEthStateTrieTransformer(database db.Database, stateTriePublisher ipfs.StateTrieNodePublisher, storageTriePublisher ipfs.StorageTrieNodePublisher, policy MissingDataPolicy, selection *AccountSelection, manifest *ShardManifest, storageIndex *StorageIndex) *EthStateTrieTransformer {
 	return &EthStateTrieTransformer{
 		database:             database,
+		manifest:             manifest,
+		policy:               policy,
+		selection:            selection,
 		stateTriePublisher:   stateTriePublisher,
+		storageIndex:         storageIndex,
 		storageTriePublisher: storageTriePublisher,
 	}
 }
 
-func (t EthStateTrieTransformer) Execute(startingBlockNumber int64, endingBlockNumber int64) error {
+func (t EthStateTrieTransformer) Execute(ctx context.Context, startingBlockNumber int64, endingBlockNumber int64) error {
 	if endingBlockNumber < startingBlockNumber {
 		return ErrInvalidRange
 	}
+	rangeCtx, cancel := blockContext(ctx)
+	defer cancel()
 	for i := startingBlockNumber; i <= endingBlockNumber; i++ {
-		root, err := t.getStateRootForBlock(i)
-		if err != nil {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		summary, blockCtx := newBlockSummary(rangeCtx, i)
+		var root common.Hash
+		skip, err := t.policy.fetch(ctx, i, func() (err error) {
+			root, err = t.getStateRootForBlock(blockCtx, i)
 			return err
+		})
+		if err != nil {
+			return summary.fail(err)
+		}
+		if skip {
+			summary.skipped()
+			continue
 		}
 
-		stateTrieNodes, storageTrieNodes, err := t.database.GetStateAndStorageTrieNodes(root)
+		stateTrieNodes, storageTrieNodes, err := t.getTrieNodes(blockCtx, i, root, summary)
 		if err != nil {
-			return fmt.Errorf("Error fetching state trie for block %d: %s\n", i, err)
+			return summary.fail(err)
 		}
 
-		err = t.writeStateTrieNodesToIpfs(stateTrieNodes)
+		stateTrieOutputs, err := t.writeStateTrieNodesToIpfs(blockCtx, i, stateTrieNodes)
 		if err != nil {
-			return err
+			return summary.fail(err)
 		}
+		summary.add(stateTrieOutputs...)
 
-		err = t.writeStorageTrieNodesToIpfs(storageTrieNodes)
+		storageTrieOutputs, err := t.writeStorageTrieNodesToIpfs(blockCtx, i, storageTrieNodes)
 		if err != nil {
-			return err
+			return summary.fail(err)
+		}
+		summary.add(storageTrieOutputs...)
+
+		err = t.recordShard(i, root, stateTrieOutputs, storageTrieOutputs)
+		if err != nil {
+			return summary.fail(err)
 		}
+		summary.done()
 	}
 	return nil
 }
 
-func (t EthStateTrieTransformer) getStateRootForBlock(blockNumber int64) (root common.Hash, err error) {
-	header := t.database.GetBlockHeaderByBlockNumber(blockNumber)
+func (t EthStateTrieTransformer) getStateRootForBlock(ctx context.Context, blockNumber int64) (root common.Hash, err error) {
+	header, err := t.database.GetBlockHeaderByBlockNumber(ctx, blockNumber)
 	if err != nil {
 		return root, err
 	}
 	return header.Root, nil
 }
 
-func (t EthStateTrieTransformer) writeStateTrieNodesToIpfs(stateTrieNodes [][]byte) error {
+// getTrieNodes fetches the whole state unless an account selection was given
+func (t EthStateTrieTransformer) getTrieNodes(ctx context.Context, blockNumber int64, root common.Hash, summary *blockSummary) (stateTrieNodes [][]byte, storageTrieNodes []level.StorageTrieNode, err error) {
+	if t.selection != nil {
+		return t.selection.getTrieNodes(ctx, t.database, blockNumber, root, summary)
+	}
+	start := time.Now()
+	stateTrieNodes, storageTrieNodes, err = t.database.GetStateAndStorageTrieNodes(ctx, root)
+	metrics.DatabaseReadSeconds.ObserveSince(start)
+	if err != nil {
+		metrics.Errors.With(metrics.DatabaseReadError).Inc()
+		return nil, nil, fmt.Errorf("Error fetching state trie for block %d: %s\n", blockNumber, err)
+	}
+	return stateTrieNodes, storageTrieNodes, nil
+}
+
+func (t EthStateTrieTransformer) recordShard(blockNumber int64, root common.Hash, stateTrieOutputs, storageTrieOutputs []ipfs.Result) error {
+	if t.manifest == nil {
+		return nil
+	}
+	return t.manifest.record(blockNumber, root, stateTrieOutputs, storageTrieOutputs)
+}
+
+func (t EthStateTrieTransformer) writeStateTrieNodesToIpfs(ctx context.Context, blockNumber int64, stateTrieNodes [][]byte) ([]ipfs.Result, error) {
+	outputs := make([]ipfs.Result, 0, len(stateTrieNodes))
 	for _, node := range stateTrieNodes {
-		output, err := t.stateTriePublisher.Write(node)
+		output, err := t.stateTriePublisher.WriteStateTrieNode(ctx, blockNumber, node)
 		if err != nil {
-			return fmt.Errorf("Error writing state trie node to ipfs: %s\n", err.Error())
+			return nil, fmt.Errorf("Error writing state trie node to ipfs: %s\n", err.Error())
 		}
-		log.Println("Created ipld: ", output)
+		outputs = append(outputs, output)
 	}
-	return nil
+	return outputs, nil
 }
 
-func (t EthStateTrieTransformer) writeStorageTrieNodesToIpfs(storageTrieNodes [][]byte) error {
+func (t EthStateTrieTransformer) writeStorageTrieNodesToIpfs(ctx context.Context, blockNumber int64, storageTrieNodes []level.StorageTrieNode) ([]ipfs.Result, error) {
+	outputs := make([]ipfs.Result, 0, len(storageTrieNodes))
 	for _, node := range storageTrieNodes {
-		output, err := t.storageTriePublisher.Write(node)
+		output, err := t.storageTriePublisher.WriteStorageTrieNode(ctx, blockNumber, node.Node)
 		if err != nil {
-			return fmt.Errorf("Error writing storage trie node to ipfs: %s\n", err.Error())
+			return nil, fmt.Errorf("Error writing storage trie node to ipfs: %s\n", err.Error())
 		}
-		log.Println("Created ipld: ", output)
+		outputs = append(outputs, output)
 	}
-	return nil
+	if t.storageIndex != nil {
+		err := t.storageIndex.record(blockNumber, storageTrieNodes, outputs)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
 }
@@ -1,13 +1,22 @@
 package transformers_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"io/ioutil"
-	"log"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	log "github.com/sirupsen/logrus"
 
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	eth_ipfs "github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_state_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/eth_storage_trie"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs/util"
 	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
 	"github.com/vulcanize/eth-block-extractor/test_helpers"
 	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
@@ -21,9 +30,9 @@ var _ = Describe("Eth state trie transformer", func() {
 	})
 
 	It("returns error if ending block number is less than starting block number", func() {
-		transformer := transformers.NewEthStateTrieTransformer(db.NewMockDatabase(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher())
+		transformer := transformers.NewEthStateTrieTransformer(db.NewMockDatabase(), ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, nil, nil, nil)
 
-		err := transformer.Execute(1, 0)
+		err := transformer.Execute(context.Background(), 1, 0)
 
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(transformers.ErrInvalidRange))
@@ -32,9 +41,9 @@ var _ = Describe("Eth state trie transformer", func() {
 	It("fetches block header for block", func() {
 		mockDB := db.NewMockDatabase()
 		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
-		transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher())
+		transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, nil, nil, nil)
 
-		err := transformer.Execute(0, 0)
+		err := transformer.Execute(context.Background(), 0, 0)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockDB.AssertGetBlockHeaderByBlockNumberCalledWith([]int64{0})
@@ -43,9 +52,9 @@ var _ = Describe("Eth state trie transformer", func() {
 	It("fetches state and storage trie nodes with state root from decoded block header", func() {
 		mockDB := db.NewMockDatabase()
 		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: test_helpers.FakeHash})
-		transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher())
+		transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, nil, nil, nil)
 
-		err := transformer.Execute(0, 0)
+		err := transformer.Execute(context.Background(), 0, 0)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockDB.AssertGetStateTrieNodesCalledWith(test_helpers.FakeHash)
@@ -55,9 +64,9 @@ var _ = Describe("Eth state trie transformer", func() {
 		mockDB := db.NewMockDatabase()
 		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
 		mockDB.SetGetStateAndStorageTrieNodesError(test_helpers.FakeError)
-		transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher())
+		transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, nil, nil, nil)
 
-		err := transformer.Execute(0, 0)
+		err := transformer.Execute(context.Background(), 0, 0)
 
 		Expect(err).To(HaveOccurred())
 		Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
@@ -71,10 +80,9 @@ var _ = Describe("Eth state trie transformer", func() {
 		mockDecoder := rlp.NewMockDecoder()
 		mockDecoder.SetReturnOut(&types.Header{})
 		mockStateTriePublisher := ipfs.NewMockPublisher()
-		mockStateTriePublisher.SetReturnStrings([][]string{{"one"}, {"two"}})
-		transformer := transformers.NewEthStateTrieTransformer(mockDB, mockStateTriePublisher, ipfs.NewMockPublisher())
+		transformer := transformers.NewEthStateTrieTransformer(mockDB, mockStateTriePublisher, ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, nil, nil, nil)
 
-		err := transformer.Execute(0, 0)
+		err := transformer.Execute(context.Background(), 0, 0)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockStateTriePublisher.AssertWriteCalledWithBytes(fakeStateTrieNodes)
@@ -84,16 +92,155 @@ var _ = Describe("Eth state trie transformer", func() {
 		mockDB := db.NewMockDatabase()
 		mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
 		fakeStateTrieNodes := [][]byte{{1, 2, 3, 4, 5}, {6, 7, 8, 9, 0}}
-		mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieBytes(fakeStateTrieNodes)
+		mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieNodes(storageTrieNodes(fakeStateTrieNodes))
 		mockDecoder := rlp.NewMockDecoder()
 		mockDecoder.SetReturnOut(&types.Header{})
 		mockStorageTriePublisher := ipfs.NewMockPublisher()
-		mockStorageTriePublisher.SetReturnStrings([][]string{{"one"}, {"two"}})
-		transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), mockStorageTriePublisher)
+		transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), mockStorageTriePublisher, transformers.DefaultMissingDataPolicy, nil, nil, nil)
 
-		err := transformer.Execute(0, 0)
+		err := transformer.Execute(context.Background(), 0, 0)
 
 		Expect(err).NotTo(HaveOccurred())
 		mockStorageTriePublisher.AssertWriteCalledWithBytes(fakeStateTrieNodes)
 	})
+
+	Describe("with an account selection", func() {
+		var addresses = []common.Address{common.HexToAddress("0xabc")}
+
+		It("fetches only the selected accounts' trie nodes", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: test_helpers.FakeHash})
+			selection := transformers.NewAccountSelection(addresses, ipfs.NewMockPublisher())
+			transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, selection, nil, nil)
+
+			err := transformer.Execute(context.Background(), 0, 0)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockDB.AssertGetAccountTrieNodesCalledWith(test_helpers.FakeHash, addresses)
+		})
+
+		It("writes the selected accounts' state and storage trie nodes and code to ipfs", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			fakeStateTrieNodes := [][]byte{{1, 2, 3}}
+			fakeStorageTrieNodes := [][]byte{{4, 5, 6}}
+			fakeCodes := [][]byte{{7, 8, 9}}
+			mockDB.SetGetAccountTrieNodesReturnStateTrieBytes(fakeStateTrieNodes)
+			mockDB.SetGetAccountTrieNodesReturnStorageTrieNodes(storageTrieNodes(fakeStorageTrieNodes))
+			mockDB.SetGetAccountTrieNodesReturnCodes(fakeCodes)
+			mockStateTriePublisher := ipfs.NewMockPublisher()
+			mockStorageTriePublisher := ipfs.NewMockPublisher()
+			mockCodePublisher := ipfs.NewMockPublisher()
+			selection := transformers.NewAccountSelection(addresses, mockCodePublisher)
+			transformer := transformers.NewEthStateTrieTransformer(mockDB, mockStateTriePublisher, mockStorageTriePublisher, transformers.DefaultMissingDataPolicy, selection, nil, nil)
+
+			err := transformer.Execute(context.Background(), 0, 0)
+
+			Expect(err).NotTo(HaveOccurred())
+			mockStateTriePublisher.AssertWriteCalledWithBytes(fakeStateTrieNodes)
+			mockStorageTriePublisher.AssertWriteCalledWithBytes(fakeStorageTrieNodes)
+			mockCodePublisher.AssertWriteCalledWithBytes(fakeCodes)
+		})
+
+		It("returns err if fetching the selected accounts returns err", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			mockDB.SetGetAccountTrieNodesError(test_helpers.FakeError)
+			selection := transformers.NewAccountSelection(addresses, ipfs.NewMockPublisher())
+			transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, selection, nil, nil)
+
+			err := transformer.Execute(context.Background(), 0, 0)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+		})
+
+		It("returns err if writing code returns err", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			mockDB.SetGetAccountTrieNodesReturnCodes([][]byte{{7, 8, 9}})
+			mockCodePublisher := ipfs.NewMockPublisher()
+			mockCodePublisher.SetError(test_helpers.FakeError)
+			selection := transformers.NewAccountSelection(addresses, mockCodePublisher)
+			transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), ipfs.NewMockPublisher(), transformers.DefaultMissingDataPolicy, selection, nil, nil)
+
+			err := transformer.Execute(context.Background(), 0, 0)
+
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(test_helpers.FakeError.Error()))
+		})
+	})
+
+	Describe("with a shard manifest", func() {
+		It("writes the range and the CIDs of the nodes published for each block", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{Root: test_helpers.FakeHash})
+			mockDB.SetGetStateAndStorageTrieNodesReturnStateTrieBytes([][]byte{{1, 2, 3}})
+			mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieNodes(storageTrieNodes([][]byte{{4, 5, 6}}))
+			stateTrieNodeCid, err := util.RawToCid(eth_state_trie.EthStateTrieNodeCode, []byte{1, 2, 3})
+			Expect(err).NotTo(HaveOccurred())
+			storageTrieNodeCid, err := util.RawToCid(eth_storage_trie.EthStorageTrieNodeCode, []byte{4, 5, 6})
+			Expect(err).NotTo(HaveOccurred())
+			mockStateTriePublisher := ipfs.NewMockPublisher()
+			mockStateTriePublisher.SetReturnResults([][]eth_ipfs.Result{{{Cid: stateTrieNodeCid}}})
+			mockStorageTriePublisher := ipfs.NewMockPublisher()
+			mockStorageTriePublisher.SetReturnResults([][]eth_ipfs.Result{{{Cid: storageTrieNodeCid}}})
+			keySpace, err := level.NewPrefixRange("0x3")
+			Expect(err).NotTo(HaveOccurred())
+			var out bytes.Buffer
+			manifest := transformers.NewShardManifest(&out, keySpace)
+			transformer := transformers.NewEthStateTrieTransformer(mockDB, mockStateTriePublisher, mockStorageTriePublisher, transformers.DefaultMissingDataPolicy, nil, manifest, nil)
+
+			err = transformer.Execute(context.Background(), 0, 0)
+
+			Expect(err).NotTo(HaveOccurred())
+			var entry transformers.ShardManifestEntry
+			Expect(json.Unmarshal(out.Bytes(), &entry)).To(Succeed())
+			Expect(entry.BlockNumber).To(BeZero())
+			Expect(entry.StateRoot).To(Equal(test_helpers.FakeHash))
+			Expect(uint64(entry.KeySpaceStart)).To(Equal(uint64(0x3000)))
+			Expect(uint64(entry.KeySpaceEnd)).To(Equal(uint64(0x4000)))
+			Expect(entry.StateTrieNodes).To(Equal([]string{stateTrieNodeCid.String()}))
+			Expect(entry.StorageTrieNodes).To(Equal([]string{storageTrieNodeCid.String()}))
+		})
+	})
+
+	Describe("with a storage index", func() {
+		It("writes the account, slot key, path and CID of each published storage trie node", func() {
+			mockDB := db.NewMockDatabase()
+			mockDB.SetGetBlockHeaderByBlockNumberReturnHeader(&types.Header{})
+			address := common.HexToAddress("0xabc")
+			key := common.HexToHash("0x1")
+			node := level.StorageTrieNode{AddressHash: test_helpers.FakeHash, Address: &address, Key: &key, Path: []byte{1, 2}, Node: []byte{4, 5, 6}}
+			mockDB.SetGetStateAndStorageTrieNodesReturnStorageTrieNodes([]level.StorageTrieNode{node})
+			storageTrieNodeCid, err := util.RawToCid(eth_storage_trie.EthStorageTrieNodeCode, node.Node)
+			Expect(err).NotTo(HaveOccurred())
+			mockStorageTriePublisher := ipfs.NewMockPublisher()
+			mockStorageTriePublisher.SetReturnResults([][]eth_ipfs.Result{{{Cid: storageTrieNodeCid}}})
+			var out bytes.Buffer
+			transformer := transformers.NewEthStateTrieTransformer(mockDB, ipfs.NewMockPublisher(), mockStorageTriePublisher, transformers.DefaultMissingDataPolicy, nil, nil, transformers.NewStorageIndex(&out))
+
+			err = transformer.Execute(context.Background(), 0, 0)
+
+			Expect(err).NotTo(HaveOccurred())
+			var entry transformers.StorageIndexEntry
+			Expect(json.Unmarshal(out.Bytes(), &entry)).To(Succeed())
+			Expect(entry).To(Equal(transformers.StorageIndexEntry{
+				BlockNumber: 0,
+				AddressHash: test_helpers.FakeHash,
+				Address:     &address,
+				Key:         &key,
+				Path:        []byte{1, 2},
+				Cid:         storageTrieNodeCid.String(),
+			}))
+		})
+	})
 })
+
+func storageTrieNodes(nodes [][]byte) []level.StorageTrieNode {
+	var storageTrieNodes []level.StorageTrieNode
+	for _, node := range nodes {
+		storageTrieNodes = append(storageTrieNodes, level.StorageTrieNode{AddressHash: test_helpers.FakeHash, Node: node})
+	}
+	return storageTrieNodes
+}
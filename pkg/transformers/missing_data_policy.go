@@ -0,0 +1,88 @@
+package transformers
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/metrics"
+)
+
+type MissingDataAction int
+
+const (
+	FailOnMissingData MissingDataAction = iota
+	SkipMissingData
+	RetryMissingData
+)
+
+// MissingDataPolicy determines how a transformer reacts when the database
+// reports that data for a block is not found, pruned or corrupt.
+type MissingDataPolicy struct {
+	Action     MissingDataAction
+	Retries    int
+	RetryDelay time.Duration
+}
+
+var DefaultMissingDataPolicy = MissingDataPolicy{Action: FailOnMissingData}
+
+func NewMissingDataPolicy(action MissingDataAction, retries int, retryDelay time.Duration) MissingDataPolicy {
+	return MissingDataPolicy{
+		Action:     action,
+		Retries:    retries,
+		RetryDelay: retryDelay,
+	}
+}
+
+// fetch invokes read, retrying while the policy allows it. It returns skip as
+// true when the block's data is missing and the policy is to skip the block.
+// Waiting to retry stops with ctx's error if ctx is done, and a read that
+// stops with its context's error returns it as is.
+func (p MissingDataPolicy) fetch(ctx context.Context, blockNumber int64, read func() error) (skip bool, err error) {
+	read = timedRead(read)
+	err = read()
+	for attempt := 0; err != nil && level.IsBlockDataError(err) && p.Action == RetryMissingData && attempt < p.Retries; attempt++ {
+		log.WithFields(log.Fields{"block": blockNumber, "delay": p.RetryDelay.String()}).WithError(err).Warn("Retrying block with missing data")
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(p.RetryDelay):
+		}
+		err = read()
+	}
+	if err == nil {
+		return false, nil
+	}
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return false, err
+	}
+	if !level.IsBlockDataError(err) {
+		metrics.Errors.With(metrics.DatabaseReadError).Inc()
+		return false, NewExecuteError(GetBlockRlpErr, err)
+	}
+	metrics.Errors.With(metrics.MissingDataError).Inc()
+	if p.Action == SkipMissingData {
+		log.WithField("block", blockNumber).WithError(err).Warn("Skipping block with missing data")
+		return true, nil
+	}
+	return false, NewExecuteError(GetBlockRlpErr, err)
+}
+
+// timedRead observes the latency of each call of read
+func timedRead(read func() error) func() error {
+	return func() error {
+		defer metrics.DatabaseReadSeconds.ObserveSince(time.Now())
+		return read()
+	}
+}
+
+// fetchRequired is like fetch, but fails instead of skipping, for blocks that
+// later blocks depend on.
+func (p MissingDataPolicy) fetchRequired(ctx context.Context, blockNumber int64, read func() error) error {
+	if p.Action == SkipMissingData {
+		p.Action = FailOnMissingData
+	}
+	_, err := p.fetch(ctx, blockNumber, read)
+	return err
+}
@@ -0,0 +1,36 @@
+package transformers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+// PreimageExportTransformer writes the stored secure trie key preimages in a
+// range of the key space to writer as one JSON object per line
+type PreimageExportTransformer struct {
+	database db.Database
+	writer   io.Writer
+}
+
+func NewPreimageExportTransformer(database db.Database, writer io.Writer) *PreimageExportTransformer {
+	return &PreimageExportTransformer{
+		database: database,
+		writer:   writer,
+	}
+}
+
+func (t PreimageExportTransformer) Execute(ctx context.Context, keySpace level.KeySpaceRange) error {
+	encoder := json.NewEncoder(t.writer)
+	return t.database.ExportPreimages(ctx, keySpace, func(preimage level.Preimage) error {
+		err := encoder.Encode(preimage)
+		if err != nil {
+			return fmt.Errorf("Error writing preimage %s: %s", preimage.Hash.Hex(), err)
+		}
+		return nil
+	})
+}
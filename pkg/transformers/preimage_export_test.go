@@ -0,0 +1,61 @@
+package transformers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
+)
+
+var _ = Describe("Preimage export transformer", func() {
+	It("exports the preimages in the key space range", func() {
+		mockDB := db.NewMockDatabase()
+		keySpace := level.KeySpaceRange{Start: 0x100, End: 0x200}
+		transformer := transformers.NewPreimageExportTransformer(mockDB, &bytes.Buffer{})
+
+		err := transformer.Execute(context.Background(), keySpace)
+
+		Expect(err).NotTo(HaveOccurred())
+		mockDB.AssertExportPreimagesCalledWith(keySpace)
+	})
+
+	It("writes each preimage as a line of JSON", func() {
+		preimages := []level.Preimage{
+			{Hash: common.HexToHash("0x1"), Preimage: common.HexToAddress("0xabc").Bytes()},
+			{Hash: common.HexToHash("0x2"), Preimage: common.HexToHash("0xdef").Bytes()},
+		}
+		mockDB := db.NewMockDatabase()
+		mockDB.SetExportPreimagesReturnPreimages(preimages)
+		writer := &bytes.Buffer{}
+		transformer := transformers.NewPreimageExportTransformer(mockDB, writer)
+
+		err := transformer.Execute(context.Background(), level.FullKeySpace)
+
+		Expect(err).NotTo(HaveOccurred())
+		decoder := json.NewDecoder(writer)
+		for _, expected := range preimages {
+			var preimage level.Preimage
+			Expect(decoder.Decode(&preimage)).To(Succeed())
+			Expect(preimage).To(Equal(expected))
+		}
+		Expect(decoder.More()).To(BeFalse())
+	})
+
+	It("returns error if exporting preimages fails", func() {
+		mockDB := db.NewMockDatabase()
+		mockDB.SetExportPreimagesError(test_helpers.FakeError)
+		transformer := transformers.NewPreimageExportTransformer(mockDB, &bytes.Buffer{})
+
+		err := transformer.Execute(context.Background(), level.FullKeySpace)
+
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+})
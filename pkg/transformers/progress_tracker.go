@@ -0,0 +1,74 @@
+package transformers
+
+import (
+	"math"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ProgressTracker logs how far through a range of blocks a transformer is, at
+// most once per interval and when the last block is done
+type ProgressTracker struct {
+	done                int64
+	endingBlockNumber   int64
+	interval            time.Duration
+	lastReport          time.Time
+	now                 func() time.Time
+	onBlockDone         func(blockNumber, done, total int64)
+	started             time.Time
+	startingBlockNumber int64
+}
+
+func NewProgressTracker(startingBlockNumber, endingBlockNumber int64, interval time.Duration) *ProgressTracker {
+	return newProgressTracker(startingBlockNumber, endingBlockNumber, interval, time.Now)
+}
+
+func newProgressTracker(startingBlockNumber, endingBlockNumber int64, interval time.Duration, now func() time.Time) *ProgressTracker {
+	started := now()
+	return &ProgressTracker{
+		endingBlockNumber:   endingBlockNumber,
+		interval:            interval,
+		lastReport:          started,
+		now:                 now,
+		started:             started,
+		startingBlockNumber: startingBlockNumber,
+	}
+}
+
+// OnBlockDone calls report with the number of blocks done, out of the total
+// in the range, each time a block is done
+func (p *ProgressTracker) OnBlockDone(report func(blockNumber, done, total int64)) *ProgressTracker {
+	p.onBlockDone = report
+	return p
+}
+
+// blockDone records that blockNumber was extracted or skipped
+func (p *ProgressTracker) blockDone(blockNumber int64) {
+	p.done++
+	total := p.endingBlockNumber - p.startingBlockNumber + 1
+	if p.onBlockDone != nil {
+		p.onBlockDone(blockNumber, p.done, total)
+	}
+	current := p.now()
+	if blockNumber != p.endingBlockNumber && current.Sub(p.lastReport) < p.interval {
+		return
+	}
+	p.lastReport = current
+	elapsed := current.Sub(p.started).Seconds()
+	var rate float64
+	if elapsed > 0 {
+		rate = float64(p.done) / elapsed
+	}
+	log.WithFields(log.Fields{
+		"block":             blockNumber,
+		"done":              p.done,
+		"total":             total,
+		"percent":           roundTenths(100 * float64(p.done) / float64(total)),
+		"blocks_per_second": roundTenths(rate),
+	}).Info("Extraction progress")
+}
+
+func roundTenths(f float64) float64 {
+	return math.Round(f*10) / 10
+}
@@ -0,0 +1,63 @@
+package transformers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// ShardManifestEntry lists the trie nodes published for a block's state by a
+// process extracting one range of the state trie's key space. Shards covering
+// adjacent ranges publish disjoint nodes, so their manifests can be
+// concatenated, and the ranges checked to cover 0x0000 up to 0x10000.
+type ShardManifestEntry struct {
+	BlockNumber      int64          `json:"blockNumber"`
+	StateRoot        common.Hash    `json:"stateRoot"`
+	KeySpaceStart    hexutil.Uint64 `json:"keySpaceStart"`
+	KeySpaceEnd      hexutil.Uint64 `json:"keySpaceEnd"`
+	StateTrieNodes   []string       `json:"stateTrieNodes"`
+	StorageTrieNodes []string       `json:"storageTrieNodes"`
+}
+
+// ShardManifest writes a ShardManifestEntry per block to writer as one JSON
+// object per line
+type ShardManifest struct {
+	keySpace level.KeySpaceRange
+	writer   io.Writer
+}
+
+func NewShardManifest(writer io.Writer, keySpace level.KeySpaceRange) *ShardManifest {
+	return &ShardManifest{
+		keySpace: keySpace,
+		writer:   writer,
+	}
+}
+
+func (m *ShardManifest) record(blockNumber int64, root common.Hash, stateTrieNodes, storageTrieNodes []ipfs.Result) error {
+	entry := ShardManifestEntry{
+		BlockNumber:      blockNumber,
+		StateRoot:        root,
+		KeySpaceStart:    hexutil.Uint64(m.keySpace.Start),
+		KeySpaceEnd:      hexutil.Uint64(m.keySpace.End),
+		StateTrieNodes:   cidStrings(stateTrieNodes),
+		StorageTrieNodes: cidStrings(storageTrieNodes),
+	}
+	err := json.NewEncoder(m.writer).Encode(entry)
+	if err != nil {
+		return fmt.Errorf("Error writing shard manifest for block %d: %s", blockNumber, err)
+	}
+	return nil
+}
+
+func cidStrings(results []ipfs.Result) []string {
+	cids := make([]string, 0, len(results))
+	for _, result := range results {
+		cids = append(cids, result.String())
+	}
+	return cids
+}
@@ -0,0 +1,58 @@
+package transformers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// StateDiffExporter writes each block's state diff to writer as one JSON object
+// per line and publishes it to IPFS. Either writer or publisher may be nil.
+type StateDiffExporter struct {
+	publisher ipfs.StateDiffPublisher
+	writer    io.Writer
+}
+
+func NewStateDiffExporter(writer io.Writer, publisher ipfs.StateDiffPublisher) *StateDiffExporter {
+	return &StateDiffExporter{
+		publisher: publisher,
+		writer:    writer,
+	}
+}
+
+func (e *StateDiffExporter) export(ctx context.Context, database db.Database, blockNumber int64, blockHash, parentRoot, root common.Hash, summary *blockSummary) error {
+	accounts, err := database.DiffStateTries(ctx, parentRoot, root)
+	if err != nil {
+		return fmt.Errorf("Error diffing state for block %d: %s", blockNumber, err)
+	}
+	if accounts == nil {
+		accounts = []level.AccountDiff{}
+	}
+	diff := db.StateDiff{
+		BlockNumber:     blockNumber,
+		BlockHash:       blockHash,
+		ParentStateRoot: parentRoot,
+		StateRoot:       root,
+		Accounts:        accounts,
+	}
+	if e.writer != nil {
+		err = json.NewEncoder(e.writer).Encode(diff)
+		if err != nil {
+			return fmt.Errorf("Error writing state diff for block %d: %s", blockNumber, err)
+		}
+	}
+	if e.publisher != nil {
+		output, err := e.publisher.WriteStateDiff(ctx, blockNumber, diff)
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(output)
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package transformers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	log "github.com/sirupsen/logrus"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+type StateRootMismatchAction int
+
+const (
+	StopOnStateRootMismatch StateRootMismatchAction = iota
+	ContinueOnStateRootMismatch
+)
+
+// StateRootValidation determines how computed state is checked against block
+// headers. Mismatch reports are written to Report as one JSON object per line
+// when it is set.
+type StateRootValidation struct {
+	Action StateRootMismatchAction
+	Report io.Writer
+}
+
+var DefaultStateRootValidation = StateRootValidation{Action: StopOnStateRootMismatch}
+
+func NewStateRootValidation(action StateRootMismatchAction, report io.Writer) StateRootValidation {
+	return StateRootValidation{
+		Action: action,
+		Report: report,
+	}
+}
+
+// StateRootMismatchReport describes a block whose computed state root differs
+// from the root in its header. When the expected state is not available, e.g.
+// on a pruned node, AccountDiffs holds the accounts the block changed relative
+// to its parent's state instead, and DiffedAgainstParent is set.
+type StateRootMismatchReport struct {
+	BlockNumber         int64               `json:"blockNumber"`
+	ExpectedRoot        common.Hash         `json:"expectedRoot"`
+	ComputedRoot        common.Hash         `json:"computedRoot"`
+	DiffedAgainstParent bool                `json:"diffedAgainstParent"`
+	AccountDiffs        []level.AccountDiff `json:"accountDiffs"`
+}
+
+type StateRootMismatchError struct {
+	Report StateRootMismatchReport
+}
+
+func (e StateRootMismatchError) Error() string {
+	return fmt.Sprintf("computed state root %s does not match header root %s for block %d (%d accounts differ)",
+		e.Report.ComputedRoot.Hex(), e.Report.ExpectedRoot.Hex(), e.Report.BlockNumber, len(e.Report.AccountDiffs))
+}
+
+func (v StateRootValidation) validate(ctx context.Context, database db.Database, block *types.Block, parentRoot, computedRoot common.Hash) error {
+	if computedRoot == block.Root() {
+		return nil
+	}
+	report := StateRootMismatchReport{
+		BlockNumber:  block.Number().Int64(),
+		ExpectedRoot: block.Root(),
+		ComputedRoot: computedRoot,
+	}
+	diffs, err := database.DiffStateTries(ctx, block.Root(), computedRoot)
+	if err != nil {
+		log.WithField("block", report.BlockNumber).WithError(err).Warn("Expected state unavailable, diffing against parent state")
+		report.DiffedAgainstParent = true
+		diffs, err = database.DiffStateTries(ctx, parentRoot, computedRoot)
+		if err != nil {
+			return fmt.Errorf("Error diffing computed state for block %d: %s", report.BlockNumber, err)
+		}
+	}
+	report.AccountDiffs = diffs
+	mismatchErr := &StateRootMismatchError{Report: report}
+	log.WithFields(log.Fields{
+		"block":    report.BlockNumber,
+		"expected": report.ExpectedRoot.Hex(),
+		"computed": report.ComputedRoot.Hex(),
+		"accounts": len(diffs),
+	}).Warn("State root mismatch")
+	for _, diff := range diffs {
+		log.WithFields(log.Fields{
+			"block":   report.BlockNumber,
+			"account": diff.AddressHash.Hex(),
+			"from":    formatAccount(diff.From),
+			"to":      formatAccount(diff.To),
+		}).Warn("State root mismatch account diff")
+	}
+	if v.Report != nil {
+		err = json.NewEncoder(v.Report).Encode(report)
+		if err != nil {
+			return fmt.Errorf("Error writing state root mismatch report: %s", err)
+		}
+	}
+	if v.Action == ContinueOnStateRootMismatch {
+		return nil
+	}
+	return mismatchErr
+}
+
+func formatAccount(account *level.Account) string {
+	if account == nil {
+		return "absent"
+	}
+	return fmt.Sprintf("{nonce: %d, balance: %s, storageRoot: %s, codeHash: %s}",
+		account.Nonce, account.Balance, account.StorageRoot.Hex(), account.CodeHash.Hex())
+}
@@ -0,0 +1,53 @@
+package transformers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// StorageIndexEntry locates a published storage trie node by the account it
+// belongs to and its path in the account's storage trie. Address and a leaf's
+// slot Key are omitted if their preimages are unknown.
+type StorageIndexEntry struct {
+	BlockNumber int64           `json:"blockNumber"`
+	AddressHash common.Hash     `json:"addressHash"`
+	Address     *common.Address `json:"address,omitempty"`
+	Key         *common.Hash    `json:"key,omitempty"`
+	Path        hexutil.Bytes   `json:"path"`
+	Cid         string          `json:"cid"`
+}
+
+// StorageIndex writes a StorageIndexEntry per published storage trie node to
+// writer as one JSON object per line
+type StorageIndex struct {
+	writer io.Writer
+}
+
+func NewStorageIndex(writer io.Writer) *StorageIndex {
+	return &StorageIndex{writer: writer}
+}
+
+func (i *StorageIndex) record(blockNumber int64, storageTrieNodes []level.StorageTrieNode, outputs []ipfs.Result) error {
+	encoder := json.NewEncoder(i.writer)
+	for n, node := range storageTrieNodes {
+		entry := StorageIndexEntry{
+			BlockNumber: blockNumber,
+			AddressHash: node.AddressHash,
+			Address:     node.Address,
+			Key:         node.Key,
+			Path:        node.Path,
+			Cid:         outputs[n].String(),
+		}
+		err := encoder.Encode(entry)
+		if err != nil {
+			return fmt.Errorf("Error writing storage index for block %d: %s", blockNumber, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package transformers
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+var ErrNoBlocksInTimeRange = errors.New("no canonical blocks have timestamps in the time range")
+
+// BlockRangeForTimes returns the first and last canonical blocks whose header
+// timestamps are within from and to, inclusive. Headers are binary searched,
+// so each header up to the chain head must be stored.
+func BlockRangeForTimes(ctx context.Context, database db.Database, from, to time.Time) (startingBlockNumber, endingBlockNumber int64, err error) {
+	startingBlockNumber, err = firstBlockAfter(ctx, database, from.Unix(), false)
+	if err != nil {
+		return 0, 0, err
+	}
+	afterEndingBlockNumber, err := firstBlockAfter(ctx, database, to.Unix(), true)
+	if err != nil {
+		return 0, 0, err
+	}
+	endingBlockNumber = afterEndingBlockNumber - 1
+	if endingBlockNumber < startingBlockNumber {
+		return 0, 0, ErrNoBlocksInTimeRange
+	}
+	return startingBlockNumber, endingBlockNumber, nil
+}
+
+// firstBlockAfter returns the lowest block number whose timestamp is at least
+// timestamp, or greater than it if strict, treating blocks past the chain head
+// as later than any timestamp. The search probes doubling block numbers to
+// bound the head before bisecting.
+func firstBlockAfter(ctx context.Context, database db.Database, timestamp int64, strict bool) (int64, error) {
+	isAfter := func(blockNumber int64) (bool, error) {
+		header, err := database.GetBlockHeaderByBlockNumber(ctx, blockNumber)
+		if level.IsNotFound(err) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		comparison := header.Time.Cmp(big.NewInt(timestamp))
+		return comparison > 0 || (!strict && comparison == 0), nil
+	}
+	after, err := isAfter(0)
+	if err != nil || after {
+		return 0, err
+	}
+	// blocks up to low are before the timestamp, and high is after it
+	low, high := int64(0), int64(1)
+	for {
+		after, err = isAfter(high)
+		if err != nil {
+			return 0, err
+		}
+		if after {
+			break
+		}
+		low, high = high, high*2
+	}
+	for high-low > 1 {
+		middle := low + (high-low)/2
+		after, err = isAfter(middle)
+		if err != nil {
+			return 0, err
+		}
+		if after {
+			high = middle
+		} else {
+			low = middle
+		}
+	}
+	return high, nil
+}
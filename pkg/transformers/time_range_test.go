@@ -0,0 +1,74 @@
+package transformers_test
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/transformers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers"
+	"github.com/vulcanize/eth-block-extractor/test_helpers/mocks/db"
+)
+
+var _ = Describe("Block range for times", func() {
+	var mockDB *db.MockDatabase
+
+	// blocks every 15 seconds from the epoch, for 100 blocks
+	BeforeEach(func() {
+		mockDB = db.NewMockDatabase()
+		var headers []*types.Header
+		for i := int64(0); i < 100; i++ {
+			headers = append(headers, &types.Header{Number: big.NewInt(i), Time: big.NewInt(i * 15)})
+		}
+		mockDB.SetGetBlockHeaderByBlockNumberReturnChain(headers)
+	})
+
+	It("returns the blocks with timestamps in the range, inclusive", func() {
+		startingBlockNumber, endingBlockNumber, err := transformers.BlockRangeForTimes(context.Background(), mockDB, time.Unix(150, 0), time.Unix(300, 0))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(startingBlockNumber).To(Equal(int64(10)))
+		Expect(endingBlockNumber).To(Equal(int64(20)))
+	})
+
+	It("returns the blocks between times that fall between blocks", func() {
+		startingBlockNumber, endingBlockNumber, err := transformers.BlockRangeForTimes(context.Background(), mockDB, time.Unix(151, 0), time.Unix(299, 0))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(startingBlockNumber).To(Equal(int64(11)))
+		Expect(endingBlockNumber).To(Equal(int64(19)))
+	})
+
+	It("ends the range at the chain head", func() {
+		startingBlockNumber, endingBlockNumber, err := transformers.BlockRangeForTimes(context.Background(), mockDB, time.Unix(0, 0), time.Unix(100000, 0))
+
+		Expect(err).NotTo(HaveOccurred())
+		Expect(startingBlockNumber).To(Equal(int64(0)))
+		Expect(endingBlockNumber).To(Equal(int64(99)))
+	})
+
+	It("returns error if no blocks are in the range", func() {
+		_, _, err := transformers.BlockRangeForTimes(context.Background(), mockDB, time.Unix(151, 0), time.Unix(155, 0))
+
+		Expect(err).To(MatchError(transformers.ErrNoBlocksInTimeRange))
+	})
+
+	It("returns error if the range is after the chain head", func() {
+		_, _, err := transformers.BlockRangeForTimes(context.Background(), mockDB, time.Unix(100000, 0), time.Unix(200000, 0))
+
+		Expect(err).To(MatchError(transformers.ErrNoBlocksInTimeRange))
+	})
+
+	It("returns error if fetching a header fails", func() {
+		mockDB = db.NewMockDatabase()
+		mockDB.SetGetBlockHeaderByBlockNumberError(test_helpers.FakeError)
+
+		_, _, err := transformers.BlockRangeForTimes(context.Background(), mockDB, time.Unix(150, 0), time.Unix(300, 0))
+
+		Expect(err).To(MatchError(test_helpers.FakeError))
+	})
+})
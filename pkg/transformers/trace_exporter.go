@@ -0,0 +1,53 @@
+package transformers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// TraceExporter writes each block's transaction call traces to writer as one
+// JSON object per line and publishes them to IPFS. Either writer or publisher
+// may be nil.
+type TraceExporter struct {
+	publisher ipfs.TracesPublisher
+	writer    io.Writer
+}
+
+func NewTraceExporter(writer io.Writer, publisher ipfs.TracesPublisher) *TraceExporter {
+	return &TraceExporter{
+		publisher: publisher,
+		writer:    writer,
+	}
+}
+
+func (e *TraceExporter) export(ctx context.Context, blockNumber int64, blockHash common.Hash, transactions []level.TransactionTrace, summary *blockSummary) error {
+	if transactions == nil {
+		transactions = []level.TransactionTrace{}
+	}
+	traces := db.BlockTraces{
+		BlockNumber:  blockNumber,
+		BlockHash:    blockHash,
+		Transactions: transactions,
+	}
+	if e.writer != nil {
+		err := json.NewEncoder(e.writer).Encode(traces)
+		if err != nil {
+			return fmt.Errorf("Error writing traces for block %d: %s", blockNumber, err)
+		}
+	}
+	if e.publisher != nil {
+		outputs, err := e.publisher.WriteTraces(ctx, blockNumber, traces)
+		if err != nil {
+			return NewExecuteError(PutIpldErr, err)
+		}
+		summary.add(outputs...)
+	}
+	return nil
+}
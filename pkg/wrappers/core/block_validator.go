@@ -1,24 +1,33 @@
 package core
 
 import (
-	"github.com/ethereum/go-ethereum/core"
-	"github.com/ethereum/go-ethereum/core/state"
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/core/types"
 )
 
 type GethBlockValidator interface {
-	ValidateState(block *types.Block, state *state.StateDB, receipts types.Receipts, usedGas uint64) error
+	ValidateReceipts(block *types.Block, receipts types.Receipts, usedGas uint64) error
 }
 
-type BlockValidator struct {
-	validator core.Validator
-}
+// BlockValidator performs geth's post-processing checks on gas used, bloom
+// and receipt root. Unlike geth's validator it leaves the state root alone, so
+// that callers can diff and report a mismatched root instead of just failing.
+type BlockValidator struct{}
 
-func NewBlockValidator(blockChain BlockChain) *BlockValidator {
-	validator := core.NewBlockValidator(blockChain.Config(), blockChain.BlockChain(), blockChain.Engine())
-	return &BlockValidator{validator: validator}
+func NewBlockValidator() *BlockValidator {
+	return &BlockValidator{}
 }
 
-func (sv *BlockValidator) ValidateState(block *types.Block, state *state.StateDB, receipts types.Receipts, usedGas uint64) error {
-	return sv.validator.ValidateState(block, state, receipts, usedGas)
+func (bv *BlockValidator) ValidateReceipts(block *types.Block, receipts types.Receipts, usedGas uint64) error {
+	if block.GasUsed() != usedGas {
+		return fmt.Errorf("invalid gas used (remote: %d local: %d)", block.GasUsed(), usedGas)
+	}
+	if bloom := types.CreateBloom(receipts); bloom != block.Bloom() {
+		return fmt.Errorf("invalid bloom (remote: %x local: %x)", block.Bloom(), bloom)
+	}
+	if receiptSha := types.DeriveSha(receipts); receiptSha != block.ReceiptHash() {
+		return fmt.Errorf("invalid receipt root hash (remote: %x local: %x)", block.ReceiptHash(), receiptSha)
+	}
+	return nil
 }
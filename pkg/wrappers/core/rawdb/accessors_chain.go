@@ -1,6 +1,8 @@
 package rawdb
 
 import (
+	"encoding/binary"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -8,13 +10,22 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// headerPrefix is the prefix geth stores headers under, followed by the block
+// number and hash
+var headerPrefix = []byte("h")
+
 type IAccessorsChain interface {
+	GetAllHashes(number uint64) ([]common.Hash, error)
 	GetBlock(hash common.Hash, number uint64) *types.Block
 	GetBlockReceipts(hash common.Hash, number uint64) types.Receipts
 	GetBody(hash common.Hash, number uint64) *types.Body
+	GetBodyRLP(hash common.Hash, number uint64) rlp.RawValue
 	GetCanonicalHash(number uint64) common.Hash
 	GetHeader(hash common.Hash, number uint64) *types.Header
+	GetHeaderNumber(hash common.Hash) *uint64
 	GetHeaderRLP(hash common.Hash, number uint64) rlp.RawValue
+	GetReceiptsRLP(hash common.Hash, number uint64) rlp.RawValue
+	GetTxLookupEntry(txHash common.Hash) common.Hash
 }
 
 type AccessorsChain struct {
@@ -25,6 +36,28 @@ func NewAccessorsChain(databaseConnection ethdb.Database) *AccessorsChain {
 	return &AccessorsChain{ethDbConnection: databaseConnection}
 }
 
+// GetAllHashes returns the hashes of every header stored at number, canonical
+// or not, or the error that stopped iterating over them
+func (accessor *AccessorsChain) GetAllHashes(number uint64) ([]common.Hash, error) {
+	prefix := make([]byte, len(headerPrefix)+8)
+	copy(prefix, headerPrefix)
+	binary.BigEndian.PutUint64(prefix[len(headerPrefix):], number)
+	iterator := accessor.ethDbConnection.NewIteratorWithPrefix(prefix)
+	defer iterator.Release()
+	var hashes []common.Hash
+	for iterator.Next() {
+		// skip the canonical hash and total difficulty entries sharing the prefix
+		key := iterator.Key()
+		if len(key) == len(prefix)+common.HashLength {
+			hashes = append(hashes, common.BytesToHash(key[len(prefix):]))
+		}
+	}
+	if err := iterator.Error(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
 func (accessor *AccessorsChain) GetBlock(hash common.Hash, number uint64) *types.Block {
 	return rawdb.ReadBlock(accessor.ethDbConnection, hash, number)
 }
@@ -37,6 +70,10 @@ func (accessor *AccessorsChain) GetBody(hash common.Hash, number uint64) *types.
 	return rawdb.ReadBody(accessor.ethDbConnection, hash, number)
 }
 
+func (accessor *AccessorsChain) GetBodyRLP(hash common.Hash, number uint64) rlp.RawValue {
+	return rawdb.ReadBodyRLP(accessor.ethDbConnection, hash, number)
+}
+
 func (accessor *AccessorsChain) GetCanonicalHash(number uint64) common.Hash {
 	return rawdb.ReadCanonicalHash(accessor.ethDbConnection, number)
 }
@@ -45,6 +82,20 @@ func (accessor *AccessorsChain) GetHeader(hash common.Hash, number uint64) *type
 	return rawdb.ReadHeader(accessor.ethDbConnection, hash, number)
 }
 
+func (accessor *AccessorsChain) GetHeaderNumber(hash common.Hash) *uint64 {
+	return rawdb.ReadHeaderNumber(accessor.ethDbConnection, hash)
+}
+
 func (accessor *AccessorsChain) GetHeaderRLP(hash common.Hash, number uint64) rlp.RawValue {
 	return rawdb.ReadHeaderRLP(accessor.ethDbConnection, hash, number)
 }
+
+func (accessor *AccessorsChain) GetReceiptsRLP(hash common.Hash, number uint64) rlp.RawValue {
+	return rawdb.ReadReceiptsRLP(accessor.ethDbConnection, hash, number)
+}
+
+// GetTxLookupEntry returns the hash of the canonical block containing the
+// transaction, or the zero hash if it is not indexed
+func (accessor *AccessorsChain) GetTxLookupEntry(txHash common.Hash) common.Hash {
+	return rawdb.ReadTxLookupEntry(accessor.ethDbConnection, txHash)
+}
@@ -0,0 +1,42 @@
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// preimagePrefix is the prefix geth stores secure trie key preimages under
+var preimagePrefix = []byte("secure-key-")
+
+// IPreimages iterates the stored preimages of hashed secure trie keys
+type IPreimages interface {
+	IteratePreimages(hashPrefix []byte, visit func(hash common.Hash, preimage []byte) error) error
+}
+
+type Preimages struct {
+	ethDbConnection ethdb.Database
+}
+
+func NewPreimages(databaseConnection ethdb.Database) *Preimages {
+	return &Preimages{ethDbConnection: databaseConnection}
+}
+
+// IteratePreimages visits every stored preimage whose hash begins with
+// hashPrefix, in hash order
+func (p *Preimages) IteratePreimages(hashPrefix []byte, visit func(hash common.Hash, preimage []byte) error) error {
+	prefix := append(append([]byte{}, preimagePrefix...), hashPrefix...)
+	iterator := p.ethDbConnection.NewIteratorWithPrefix(prefix)
+	defer iterator.Release()
+	for iterator.Next() {
+		key := iterator.Key()
+		if len(key) != len(preimagePrefix)+common.HashLength {
+			continue
+		}
+		hash := common.BytesToHash(key[len(preimagePrefix):])
+		err := visit(hash, common.CopyBytes(iterator.Value()))
+		if err != nil {
+			return err
+		}
+	}
+	return iterator.Error()
+}
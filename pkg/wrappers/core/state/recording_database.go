@@ -0,0 +1,160 @@
+package state
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// RecordingDatabase wraps a state database to record the trie nodes read while
+// executing against it. For every key accessed through a trie it opens, the
+// nodes on the key's path in the trie as it was opened are recorded, so the
+// result is the pre-state witness needed to re-execute the same accesses.
+//
+// Nodes that only become necessary when a deletion collapses a branch (the
+// remaining sibling) are not recorded.
+type RecordingDatabase struct {
+	state.Database
+	stateNodes   *nodeRecorder
+	storageNodes *nodeRecorder
+}
+
+func NewRecordingDatabase(db state.Database) *RecordingDatabase {
+	return &RecordingDatabase{
+		Database:     db,
+		stateNodes:   newNodeRecorder(),
+		storageNodes: newNodeRecorder(),
+	}
+}
+
+func (rdb *RecordingDatabase) OpenTrie(root common.Hash) (state.Trie, error) {
+	return rdb.openRecordingTrie(rdb.stateNodes, func() (state.Trie, error) {
+		return rdb.Database.OpenTrie(root)
+	})
+}
+
+func (rdb *RecordingDatabase) OpenStorageTrie(addrHash, root common.Hash) (state.Trie, error) {
+	return rdb.openRecordingTrie(rdb.storageNodes, func() (state.Trie, error) {
+		return rdb.Database.OpenStorageTrie(addrHash, root)
+	})
+}
+
+func (rdb *RecordingDatabase) CopyTrie(t state.Trie) state.Trie {
+	rt, ok := t.(*recordingTrie)
+	if !ok {
+		return rdb.Database.CopyTrie(t)
+	}
+	return &recordingTrie{
+		Trie:     rdb.Database.CopyTrie(rt.Trie),
+		original: rt.original,
+		recorder: rt.recorder,
+	}
+}
+
+// StateTrieNodes returns the recorded state trie nodes, ordered by hash
+func (rdb *RecordingDatabase) StateTrieNodes() [][]byte {
+	return rdb.stateNodes.nodes()
+}
+
+// StorageTrieNodes returns the recorded storage trie nodes, ordered by hash
+func (rdb *RecordingDatabase) StorageTrieNodes() [][]byte {
+	return rdb.storageNodes.nodes()
+}
+
+// the trie is opened twice so that proofs are always taken against the
+// unmodified trie, rather than one holding the block's pending changes
+func (rdb *RecordingDatabase) openRecordingTrie(recorder *nodeRecorder, open func() (state.Trie, error)) (state.Trie, error) {
+	t, err := open()
+	if err != nil {
+		return nil, err
+	}
+	original, err := open()
+	if err != nil {
+		return nil, err
+	}
+	return &recordingTrie{Trie: t, original: original, recorder: recorder}, nil
+}
+
+type recordingTrie struct {
+	state.Trie
+	original state.Trie
+	recorder *nodeRecorder
+}
+
+func (rt *recordingTrie) TryGet(key []byte) ([]byte, error) {
+	err := rt.record(key)
+	if err != nil {
+		return nil, err
+	}
+	return rt.Trie.TryGet(key)
+}
+
+func (rt *recordingTrie) TryUpdate(key, value []byte) error {
+	err := rt.record(key)
+	if err != nil {
+		return err
+	}
+	return rt.Trie.TryUpdate(key, value)
+}
+
+func (rt *recordingTrie) TryDelete(key []byte) error {
+	err := rt.record(key)
+	if err != nil {
+		return err
+	}
+	return rt.Trie.TryDelete(key)
+}
+
+// secure tries hash keys on access but expect already hashed keys for proofs
+func (rt *recordingTrie) record(key []byte) error {
+	err := rt.original.Prove(crypto.Keccak256(key), 0, rt.recorder)
+	if _, ok := err.(*trie.MissingNodeError); ok {
+		// let the access itself report the missing node
+		return nil
+	}
+	return err
+}
+
+// nodeRecorder collects proof nodes keyed by hash
+type nodeRecorder struct {
+	lock   sync.Mutex
+	values map[string][]byte
+}
+
+func newNodeRecorder() *nodeRecorder {
+	return &nodeRecorder{values: make(map[string][]byte)}
+}
+
+func (nr *nodeRecorder) Put(key []byte, value []byte) error {
+	nr.lock.Lock()
+	defer nr.lock.Unlock()
+	nr.values[string(key)] = common.CopyBytes(value)
+	return nil
+}
+
+func (nr *nodeRecorder) Delete(key []byte) error {
+	nr.lock.Lock()
+	defer nr.lock.Unlock()
+	delete(nr.values, string(key))
+	return nil
+}
+
+func (nr *nodeRecorder) nodes() [][]byte {
+	nr.lock.Lock()
+	defer nr.lock.Unlock()
+	keys := make([][]byte, 0, len(nr.values))
+	for key := range nr.values {
+		keys = append(keys, []byte(key))
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+	nodes := make([][]byte, 0, len(keys))
+	for _, key := range keys {
+		nodes = append(nodes, nr.values[string(key)])
+	}
+	return nodes
+}
@@ -0,0 +1,87 @@
+package state_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/trie"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	state_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/state"
+)
+
+var _ = Describe("Recording database", func() {
+	var (
+		diskDB   ethdb.Database
+		root     common.Hash
+		accounts = []common.Address{
+			common.HexToAddress("0x1"),
+			common.HexToAddress("0x2"),
+			common.HexToAddress("0x3"),
+			common.HexToAddress("0x4"),
+		}
+		slot = common.HexToHash("0x5")
+	)
+
+	BeforeEach(func() {
+		diskDB = rawdb.NewMemoryDatabase()
+		stateDB, err := state.New(common.Hash{}, state.NewDatabase(diskDB))
+		Expect(err).NotTo(HaveOccurred())
+		for i, account := range accounts {
+			stateDB.SetBalance(account, big.NewInt(int64(i+1)))
+		}
+		stateDB.SetState(accounts[0], slot, common.HexToHash("0x6"))
+		root, err = stateDB.Commit(true)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stateDB.Database().TrieDB().Commit(root, false)).To(Succeed())
+	})
+
+	It("records the state trie nodes proving each account read", func() {
+		recorder := state_wrapper.NewRecordingDatabase(state.NewDatabase(diskDB))
+		stateDB, err := state.New(root, recorder)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stateDB.GetBalance(accounts[1])).To(Equal(big.NewInt(2)))
+
+		proof := rawdb.NewMemoryDatabase()
+		for _, node := range recorder.StateTrieNodes() {
+			Expect(proof.Put(crypto.Keccak256(node), node)).To(Succeed())
+		}
+		_, _, err = trie.VerifyProof(root, crypto.Keccak256(accounts[1].Bytes()), proof)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(recorder.StorageTrieNodes()).To(BeEmpty())
+	})
+
+	It("records storage trie nodes for storage read", func() {
+		recorder := state_wrapper.NewRecordingDatabase(state.NewDatabase(diskDB))
+		stateDB, err := state.New(root, recorder)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(stateDB.GetState(accounts[0], slot)).To(Equal(common.HexToHash("0x6")))
+
+		Expect(recorder.StorageTrieNodes()).NotTo(BeEmpty())
+	})
+
+	It("only records nodes of the state as it was opened", func() {
+		recorder := state_wrapper.NewRecordingDatabase(state.NewDatabase(diskDB))
+		stateDB, err := state.New(root, recorder)
+		Expect(err).NotTo(HaveOccurred())
+
+		stateDB.SetBalance(accounts[0], big.NewInt(100))
+		stateDB.SetState(accounts[0], slot, common.HexToHash("0x7"))
+		stateDB.IntermediateRoot(true)
+		stateDB.GetBalance(accounts[2])
+		stateDB.GetState(accounts[0], common.HexToHash("0x8"))
+
+		nodes := append(recorder.StateTrieNodes(), recorder.StorageTrieNodes()...)
+		Expect(nodes).NotTo(BeEmpty())
+		for _, node := range nodes {
+			Expect(diskDB.Has(crypto.Keccak256(node))).To(BeTrue())
+		}
+	})
+})
@@ -1,13 +1,18 @@
 package state
 
 import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
 
 	"github.com/vulcanize/eth-block-extractor/pkg/wrappers/trie"
 )
 
 type GethTrie interface {
+	GetKey(hashedKey []byte) []byte
 	NodeIterator(startKey []byte) trie.GethTrieNodeIterator
+	Prove(key []byte) ([][]byte, error)
 }
 
 type Trie struct {
@@ -18,7 +23,32 @@ func NewTrie(trie state.Trie) GethTrie {
 	return &Trie{trie: trie}
 }
 
+// GetKey returns the preimage of a hashed key, or nil if it was not recorded
+func (t *Trie) GetKey(hashedKey []byte) []byte {
+	return t.trie.GetKey(hashedKey)
+}
+
 func (t *Trie) NodeIterator(startKey []byte) trie.GethTrieNodeIterator {
 	iterator := t.trie.NodeIterator(startKey)
 	return trie.NewNodeIterator(iterator)
 }
+
+// Prove returns the encoded nodes on the path from the root to key, which must
+// already be hashed
+func (t *Trie) Prove(key []byte) ([][]byte, error) {
+	var proof proofList
+	err := t.trie.Prove(key, 0, &proof)
+	return proof, err
+}
+
+// proofList collects proof nodes in the order the trie writes them, root first
+type proofList [][]byte
+
+func (p *proofList) Put(key []byte, value []byte) error {
+	*p = append(*p, common.CopyBytes(value))
+	return nil
+}
+
+func (p *proofList) Delete(key []byte) error {
+	return errors.New("proof list does not support delete")
+}
@@ -11,6 +11,7 @@ import (
 
 type GethStateProcessor interface {
 	Process(block *types.Block, statedb *state.StateDB) (types.Receipts, []*types.Log, uint64, error)
+	ProcessWithTracer(block *types.Block, statedb *state.StateDB, tracer vm.Tracer) (types.Receipts, []*types.Log, uint64, error)
 }
 
 type StateProcessor struct {
@@ -25,3 +26,7 @@ func NewStateProcessor(blockChain BlockChain) *StateProcessor {
 func (sp *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (types.Receipts, []*types.Log, uint64, error) {
 	return sp.processor.Process(block, statedb, vm.Config{})
 }
+
+func (sp *StateProcessor) ProcessWithTracer(block *types.Block, statedb *state.StateDB, tracer vm.Tracer) (types.Receipts, []*types.Log, uint64, error) {
+	return sp.processor.Process(block, statedb, vm.Config{Debug: true, Tracer: tracer})
+}
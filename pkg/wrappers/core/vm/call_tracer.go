@@ -0,0 +1,162 @@
+package vm
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+// CallFrame is a message call or contract creation made while executing a
+// transaction, with the calls it made in turn
+type CallFrame struct {
+	Type    string         `json:"type"`
+	From    common.Address `json:"from"`
+	To      common.Address `json:"to"`
+	Value   *big.Int       `json:"value,omitempty"`
+	Gas     uint64         `json:"gas"`
+	GasUsed uint64         `json:"gasUsed,omitempty"`
+	Input   hexutil.Bytes  `json:"input,omitempty"`
+	Output  hexutil.Bytes  `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Calls   []*CallFrame   `json:"calls,omitempty"`
+}
+
+// CallTracer records the call tree of each transaction executed by the EVM it
+// is configured on, in execution order. Internal calls are reconstructed from
+// the opcodes that make them, since the EVM only reports the outermost call.
+// Gas used and output are only known for the outermost call; for internal
+// calls Gas is the amount requested by the calling opcode, and is zero for
+// creates.
+type CallTracer struct {
+	calls  []*CallFrame
+	frames []*CallFrame
+}
+
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+// Calls returns the outermost call of each transaction traced so far
+func (ct *CallTracer) Calls() []*CallFrame {
+	return ct.calls
+}
+
+func (ct *CallTracer) CaptureStart(from common.Address, to common.Address, create bool, input []byte, gas uint64, value *big.Int) error {
+	frame := &CallFrame{
+		Type:  vm.CALL.String(),
+		From:  from,
+		To:    to,
+		Value: new(big.Int).Set(value),
+		Gas:   gas,
+		Input: common.CopyBytes(input),
+	}
+	if create {
+		frame.Type = vm.CREATE.String()
+	}
+	ct.frames = []*CallFrame{frame}
+	return nil
+}
+
+// CaptureState is called before each opcode executes, at the depth of the
+// executing frame (1 for the outermost call)
+func (ct *CallTracer) CaptureState(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if err != nil {
+		// the opcode failed before executing, ending its frame
+		return ct.CaptureFault(env, pc, op, gas, cost, memory, stack, contract, depth, err)
+	}
+	if len(ct.frames) == 0 {
+		return nil
+	}
+	// a frame deeper than the executing one has returned, leaving its
+	// result on top of the caller's stack
+	for len(ct.frames) > depth {
+		ct.exitFrame(stack.Back(0))
+	}
+	parent := ct.frames[len(ct.frames)-1]
+	switch op {
+	case vm.CALL, vm.CALLCODE:
+		ct.enterFrame(parent, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    common.BigToAddress(stack.Back(1)),
+			Value: new(big.Int).Set(stack.Back(2)),
+			Gas:   stack.Back(0).Uint64(),
+			Input: memory.Get(stack.Back(3).Int64(), stack.Back(4).Int64()),
+		})
+	case vm.DELEGATECALL, vm.STATICCALL:
+		ct.enterFrame(parent, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    common.BigToAddress(stack.Back(1)),
+			Gas:   stack.Back(0).Uint64(),
+			Input: memory.Get(stack.Back(2).Int64(), stack.Back(3).Int64()),
+		})
+	case vm.CREATE, vm.CREATE2:
+		ct.enterFrame(parent, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			Value: new(big.Int).Set(stack.Back(0)),
+			Input: memory.Get(stack.Back(1).Int64(), stack.Back(2).Int64()),
+		})
+	case vm.SELFDESTRUCT:
+		parent.Calls = append(parent.Calls, &CallFrame{
+			Type:  op.String(),
+			From:  contract.Address(),
+			To:    common.BigToAddress(stack.Back(0)),
+			Value: new(big.Int).Set(env.StateDB.GetBalance(contract.Address())),
+		})
+	}
+	return nil
+}
+
+// CaptureFault is called when the frame at depth ends with an error
+func (ct *CallTracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost uint64, memory *vm.Memory, stack *vm.Stack, contract *vm.Contract, depth int, err error) error {
+	if depth < 1 || depth > len(ct.frames) {
+		return nil
+	}
+	ct.frames = ct.frames[:depth]
+	frame := ct.frames[depth-1]
+	if frame.Error == "" {
+		frame.Error = err.Error()
+	}
+	return nil
+}
+
+func (ct *CallTracer) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
+	if len(ct.frames) == 0 {
+		return nil
+	}
+	frame := ct.frames[0]
+	frame.GasUsed = gasUsed
+	frame.Output = common.CopyBytes(output)
+	if err != nil {
+		frame.Error = err.Error()
+	}
+	ct.calls = append(ct.calls, frame)
+	ct.frames = nil
+	return nil
+}
+
+func (ct *CallTracer) enterFrame(parent, frame *CallFrame) {
+	parent.Calls = append(parent.Calls, frame)
+	ct.frames = append(ct.frames, frame)
+}
+
+// exitFrame pops the innermost frame, given the value its opcode pushed: zero
+// on failure, or the created address for a successful create
+func (ct *CallTracer) exitFrame(result *big.Int) {
+	frame := ct.frames[len(ct.frames)-1]
+	ct.frames = ct.frames[:len(ct.frames)-1]
+	if result.Sign() == 0 {
+		if frame.Error == "" {
+			frame.Error = "call failed"
+		}
+		return
+	}
+	if frame.Type == vm.CREATE.String() || frame.Type == vm.CREATE2.String() {
+		frame.To = common.BigToAddress(result)
+	}
+}
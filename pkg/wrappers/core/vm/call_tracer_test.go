@@ -0,0 +1,147 @@
+package vm_test
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	vm_wrapper "github.com/vulcanize/eth-block-extractor/pkg/wrappers/core/vm"
+)
+
+var _ = Describe("Call tracer", func() {
+	var (
+		origin      = common.HexToAddress("0xaa")
+		callee      = common.HexToAddress("0xbb")
+		beneficiary = common.HexToAddress("0xcc")
+		// runtime.Execute runs the code at this address
+		contract = common.BytesToAddress([]byte("contract"))
+		tracer   *vm_wrapper.CallTracer
+		stateDB  *state.StateDB
+	)
+
+	// call pushes the arguments for CALL to callee with value, then calls it
+	call := func(value byte) []byte {
+		code := []byte{
+			byte(vm.PUSH1), 0, // ret size
+			byte(vm.PUSH1), 0, // ret offset
+			byte(vm.PUSH1), 0, // in size
+			byte(vm.PUSH1), 0, // in offset
+			byte(vm.PUSH1), value,
+			byte(vm.PUSH20),
+		}
+		code = append(code, callee.Bytes()...)
+		return append(code, byte(vm.PUSH2), 0xff, 0xff, byte(vm.CALL), byte(vm.POP), byte(vm.STOP))
+	}
+
+	execute := func(code []byte) {
+		_, _, err := runtime.Execute(code, nil, &runtime.Config{
+			ChainConfig: params.AllEthashProtocolChanges,
+			Origin:      origin,
+			GasLimit:    1000000,
+			State:       stateDB,
+			EVMConfig:   vm.Config{Debug: true, Tracer: tracer},
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	BeforeEach(func() {
+		tracer = vm_wrapper.NewCallTracer()
+		var err error
+		stateDB, err = state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()))
+		Expect(err).NotTo(HaveOccurred())
+		stateDB.SetBalance(contract, big.NewInt(100))
+	})
+
+	It("records the outermost call", func() {
+		execute([]byte{byte(vm.STOP)})
+
+		calls := tracer.Calls()
+		Expect(len(calls)).To(Equal(1))
+		Expect(calls[0].Type).To(Equal("CALL"))
+		Expect(calls[0].From).To(Equal(origin))
+		Expect(calls[0].To).To(Equal(contract))
+		Expect(calls[0].Gas).To(Equal(uint64(1000000)))
+		Expect(calls[0].Error).To(BeEmpty())
+		Expect(calls[0].Calls).To(BeEmpty())
+	})
+
+	It("records internal calls with their value", func() {
+		execute(call(5))
+
+		calls := tracer.Calls()
+		Expect(len(calls)).To(Equal(1))
+		Expect(len(calls[0].Calls)).To(Equal(1))
+		internal := calls[0].Calls[0]
+		Expect(internal.Type).To(Equal("CALL"))
+		Expect(internal.From).To(Equal(contract))
+		Expect(internal.To).To(Equal(callee))
+		Expect(internal.Value).To(Equal(big.NewInt(5)))
+		Expect(internal.Gas).To(Equal(uint64(0xffff)))
+		Expect(internal.Error).To(BeEmpty())
+	})
+
+	It("records calls made by internal calls", func() {
+		stateDB.SetCode(callee, append([]byte{byte(vm.PUSH20)}, append(beneficiary.Bytes(), byte(vm.SELFDESTRUCT))...))
+
+		execute(call(5))
+
+		internal := tracer.Calls()[0].Calls[0]
+		Expect(len(internal.Calls)).To(Equal(1))
+		selfDestruct := internal.Calls[0]
+		Expect(selfDestruct.Type).To(Equal("SELFDESTRUCT"))
+		Expect(selfDestruct.From).To(Equal(callee))
+		Expect(selfDestruct.To).To(Equal(beneficiary))
+		Expect(selfDestruct.Value).To(Equal(big.NewInt(5)))
+	})
+
+	It("records failed internal calls", func() {
+		stateDB.SetCode(callee, []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.REVERT)})
+
+		execute(call(5))
+
+		calls := tracer.Calls()
+		Expect(calls[0].Error).To(BeEmpty())
+		Expect(calls[0].Calls[0].Error).NotTo(BeEmpty())
+	})
+
+	It("records internal calls that fail without executing", func() {
+		execute(call(200))
+
+		Expect(tracer.Calls()[0].Calls[0].Error).NotTo(BeEmpty())
+	})
+
+	It("records created contract addresses", func() {
+		execute([]byte{
+			byte(vm.PUSH1), 0, // size
+			byte(vm.PUSH1), 0, // offset
+			byte(vm.PUSH1), 7, // value
+			byte(vm.CREATE),
+			byte(vm.POP),
+			byte(vm.STOP),
+		})
+
+		internal := tracer.Calls()[0].Calls[0]
+		Expect(internal.Type).To(Equal("CREATE"))
+		Expect(internal.From).To(Equal(contract))
+		Expect(internal.To).To(Equal(crypto.CreateAddress(contract, 0)))
+		Expect(internal.Value).To(Equal(big.NewInt(7)))
+	})
+
+	It("records each transaction in order", func() {
+		execute([]byte{byte(vm.STOP)})
+		execute(call(1))
+
+		calls := tracer.Calls()
+		Expect(len(calls)).To(Equal(2))
+		Expect(calls[0].Calls).To(BeEmpty())
+		Expect(len(calls[1].Calls)).To(Equal(1))
+	})
+})
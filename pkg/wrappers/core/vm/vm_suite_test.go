@@ -0,0 +1,13 @@
+package vm_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestVm(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Vm Suite")
+}
@@ -6,10 +6,13 @@ import (
 )
 
 type GethTrieNodeIterator interface {
+	Error() error
 	Hash() common.Hash
 	Leaf() bool
 	LeafBlob() []byte
+	LeafKey() []byte
 	Next(bool) bool
+	Path() []byte
 }
 
 type NodeIterator struct {
@@ -20,6 +23,10 @@ func NewNodeIterator(nodeIterator trie.NodeIterator) *NodeIterator {
 	return &NodeIterator{iterator: nodeIterator}
 }
 
+func (ni *NodeIterator) Error() error {
+	return ni.iterator.Error()
+}
+
 func (ni *NodeIterator) Hash() common.Hash {
 	return ni.iterator.Hash()
 }
@@ -32,6 +39,14 @@ func (ni *NodeIterator) LeafBlob() []byte {
 	return ni.iterator.LeafBlob()
 }
 
+func (ni *NodeIterator) LeafKey() []byte {
+	return ni.iterator.LeafKey()
+}
+
 func (ni *NodeIterator) Next(b bool) bool {
 	return ni.iterator.Next(b)
 }
+
+func (ni *NodeIterator) Path() []byte {
+	return ni.iterator.Path()
+}
@@ -0,0 +1,23 @@
+package trie
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// VerifyProof checks that proof, a list of encoded trie nodes, proves the value
+// at key in the trie with the given root. It returns the value, or nil if the
+// proof shows the key is absent.
+func VerifyProof(root common.Hash, key []byte, proof [][]byte) ([]byte, error) {
+	proofDB := memorydb.New()
+	for _, node := range proof {
+		err := proofDB.Put(crypto.Keccak256(node), node)
+		if err != nil {
+			return nil, err
+		}
+	}
+	value, _, err := trie.VerifyProof(root, key, proofDB)
+	return value, err
+}
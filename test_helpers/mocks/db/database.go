@@ -1,52 +1,107 @@
 package db
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
 )
 
 type MockDatabase struct {
 	computeBlockStateTrieErr                          error
-	computeBlockStateTriePassedCurrentBlock           *types.Block
-	computeBlockStateTriePassedParentBlock            *types.Block
+	computeBlockStateTriePassedBlock                  *types.Block
+	computeBlockStateTriePassedParentRoot             common.Hash
 	computeBlockStateTrieReturnHash                   common.Hash
+	computeBlockStateTrieReturnTraces                 []level.TransactionTrace
+	computeBlockStateTrieTraced                       bool
+	computeBlockWitnessErr                            error
+	computeBlockWitnessPassedBlocks                   []*types.Block
+	computeBlockWitnessPassedParentRoots              []common.Hash
+	computeBlockWitnessReturnWitness                  level.Witness
+	diffStateTriesErr                                 error
+	exportPreimagesErr                                error
+	exportPreimagesPassedKeySpace                     level.KeySpaceRange
+	exportPreimagesReturnPreimages                    []level.Preimage
+	getAllBlocksByBlockNumberErr                      error
+	getAllBlocksByBlockNumberPassedBlockNumbers       []int64
+	getAllBlocksByBlockNumberReturnBlocks             []level.StoredBlock
+	getAccountTrieNodesErr                            error
+	getAccountTrieNodesPassedAddresses                []common.Address
+	getAccountTrieNodesPassedRoot                     common.Hash
+	getAccountTrieNodesReturnCodes                    [][]byte
+	getAccountTrieNodesReturnStateTrieBytes           [][]byte
+	getAccountTrieNodesReturnStorageTrieNodes         []level.StorageTrieNode
+	diffStateTriesPassedRoots                         [][2]common.Hash
+	diffStateTriesReturnDiffs                         []level.AccountDiff
+	getBlockBodyByBlockNumberErr                      error
 	getBlockBodyByBlockNumberPassedBlockNumbers       []int64
 	getBlockBodyByBlockNumberReturnBodies             []*types.Body
+	getBlockNumberByBlockHashErr                      error
+	getBlockNumberByBlockHashPassedHash               common.Hash
+	getBlockNumberByBlockHashReturnCanonical          bool
+	getBlockNumberByBlockHashReturnNumber             int64
+	getBlockNumberByTransactionHashErr                error
+	getBlockNumberByTransactionHashPassedHash         common.Hash
+	getBlockNumberByTransactionHashReturnNumber       int64
+	getBlockByBlockNumberErr                          error
 	getBlockByBlockNumberPassedNumbers                []int64
 	getBlockByBlockNumberReturnBlock                  *types.Block
+	getBlockHeaderByBlockNumberErr                    error
 	getBlockHeaderByBlockNumberPassedBlockNumbers     []int64
 	getBlockHeaderByBlockNumberReturnHeader           *types.Header
+	getBlockHeaderByBlockNumberReturnChain            []*types.Header
 	getRawBlockHeaderByBlockNumberPassedBlockNumbers  []int64
 	getRawBlockHeaderByBlockNumberReturnBytes         [][]byte
+	getRawBlockHeaderByBlockNumberReturnErrs          []error
+	getBlockReceiptsErr                               error
 	getBlockReceiptsPassedBlockNumbers                []int64
 	getBlockReceiptsReturnReceipts                    types.Receipts
 	getStateAndStorageTrieNodesErr                    error
 	getStateAndStorageTrieNodesPassedRoot             common.Hash
 	getStateAndStorageTrieNodesReturnStateTrieBytes   [][]byte
-	getStateAndStorageTrieNodesReturnStorageTrieBytes [][]byte
+	getStateAndStorageTrieNodesReturnStorageTrieNodes []level.StorageTrieNode
+	proveAccountErr                                   error
+	proveAccountPassedAddress                         common.Address
+	proveAccountPassedRoot                            common.Hash
+	proveAccountPassedStorageKeys                     []common.Hash
+	proveAccountReturnProof                           level.AccountProof
 }
 
 func NewMockDatabase() *MockDatabase {
 	return &MockDatabase{
 		computeBlockStateTrieErr:                          nil,
-		computeBlockStateTriePassedCurrentBlock:           nil,
-		computeBlockStateTriePassedParentBlock:            nil,
+		computeBlockStateTriePassedBlock:                  nil,
+		computeBlockStateTriePassedParentRoot:             common.Hash{},
 		computeBlockStateTrieReturnHash:                   common.Hash{},
+		computeBlockWitnessErr:                            nil,
+		computeBlockWitnessPassedBlocks:                   nil,
+		computeBlockWitnessPassedParentRoots:              nil,
+		computeBlockWitnessReturnWitness:                  level.Witness{},
+		diffStateTriesErr:                                 nil,
+		diffStateTriesPassedRoots:                         nil,
+		diffStateTriesReturnDiffs:                         nil,
+		getBlockBodyByBlockNumberErr:                      nil,
 		getBlockBodyByBlockNumberPassedBlockNumbers:       nil,
 		getBlockBodyByBlockNumberReturnBodies:             nil,
+		getBlockByBlockNumberErr:                          nil,
 		getBlockByBlockNumberPassedNumbers:                nil,
 		getBlockByBlockNumberReturnBlock:                  nil,
+		getBlockHeaderByBlockNumberErr:                    nil,
 		getBlockHeaderByBlockNumberPassedBlockNumbers:     nil,
 		getBlockHeaderByBlockNumberReturnHeader:           nil,
 		getRawBlockHeaderByBlockNumberPassedBlockNumbers:  nil,
 		getRawBlockHeaderByBlockNumberReturnBytes:         nil,
+		getRawBlockHeaderByBlockNumberReturnErrs:          nil,
+		getBlockReceiptsErr:                               nil,
 		getBlockReceiptsPassedBlockNumbers:                nil,
 		getBlockReceiptsReturnReceipts:                    nil,
 		getStateAndStorageTrieNodesErr:                    nil,
 		getStateAndStorageTrieNodesPassedRoot:             common.Hash{},
 		getStateAndStorageTrieNodesReturnStateTrieBytes:   nil,
-		getStateAndStorageTrieNodesReturnStorageTrieBytes: nil,
+		getStateAndStorageTrieNodesReturnStorageTrieNodes: nil,
 	}
 }
 
@@ -58,26 +113,122 @@ func (db *MockDatabase) SetComputeBlockStateTrieReturnHash(hash common.Hash) {
 	db.computeBlockStateTrieReturnHash = hash
 }
 
+func (db *MockDatabase) SetComputeBlockStateTrieReturnTraces(traces []level.TransactionTrace) {
+	db.computeBlockStateTrieReturnTraces = traces
+}
+
+func (db *MockDatabase) SetComputeBlockWitnessError(err error) {
+	db.computeBlockWitnessErr = err
+}
+
+func (db *MockDatabase) SetComputeBlockWitnessReturnWitness(witness level.Witness) {
+	db.computeBlockWitnessReturnWitness = witness
+}
+
+func (db *MockDatabase) SetDiffStateTriesError(err error) {
+	db.diffStateTriesErr = err
+}
+
+func (db *MockDatabase) SetDiffStateTriesReturnDiffs(diffs []level.AccountDiff) {
+	db.diffStateTriesReturnDiffs = diffs
+}
+
+func (db *MockDatabase) SetExportPreimagesError(err error) {
+	db.exportPreimagesErr = err
+}
+
+func (db *MockDatabase) SetExportPreimagesReturnPreimages(preimages []level.Preimage) {
+	db.exportPreimagesReturnPreimages = preimages
+}
+
+func (db *MockDatabase) SetGetAllBlocksByBlockNumberError(err error) {
+	db.getAllBlocksByBlockNumberErr = err
+}
+
+func (db *MockDatabase) SetGetAllBlocksByBlockNumberReturnBlocks(blocks []level.StoredBlock) {
+	db.getAllBlocksByBlockNumberReturnBlocks = blocks
+}
+
+func (db *MockDatabase) SetGetBlockBodyByBlockNumberError(err error) {
+	db.getBlockBodyByBlockNumberErr = err
+}
+
 func (db *MockDatabase) SetGetBlockBodyByBlockNumberReturnBody(bodies []*types.Body) {
 	db.getBlockBodyByBlockNumberReturnBodies = bodies
 }
 
+func (db *MockDatabase) SetGetBlockNumberByBlockHashError(err error) {
+	db.getBlockNumberByBlockHashErr = err
+}
+
+func (db *MockDatabase) SetGetBlockNumberByBlockHashReturnNumber(blockNumber int64, canonical bool) {
+	db.getBlockNumberByBlockHashReturnNumber = blockNumber
+	db.getBlockNumberByBlockHashReturnCanonical = canonical
+}
+
+func (db *MockDatabase) SetGetBlockNumberByTransactionHashError(err error) {
+	db.getBlockNumberByTransactionHashErr = err
+}
+
+func (db *MockDatabase) SetGetBlockNumberByTransactionHashReturnNumber(blockNumber int64) {
+	db.getBlockNumberByTransactionHashReturnNumber = blockNumber
+}
+
+func (db *MockDatabase) SetGetBlockByBlockNumberError(err error) {
+	db.getBlockByBlockNumberErr = err
+}
+
 func (db *MockDatabase) SetGetBlockByBlockNumberReturnBlock(returnBlock *types.Block) {
 	db.getBlockByBlockNumberReturnBlock = returnBlock
 }
 
+func (db *MockDatabase) SetGetBlockHeaderByBlockNumberError(err error) {
+	db.getBlockHeaderByBlockNumberErr = err
+}
+
 func (db *MockDatabase) SetGetBlockHeaderByBlockNumberReturnHeader(header *types.Header) {
 	db.getBlockHeaderByBlockNumberReturnHeader = header
 }
 
+// SetGetBlockHeaderByBlockNumberReturnChain returns the header at each block
+// number's index in headers, and a not found error past the end
+func (db *MockDatabase) SetGetBlockHeaderByBlockNumberReturnChain(headers []*types.Header) {
+	db.getBlockHeaderByBlockNumberReturnChain = headers
+}
+
 func (db *MockDatabase) SetGetRawBlockHeaderByBlockNumberReturnBytes(returnBytes [][]byte) {
 	db.getRawBlockHeaderByBlockNumberReturnBytes = returnBytes
 }
 
+// errors are returned in order, one per call, before any bytes are consumed
+func (db *MockDatabase) SetGetRawBlockHeaderByBlockNumberReturnErrors(errs []error) {
+	db.getRawBlockHeaderByBlockNumberReturnErrs = errs
+}
+
+func (db *MockDatabase) SetGetBlockReceiptsError(err error) {
+	db.getBlockReceiptsErr = err
+}
+
 func (db *MockDatabase) SetGetBlockReceiptsReturnReceipts(receipts types.Receipts) {
 	db.getBlockReceiptsReturnReceipts = receipts
 }
 
+func (db *MockDatabase) SetGetAccountTrieNodesError(err error) {
+	db.getAccountTrieNodesErr = err
+}
+
+func (db *MockDatabase) SetGetAccountTrieNodesReturnCodes(codes [][]byte) {
+	db.getAccountTrieNodesReturnCodes = codes
+}
+
+func (db *MockDatabase) SetGetAccountTrieNodesReturnStateTrieBytes(returnBytes [][]byte) {
+	db.getAccountTrieNodesReturnStateTrieBytes = returnBytes
+}
+
+func (db *MockDatabase) SetGetAccountTrieNodesReturnStorageTrieNodes(nodes []level.StorageTrieNode) {
+	db.getAccountTrieNodesReturnStorageTrieNodes = nodes
+}
+
 func (db *MockDatabase) SetGetStateAndStorageTrieNodesError(err error) {
 	db.getStateAndStorageTrieNodesErr = err
 }
@@ -86,59 +237,172 @@ func (db *MockDatabase) SetGetStateAndStorageTrieNodesReturnStateTrieBytes(retur
 	db.getStateAndStorageTrieNodesReturnStateTrieBytes = returnBytes
 }
 
-func (db *MockDatabase) SetGetStateAndStorageTrieNodesReturnStorageTrieBytes(returnBytes [][]byte) {
-	db.getStateAndStorageTrieNodesReturnStorageTrieBytes = returnBytes
+func (db *MockDatabase) SetGetStateAndStorageTrieNodesReturnStorageTrieNodes(nodes []level.StorageTrieNode) {
+	db.getStateAndStorageTrieNodesReturnStorageTrieNodes = nodes
 }
 
-func (db *MockDatabase) ComputeBlockStateTrie(currentBlock *types.Block, parentBlock *types.Block) (common.Hash, error) {
-	db.computeBlockStateTriePassedCurrentBlock = currentBlock
-	db.computeBlockStateTriePassedParentBlock = parentBlock
+func (db *MockDatabase) SetProveAccountError(err error) {
+	db.proveAccountErr = err
+}
+
+func (db *MockDatabase) SetProveAccountReturnProof(proof level.AccountProof) {
+	db.proveAccountReturnProof = proof
+}
+
+func (db *MockDatabase) ComputeBlockStateTrie(ctx context.Context, block *types.Block, parentRoot common.Hash) (common.Hash, error) {
+	db.computeBlockStateTriePassedBlock = block
+	db.computeBlockStateTriePassedParentRoot = parentRoot
 	return db.computeBlockStateTrieReturnHash, db.computeBlockStateTrieErr
 }
 
-func (db *MockDatabase) GetBlockBodyByBlockNumber(blockNumber int64) *types.Body {
+func (db *MockDatabase) ComputeBlockStateTrieWithTraces(ctx context.Context, block *types.Block, parentRoot common.Hash) (common.Hash, []level.TransactionTrace, error) {
+	db.computeBlockStateTrieTraced = true
+	hash, err := db.ComputeBlockStateTrie(ctx, block, parentRoot)
+	return hash, db.computeBlockStateTrieReturnTraces, err
+}
+
+func (db *MockDatabase) ComputeBlockWitness(ctx context.Context, block *types.Block, parentRoot common.Hash) (level.Witness, error) {
+	db.computeBlockWitnessPassedBlocks = append(db.computeBlockWitnessPassedBlocks, block)
+	db.computeBlockWitnessPassedParentRoots = append(db.computeBlockWitnessPassedParentRoots, parentRoot)
+	return db.computeBlockWitnessReturnWitness, db.computeBlockWitnessErr
+}
+
+func (db *MockDatabase) DiffStateTries(ctx context.Context, fromRoot, toRoot common.Hash) ([]level.AccountDiff, error) {
+	db.diffStateTriesPassedRoots = append(db.diffStateTriesPassedRoots, [2]common.Hash{fromRoot, toRoot})
+	return db.diffStateTriesReturnDiffs, db.diffStateTriesErr
+}
+
+func (db *MockDatabase) ExportPreimages(ctx context.Context, keySpace level.KeySpaceRange, visit func(preimage level.Preimage) error) error {
+	db.exportPreimagesPassedKeySpace = keySpace
+	if db.exportPreimagesErr != nil {
+		return db.exportPreimagesErr
+	}
+	for _, preimage := range db.exportPreimagesReturnPreimages {
+		err := visit(preimage)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *MockDatabase) GetAllBlocksByBlockNumber(ctx context.Context, blockNumber int64) ([]level.StoredBlock, error) {
+	db.getAllBlocksByBlockNumberPassedBlockNumbers = append(db.getAllBlocksByBlockNumberPassedBlockNumbers, blockNumber)
+	return db.getAllBlocksByBlockNumberReturnBlocks, db.getAllBlocksByBlockNumberErr
+}
+
+func (db *MockDatabase) GetBlockBodyByBlockNumber(ctx context.Context, blockNumber int64) (*types.Body, error) {
 	db.getBlockBodyByBlockNumberPassedBlockNumbers = append(db.getBlockBodyByBlockNumberPassedBlockNumbers, blockNumber)
+	if db.getBlockBodyByBlockNumberErr != nil {
+		return nil, db.getBlockBodyByBlockNumberErr
+	}
 	returnBytes := db.getBlockBodyByBlockNumberReturnBodies[0]
 	db.getBlockBodyByBlockNumberReturnBodies = db.getBlockBodyByBlockNumberReturnBodies[1:]
-	return returnBytes
+	return returnBytes, nil
+}
+
+func (db *MockDatabase) GetBlockNumberByBlockHash(ctx context.Context, hash common.Hash) (int64, bool, error) {
+	db.getBlockNumberByBlockHashPassedHash = hash
+	return db.getBlockNumberByBlockHashReturnNumber, db.getBlockNumberByBlockHashReturnCanonical, db.getBlockNumberByBlockHashErr
+}
+
+func (db *MockDatabase) GetBlockNumberByTransactionHash(ctx context.Context, txHash common.Hash) (int64, error) {
+	db.getBlockNumberByTransactionHashPassedHash = txHash
+	return db.getBlockNumberByTransactionHashReturnNumber, db.getBlockNumberByTransactionHashErr
 }
 
-func (db *MockDatabase) GetBlockByBlockNumber(blockNumber int64) *types.Block {
+func (db *MockDatabase) GetBlockByBlockNumber(ctx context.Context, blockNumber int64) (*types.Block, error) {
 	db.getBlockByBlockNumberPassedNumbers = append(db.getBlockByBlockNumberPassedNumbers, blockNumber)
-	return db.getBlockByBlockNumberReturnBlock
+	return db.getBlockByBlockNumberReturnBlock, db.getBlockByBlockNumberErr
 }
 
-func (db *MockDatabase) GetBlockHeaderByBlockNumber(blockNumber int64) *types.Header {
+func (db *MockDatabase) GetBlockHeaderByBlockNumber(ctx context.Context, blockNumber int64) (*types.Header, error) {
 	db.getBlockHeaderByBlockNumberPassedBlockNumbers = append(db.getBlockHeaderByBlockNumberPassedBlockNumbers, blockNumber)
-	return db.getBlockHeaderByBlockNumberReturnHeader
+	if db.getBlockHeaderByBlockNumberReturnChain != nil {
+		if blockNumber >= int64(len(db.getBlockHeaderByBlockNumberReturnChain)) {
+			return nil, level.NewBlockDataError(blockNumber, level.BlockHeader, level.ErrNotFound)
+		}
+		return db.getBlockHeaderByBlockNumberReturnChain[blockNumber], nil
+	}
+	return db.getBlockHeaderByBlockNumberReturnHeader, db.getBlockHeaderByBlockNumberErr
 }
 
-func (db *MockDatabase) GetRawBlockHeaderByBlockNumber(blockNumber int64) []byte {
+func (db *MockDatabase) GetRawBlockHeaderByBlockNumber(ctx context.Context, blockNumber int64) ([]byte, error) {
 	db.getRawBlockHeaderByBlockNumberPassedBlockNumbers = append(db.getRawBlockHeaderByBlockNumberPassedBlockNumbers, blockNumber)
+	if len(db.getRawBlockHeaderByBlockNumberReturnErrs) > 0 {
+		err := db.getRawBlockHeaderByBlockNumberReturnErrs[0]
+		db.getRawBlockHeaderByBlockNumberReturnErrs = db.getRawBlockHeaderByBlockNumberReturnErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
 	returnBytes := db.getRawBlockHeaderByBlockNumberReturnBytes[0]
 	db.getRawBlockHeaderByBlockNumberReturnBytes = db.getRawBlockHeaderByBlockNumberReturnBytes[1:]
-	return returnBytes
+	return returnBytes, nil
 }
 
-func (db *MockDatabase) GetBlockReceipts(blockNumber int64) types.Receipts {
+func (db *MockDatabase) GetBlockReceipts(ctx context.Context, blockNumber int64) (types.Receipts, error) {
 	db.getBlockReceiptsPassedBlockNumbers = append(db.getBlockReceiptsPassedBlockNumbers, blockNumber)
-	return db.getBlockReceiptsReturnReceipts
+	return db.getBlockReceiptsReturnReceipts, db.getBlockReceiptsErr
 }
 
-func (db *MockDatabase) GetStateAndStorageTrieNodes(root common.Hash) ([][]byte, [][]byte, error) {
+func (db *MockDatabase) GetAccountTrieNodes(ctx context.Context, root common.Hash, addresses []common.Address) ([][]byte, []level.StorageTrieNode, [][]byte, error) {
+	db.getAccountTrieNodesPassedRoot = root
+	db.getAccountTrieNodesPassedAddresses = addresses
+	return db.getAccountTrieNodesReturnStateTrieBytes, db.getAccountTrieNodesReturnStorageTrieNodes, db.getAccountTrieNodesReturnCodes, db.getAccountTrieNodesErr
+}
+
+func (db *MockDatabase) GetStateAndStorageTrieNodes(ctx context.Context, root common.Hash) ([][]byte, []level.StorageTrieNode, error) {
 	db.getStateAndStorageTrieNodesPassedRoot = root
-	return db.getStateAndStorageTrieNodesReturnStateTrieBytes, db.getStateAndStorageTrieNodesReturnStorageTrieBytes, db.getStateAndStorageTrieNodesErr
+	return db.getStateAndStorageTrieNodesReturnStateTrieBytes, db.getStateAndStorageTrieNodesReturnStorageTrieNodes, db.getStateAndStorageTrieNodesErr
+}
+
+func (db *MockDatabase) ProveAccount(ctx context.Context, root common.Hash, address common.Address, storageKeys []common.Hash) (level.AccountProof, error) {
+	db.proveAccountPassedRoot = root
+	db.proveAccountPassedAddress = address
+	db.proveAccountPassedStorageKeys = storageKeys
+	return db.proveAccountReturnProof, db.proveAccountErr
+}
+
+func (db *MockDatabase) AssertComputeBlockStateTrieCalledWith(block *types.Block, parentRoot common.Hash) {
+	Expect(db.computeBlockStateTriePassedBlock).To(Equal(block))
+	Expect(db.computeBlockStateTriePassedParentRoot).To(Equal(parentRoot))
+}
+
+func (db *MockDatabase) AssertComputeBlockStateTrieTraced(traced bool) {
+	Expect(db.computeBlockStateTrieTraced).To(Equal(traced))
+}
+
+func (db *MockDatabase) AssertComputeBlockWitnessCalledWith(blocks []*types.Block, parentRoots []common.Hash) {
+	Expect(db.computeBlockWitnessPassedBlocks).To(Equal(blocks))
+	Expect(db.computeBlockWitnessPassedParentRoots).To(Equal(parentRoots))
 }
 
-func (db *MockDatabase) AssertComputeBlockStateTrieCalledWith(currentBlock *types.Block, parentBlock *types.Block) {
-	Expect(db.computeBlockStateTriePassedCurrentBlock).To(Equal(currentBlock))
-	Expect(db.computeBlockStateTriePassedParentBlock).To(Equal(parentBlock))
+// roots are passed as {from, to} pairs, in call order
+func (db *MockDatabase) AssertDiffStateTriesCalledWith(roots [][2]common.Hash) {
+	Expect(db.diffStateTriesPassedRoots).To(Equal(roots))
+}
+
+func (db *MockDatabase) AssertExportPreimagesCalledWith(keySpace level.KeySpaceRange) {
+	Expect(db.exportPreimagesPassedKeySpace).To(Equal(keySpace))
+}
+
+func (db *MockDatabase) AssertGetAllBlocksByBlockNumberCalledWith(blockNumbers []int64) {
+	Expect(db.getAllBlocksByBlockNumberPassedBlockNumbers).To(Equal(blockNumbers))
 }
 
 func (db *MockDatabase) AssertGetBlockBodyByBlockNumberCalledWith(blockNumbers []int64) {
 	Expect(db.getBlockBodyByBlockNumberPassedBlockNumbers).To(Equal(blockNumbers))
 }
 
+func (db *MockDatabase) AssertGetBlockNumberByBlockHashCalledWith(hash common.Hash) {
+	Expect(db.getBlockNumberByBlockHashPassedHash).To(Equal(hash))
+}
+
+func (db *MockDatabase) AssertGetBlockNumberByTransactionHashCalledWith(txHash common.Hash) {
+	Expect(db.getBlockNumberByTransactionHashPassedHash).To(Equal(txHash))
+}
+
 func (db *MockDatabase) AssertGetBlockByBlockNumberCalledwith(blockNumbers []int64) {
 	for i := 0; i < len(blockNumbers); i++ {
 		Expect(db.getBlockByBlockNumberPassedNumbers).To(ContainElement(blockNumbers[i]))
@@ -160,6 +424,21 @@ func (db *MockDatabase) AssertGetBlockReceiptsCalledWith(blockNumbers []int64) {
 	Expect(db.getBlockReceiptsPassedBlockNumbers).To(Equal(blockNumbers))
 }
 
+func (db *MockDatabase) AssertGetAccountTrieNodesCalledWith(root common.Hash, addresses []common.Address) {
+	Expect(db.getAccountTrieNodesPassedRoot).To(Equal(root))
+	Expect(db.getAccountTrieNodesPassedAddresses).To(Equal(addresses))
+}
+
+func (db *MockDatabase) AssertGetAccountTrieNodesNotCalled() {
+	Expect(db.getAccountTrieNodesPassedAddresses).To(BeNil())
+}
+
 func (db *MockDatabase) AssertGetStateTrieNodesCalledWith(root common.Hash) {
 	Expect(db.getStateAndStorageTrieNodesPassedRoot).To(Equal(root))
 }
+
+func (db *MockDatabase) AssertProveAccountCalledWith(root common.Hash, address common.Address, storageKeys []common.Hash) {
+	Expect(db.proveAccountPassedRoot).To(Equal(root))
+	Expect(db.proveAccountPassedAddress).To(Equal(address))
+	Expect(db.proveAccountPassedStorageKeys).To(Equal(storageKeys))
+}
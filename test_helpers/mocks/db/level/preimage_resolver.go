@@ -0,0 +1,76 @@
+package level
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+// MockPreimageResolver resolves only the hashes it was given preimages for
+type MockPreimageResolver struct {
+	addresses       map[common.Hash]common.Address
+	passedKeySpace  level.KeySpaceRange
+	returnErr       error
+	returnPreimages []level.Preimage
+	slotKeys        map[common.Hash]common.Hash
+}
+
+func NewMockPreimageResolver() *MockPreimageResolver {
+	return &MockPreimageResolver{
+		addresses: make(map[common.Hash]common.Address),
+		slotKeys:  make(map[common.Hash]common.Hash),
+	}
+}
+
+func (mpr *MockPreimageResolver) SetAddress(addressHash common.Hash, address common.Address) {
+	mpr.addresses[addressHash] = address
+}
+
+func (mpr *MockPreimageResolver) SetSlotKey(keyHash, key common.Hash) {
+	mpr.slotKeys[keyHash] = key
+}
+
+func (mpr *MockPreimageResolver) SetReturnPreimages(preimages []level.Preimage) {
+	mpr.returnPreimages = preimages
+}
+
+func (mpr *MockPreimageResolver) SetReturnErr(err error) {
+	mpr.returnErr = err
+}
+
+func (mpr *MockPreimageResolver) ExportPreimages(ctx context.Context, keySpace level.KeySpaceRange, visit func(preimage level.Preimage) error) error {
+	mpr.passedKeySpace = keySpace
+	if mpr.returnErr != nil {
+		return mpr.returnErr
+	}
+	for _, preimage := range mpr.returnPreimages {
+		err := visit(preimage)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (mpr *MockPreimageResolver) ResolveAddress(addressHash common.Hash) *common.Address {
+	address, ok := mpr.addresses[addressHash]
+	if !ok {
+		return nil
+	}
+	return &address
+}
+
+func (mpr *MockPreimageResolver) ResolveSlotKey(keyHash common.Hash) *common.Hash {
+	key, ok := mpr.slotKeys[keyHash]
+	if !ok {
+		return nil
+	}
+	return &key
+}
+
+func (mpr *MockPreimageResolver) AssertExportPreimagesCalledWith(keySpace level.KeySpaceRange) {
+	Expect(mpr.passedKeySpace).To(Equal(keySpace))
+}
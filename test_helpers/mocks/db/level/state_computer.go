@@ -4,21 +4,30 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
 )
 
 type MockStateComputer struct {
-	computeBlockStateTriePassedCurrentBlock *types.Block
-	computeBlockStateTriePassedParentBlock  *types.Block
-	computeBlockStateTrieReturnErr          error
-	computeBlockStateTrieReturnHash         common.Hash
+	computeBlockStateTriePassedBlock      *types.Block
+	computeBlockStateTriePassedParentRoot common.Hash
+	computeBlockStateTrieReturnErr        error
+	computeBlockStateTrieReturnHash       common.Hash
+	computeWithTracesPassedBlock          *types.Block
+	computeWithTracesPassedParentRoot     common.Hash
+	computeWithTracesReturnTraces         []level.TransactionTrace
+	computeBlockWitnessPassedBlock        *types.Block
+	computeBlockWitnessPassedParentRoot   common.Hash
+	computeBlockWitnessReturnErr          error
+	computeBlockWitnessReturnWitness      level.Witness
 }
 
 func NewMockStateComputer() *MockStateComputer {
 	return &MockStateComputer{
-		computeBlockStateTriePassedCurrentBlock: nil,
-		computeBlockStateTriePassedParentBlock:  nil,
-		computeBlockStateTrieReturnErr:          nil,
-		computeBlockStateTrieReturnHash:         common.Hash{},
+		computeBlockStateTriePassedBlock:      nil,
+		computeBlockStateTriePassedParentRoot: common.Hash{},
+		computeBlockStateTrieReturnErr:        nil,
+		computeBlockStateTrieReturnHash:       common.Hash{},
 	}
 }
 
@@ -30,13 +39,48 @@ func (msc *MockStateComputer) SetComputeBlockStateTrieReturnHash(hash common.Has
 	msc.computeBlockStateTrieReturnHash = hash
 }
 
-func (msc *MockStateComputer) ComputeBlockStateTrie(currentBlock *types.Block, parentBlock *types.Block) (common.Hash, error) {
-	msc.computeBlockStateTriePassedCurrentBlock = currentBlock
-	msc.computeBlockStateTriePassedParentBlock = parentBlock
+func (msc *MockStateComputer) ComputeBlockStateTrie(block *types.Block, parentRoot common.Hash) (common.Hash, error) {
+	msc.computeBlockStateTriePassedBlock = block
+	msc.computeBlockStateTriePassedParentRoot = parentRoot
 	return msc.computeBlockStateTrieReturnHash, msc.computeBlockStateTrieReturnErr
 }
 
-func (msc *MockStateComputer) AssertComputeBlockStateTrieCalledWith(currentBlock *types.Block, parentBlock *types.Block) {
-	Expect(msc.computeBlockStateTriePassedCurrentBlock).To(Equal(currentBlock))
-	Expect(msc.computeBlockStateTriePassedParentBlock).To(Equal(parentBlock))
+func (msc *MockStateComputer) AssertComputeBlockStateTrieCalledWith(block *types.Block, parentRoot common.Hash) {
+	Expect(msc.computeBlockStateTriePassedBlock).To(Equal(block))
+	Expect(msc.computeBlockStateTriePassedParentRoot).To(Equal(parentRoot))
+}
+
+func (msc *MockStateComputer) SetComputeBlockStateTrieWithTracesReturnTraces(traces []level.TransactionTrace) {
+	msc.computeWithTracesReturnTraces = traces
+}
+
+// ComputeBlockStateTrieWithTraces shares the root and error set for ComputeBlockStateTrie
+func (msc *MockStateComputer) ComputeBlockStateTrieWithTraces(block *types.Block, parentRoot common.Hash) (common.Hash, []level.TransactionTrace, error) {
+	msc.computeWithTracesPassedBlock = block
+	msc.computeWithTracesPassedParentRoot = parentRoot
+	return msc.computeBlockStateTrieReturnHash, msc.computeWithTracesReturnTraces, msc.computeBlockStateTrieReturnErr
+}
+
+func (msc *MockStateComputer) AssertComputeBlockStateTrieWithTracesCalledWith(block *types.Block, parentRoot common.Hash) {
+	Expect(msc.computeWithTracesPassedBlock).To(Equal(block))
+	Expect(msc.computeWithTracesPassedParentRoot).To(Equal(parentRoot))
+}
+
+func (msc *MockStateComputer) SetComputeBlockWitnessReturnErr(err error) {
+	msc.computeBlockWitnessReturnErr = err
+}
+
+func (msc *MockStateComputer) SetComputeBlockWitnessReturnWitness(witness level.Witness) {
+	msc.computeBlockWitnessReturnWitness = witness
+}
+
+func (msc *MockStateComputer) ComputeBlockWitness(block *types.Block, parentRoot common.Hash) (level.Witness, error) {
+	msc.computeBlockWitnessPassedBlock = block
+	msc.computeBlockWitnessPassedParentRoot = parentRoot
+	return msc.computeBlockWitnessReturnWitness, msc.computeBlockWitnessReturnErr
+}
+
+func (msc *MockStateComputer) AssertComputeBlockWitnessCalledWith(block *types.Block, parentRoot common.Hash) {
+	Expect(msc.computeBlockWitnessPassedBlock).To(Equal(block))
+	Expect(msc.computeBlockWitnessPassedParentRoot).To(Equal(parentRoot))
 }
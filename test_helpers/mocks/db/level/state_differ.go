@@ -0,0 +1,40 @@
+package level
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+type MockStateDiffer struct {
+	passedFromRoot common.Hash
+	passedToRoot   common.Hash
+	returnDiffs    []level.AccountDiff
+	returnErr      error
+}
+
+func NewMockStateDiffer() *MockStateDiffer {
+	return &MockStateDiffer{}
+}
+
+func (msd *MockStateDiffer) SetReturnDiffs(diffs []level.AccountDiff) {
+	msd.returnDiffs = diffs
+}
+
+func (msd *MockStateDiffer) SetReturnErr(err error) {
+	msd.returnErr = err
+}
+
+func (msd *MockStateDiffer) DiffStateTries(ctx context.Context, fromRoot, toRoot common.Hash) ([]level.AccountDiff, error) {
+	msd.passedFromRoot = fromRoot
+	msd.passedToRoot = toRoot
+	return msd.returnDiffs, msd.returnErr
+}
+
+func (msd *MockStateDiffer) AssertDiffStateTriesCalledWith(fromRoot, toRoot common.Hash) {
+	Expect(msd.passedFromRoot).To(Equal(fromRoot))
+	Expect(msd.passedToRoot).To(Equal(toRoot))
+}
@@ -0,0 +1,41 @@
+package level
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+)
+
+type MockStateProver struct {
+	passedAddress     common.Address
+	passedRoot        common.Hash
+	passedStorageKeys []common.Hash
+	returnErr         error
+	returnProof       level.AccountProof
+}
+
+func NewMockStateProver() *MockStateProver {
+	return &MockStateProver{}
+}
+
+func (msp *MockStateProver) SetReturnProof(proof level.AccountProof) {
+	msp.returnProof = proof
+}
+
+func (msp *MockStateProver) SetReturnErr(err error) {
+	msp.returnErr = err
+}
+
+func (msp *MockStateProver) ProveAccount(root common.Hash, address common.Address, storageKeys []common.Hash) (level.AccountProof, error) {
+	msp.passedRoot = root
+	msp.passedAddress = address
+	msp.passedStorageKeys = storageKeys
+	return msp.returnProof, msp.returnErr
+}
+
+func (msp *MockStateProver) AssertProveAccountCalledWith(root common.Hash, address common.Address, storageKeys []common.Hash) {
+	Expect(msp.passedRoot).To(Equal(root))
+	Expect(msp.passedAddress).To(Equal(address))
+	Expect(msp.passedStorageKeys).To(Equal(storageKeys))
+}
@@ -1,19 +1,30 @@
 package level
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/common"
 	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
 )
 
 type MockStateTrieReader struct {
-	passedRoot common.Hash
+	passedAddresses []common.Address
+	passedRoot      common.Hash
 }
 
 func NewMockStateTrieReader() *MockStateTrieReader {
 	return &MockStateTrieReader{}
 }
 
-func (mstr *MockStateTrieReader) GetStateAndStorageTrieNodes(stateRoot common.Hash) (stateTrieNodes, storageTrieNodes [][]byte, err error) {
+func (mstr *MockStateTrieReader) GetAccountTrieNodes(ctx context.Context, stateRoot common.Hash, addresses []common.Address) (stateTrieNodes [][]byte, storageTrieNodes []level.StorageTrieNode, codes [][]byte, err error) {
+	mstr.passedRoot = stateRoot
+	mstr.passedAddresses = addresses
+	return nil, nil, nil, nil
+}
+
+func (mstr *MockStateTrieReader) GetStateAndStorageTrieNodes(ctx context.Context, stateRoot common.Hash) (stateTrieNodes [][]byte, storageTrieNodes []level.StorageTrieNode, err error) {
 	mstr.passedRoot = stateRoot
 	return nil, nil, nil
 }
@@ -21,3 +32,8 @@ func (mstr *MockStateTrieReader) GetStateAndStorageTrieNodes(stateRoot common.Ha
 func (mstr *MockStateTrieReader) AssertGetStateAndStorageTrieNodesCalledWith(root common.Hash) {
 	Expect(mstr.passedRoot).To(Equal(root))
 }
+
+func (mstr *MockStateTrieReader) AssertGetAccountTrieNodesCalledWith(root common.Hash, addresses []common.Address) {
+	Expect(mstr.passedRoot).To(Equal(root))
+	Expect(mstr.passedAddresses).To(Equal(addresses))
+}
@@ -1,12 +1,19 @@
 package level
 
 import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
 	. "github.com/onsi/gomega"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
 	"github.com/vulcanize/eth-block-extractor/test_helpers"
 )
 
 type MockStorageTrieReader struct {
-	getStorageTrieCalled bool
+	getStorageTrieCalled            bool
+	getStorageTriePassedAddressHash common.Hash
+	getStorageTriePassedAddress     *common.Address
 }
 
 func NewMockStorageTrieReader() *MockStorageTrieReader {
@@ -15,11 +22,21 @@ func NewMockStorageTrieReader() *MockStorageTrieReader {
 	}
 }
 
-func (mstr *MockStorageTrieReader) GetStorageTrie(stateTrieLeafNode []byte) (storageTrieResults [][]byte, err error) {
+func (mstr *MockStorageTrieReader) GetStorageTrie(ctx context.Context, addressHash common.Hash, address *common.Address, stateTrieLeafNode []byte) (storageTrieResults []level.StorageTrieNode, err error) {
 	mstr.getStorageTrieCalled = true
-	return test_helpers.FakeTrieNodes, nil
+	mstr.getStorageTriePassedAddressHash = addressHash
+	mstr.getStorageTriePassedAddress = address
+	for _, node := range test_helpers.FakeTrieNodes {
+		storageTrieResults = append(storageTrieResults, level.StorageTrieNode{AddressHash: addressHash, Address: address, Node: node})
+	}
+	return storageTrieResults, nil
 }
 
 func (mstr *MockStorageTrieReader) AssertGetStorageTrieCalled() {
 	Expect(mstr.getStorageTrieCalled).To(BeTrue())
 }
+
+func (mstr *MockStorageTrieReader) AssertGetStorageTrieCalledWith(addressHash common.Hash, address *common.Address) {
+	Expect(mstr.getStorageTriePassedAddressHash).To(Equal(addressHash))
+	Expect(mstr.getStorageTriePassedAddress).To(Equal(address))
+}
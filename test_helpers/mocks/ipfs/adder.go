@@ -1,14 +1,18 @@
 package ipfs
 
 import (
+	"context"
+
+	"github.com/ipfs/go-cid"
 	ipld "github.com/ipfs/go-ipld-format"
 	. "github.com/onsi/gomega"
 )
 
 type MockAdder struct {
-	calledCount int
-	passedNodes []ipld.Node
-	err         error
+	calledCount   int
+	passedContext context.Context
+	passedNodes   []ipld.Node
+	err           error
 }
 
 func NewMockAdder() *MockAdder {
@@ -23,7 +27,8 @@ func (ma *MockAdder) SetError(err error) {
 	ma.err = err
 }
 
-func (ma *MockAdder) Add(node ipld.Node) error {
+func (ma *MockAdder) Add(ctx context.Context, node ipld.Node) error {
+	ma.passedContext = ctx
 	ma.calledCount++
 	ma.passedNodes = append(ma.passedNodes, node)
 	return ma.err
@@ -36,3 +41,16 @@ func (ma *MockAdder) AssertAddCalled(times int, nodeType interface{}) {
 		Expect(passedNode).To(BeAssignableToTypeOf(nodeType))
 	}
 }
+
+func (ma *MockAdder) AssertAddedNodeLinksTo(link cid.Cid) {
+	Expect(ma.passedNodes).NotTo(BeEmpty())
+	var linked []cid.Cid
+	for _, l := range ma.passedNodes[len(ma.passedNodes)-1].Links() {
+		linked = append(linked, l.Cid)
+	}
+	Expect(linked).To(ContainElement(link))
+}
+
+func (ma *MockAdder) AssertAddCalledWithContext(ctx context.Context) {
+	Expect(ma.passedContext).To(Equal(ctx))
+}
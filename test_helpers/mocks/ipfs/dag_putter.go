@@ -1,16 +1,29 @@
 package ipfs
 
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
 type MockDagPutter struct {
-	Called          bool
-	PassedInterface interface{}
-	Err             error
+	Called            bool
+	PassedContext     context.Context
+	PassedBlockNumber int64
+	PassedInterface   interface{}
+	Err               error
 }
 
 func NewMockDagPutter() *MockDagPutter {
 	return &MockDagPutter{
-		Called:          false,
-		PassedInterface: nil,
-		Err:             nil,
+		Called:            false,
+		PassedBlockNumber: 0,
+		PassedInterface:   nil,
+		Err:               nil,
 	}
 }
 
@@ -18,8 +31,54 @@ func (mdp *MockDagPutter) SetError(err error) {
 	mdp.Err = err
 }
 
-func (mdp *MockDagPutter) DagPut(raw interface{}) ([]string, error) {
+func (mdp *MockDagPutter) DagPutCode(ctx context.Context, blockNumber int64, code []byte) (ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, code)
+	return ipfs.Result{}, mdp.Err
+}
+
+func (mdp *MockDagPutter) DagPutHeader(ctx context.Context, blockNumber int64, header []byte) (ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, header)
+	return ipfs.Result{}, mdp.Err
+}
+
+func (mdp *MockDagPutter) DagPutBody(ctx context.Context, blockNumber int64, body *types.Body) ([]ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, body)
+	return nil, mdp.Err
+}
+
+func (mdp *MockDagPutter) DagPutReceipts(ctx context.Context, blockNumber int64, receipts types.Receipts) ([]ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, receipts)
+	return nil, mdp.Err
+}
+
+func (mdp *MockDagPutter) DagPutStateTrieNode(ctx context.Context, blockNumber int64, node []byte) (ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, node)
+	return ipfs.Result{}, mdp.Err
+}
+
+func (mdp *MockDagPutter) DagPutStorageTrieNode(ctx context.Context, blockNumber int64, node []byte) (ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, node)
+	return ipfs.Result{}, mdp.Err
+}
+
+func (mdp *MockDagPutter) DagPutStateDiff(ctx context.Context, blockNumber int64, diff db.StateDiff) (ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, diff)
+	return ipfs.Result{}, mdp.Err
+}
+
+func (mdp *MockDagPutter) DagPutTraces(ctx context.Context, blockNumber int64, traces db.BlockTraces) ([]ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, traces)
+	return nil, mdp.Err
+}
+
+func (mdp *MockDagPutter) DagPutWitness(ctx context.Context, blockNumber int64, witness level.Witness) (ipfs.Result, error) {
+	mdp.record(ctx, blockNumber, witness)
+	return ipfs.Result{}, mdp.Err
+}
+
+func (mdp *MockDagPutter) record(ctx context.Context, blockNumber int64, raw interface{}) {
 	mdp.Called = true
+	mdp.PassedContext = ctx
+	mdp.PassedBlockNumber = blockNumber
 	mdp.PassedInterface = raw
-	return nil, mdp.Err
 }
@@ -0,0 +1,38 @@
+package ipfs
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
+)
+
+// MockGetter returns the raw data of the IPLDs put in it
+type MockGetter struct {
+	blocks map[string][]byte
+	err    error
+}
+
+func NewMockGetter() *MockGetter {
+	return &MockGetter{blocks: make(map[string][]byte)}
+}
+
+func (mg *MockGetter) Put(c cid.Cid, raw []byte) {
+	mg.blocks[c.KeyString()] = raw
+}
+
+func (mg *MockGetter) SetError(err error) {
+	mg.err = err
+}
+
+func (mg *MockGetter) Get(ctx context.Context, c cid.Cid) ([]byte, error) {
+	if mg.err != nil {
+		return nil, mg.err
+	}
+	raw, ok := mg.blocks[c.KeyString()]
+	if !ok {
+		return nil, ipfs.ErrNotPublished
+	}
+	return raw, nil
+}
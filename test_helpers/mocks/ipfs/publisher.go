@@ -1,50 +1,111 @@
 package ipfs
 
 import (
+	"context"
+
 	"github.com/ethereum/go-ethereum/core/types"
 	. "github.com/onsi/gomega"
-	"github.com/vulcanize/eth-block-extractor/test_helpers"
+
+	"github.com/vulcanize/eth-block-extractor/pkg/db"
+	"github.com/vulcanize/eth-block-extractor/pkg/db/level"
+	"github.com/vulcanize/eth-block-extractor/pkg/ipfs"
 )
 
 type MockPublisher struct {
-	err              error
-	passedBlockDatas []interface{}
-	returnStrings    [][]string
+	err                error
+	passedBlockDatas   []interface{}
+	passedBlockNumbers []int64
+	returnResults      [][]ipfs.Result
 }
 
 func NewMockPublisher() *MockPublisher {
 	return &MockPublisher{
-		err:              nil,
-		passedBlockDatas: []interface{}{},
-		returnStrings:    nil,
+		err:                nil,
+		passedBlockDatas:   []interface{}{},
+		passedBlockNumbers: nil,
+		returnResults:      nil,
 	}
 }
 
-func (publisher *MockPublisher) SetReturnStrings(returnBytes [][]string) {
-	publisher.returnStrings = returnBytes
+func (publisher *MockPublisher) SetReturnResults(returnResults [][]ipfs.Result) {
+	publisher.returnResults = returnResults
 }
 
 func (publisher *MockPublisher) SetError(err error) {
 	publisher.err = err
 }
 
-func (publisher *MockPublisher) Write(input interface{}) ([]string, error) {
+func (publisher *MockPublisher) WriteCode(ctx context.Context, blockNumber int64, code []byte) (ipfs.Result, error) {
+	results, err := publisher.write(ctx, blockNumber, code)
+	return firstResult(results), err
+}
+
+func (publisher *MockPublisher) WriteHeader(ctx context.Context, blockNumber int64, header []byte) (ipfs.Result, error) {
+	results, err := publisher.write(ctx, blockNumber, header)
+	return firstResult(results), err
+}
+
+func (publisher *MockPublisher) WriteBody(ctx context.Context, blockNumber int64, body *types.Body) ([]ipfs.Result, error) {
+	return publisher.write(ctx, blockNumber, body)
+}
+
+func (publisher *MockPublisher) WriteReceipts(ctx context.Context, blockNumber int64, receipts types.Receipts) ([]ipfs.Result, error) {
+	return publisher.write(ctx, blockNumber, receipts)
+}
+
+func (publisher *MockPublisher) WriteStateTrieNode(ctx context.Context, blockNumber int64, node []byte) (ipfs.Result, error) {
+	results, err := publisher.write(ctx, blockNumber, node)
+	return firstResult(results), err
+}
+
+func (publisher *MockPublisher) WriteStorageTrieNode(ctx context.Context, blockNumber int64, node []byte) (ipfs.Result, error) {
+	results, err := publisher.write(ctx, blockNumber, node)
+	return firstResult(results), err
+}
+
+func (publisher *MockPublisher) WriteStateDiff(ctx context.Context, blockNumber int64, diff db.StateDiff) (ipfs.Result, error) {
+	results, err := publisher.write(ctx, blockNumber, diff)
+	return firstResult(results), err
+}
+
+func (publisher *MockPublisher) WriteTraces(ctx context.Context, blockNumber int64, traces db.BlockTraces) ([]ipfs.Result, error) {
+	return publisher.write(ctx, blockNumber, traces)
+}
+
+func (publisher *MockPublisher) WriteWitness(ctx context.Context, blockNumber int64, witness level.Witness) (ipfs.Result, error) {
+	results, err := publisher.write(ctx, blockNumber, witness)
+	return firstResult(results), err
+}
+
+func (publisher *MockPublisher) write(ctx context.Context, blockNumber int64, input interface{}) ([]ipfs.Result, error) {
 	publisher.passedBlockDatas = append(publisher.passedBlockDatas, input)
+	publisher.passedBlockNumbers = append(publisher.passedBlockNumbers, blockNumber)
 	if publisher.err != nil {
 		return nil, publisher.err
 	}
-	var stringsToReturn []string
-	if len(publisher.returnStrings) > 0 {
-		stringsToReturn = publisher.returnStrings[0]
-		if len(publisher.returnStrings) > 1 {
-			publisher.returnStrings = publisher.returnStrings[1:]
+	// like adding to ipfs, writing fails once the context is done
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var resultsToReturn []ipfs.Result
+	if len(publisher.returnResults) > 0 {
+		resultsToReturn = publisher.returnResults[0]
+		if len(publisher.returnResults) > 1 {
+			publisher.returnResults = publisher.returnResults[1:]
 		} else {
-			publisher.returnStrings = [][]string{{test_helpers.FakeString}}
+			publisher.returnResults = [][]ipfs.Result{{{BlockNumber: blockNumber}}}
 		}
 	} else {
-		stringsToReturn = []string{test_helpers.FakeString}
+		resultsToReturn = []ipfs.Result{{BlockNumber: blockNumber}}
+	}
+	return resultsToReturn, nil
+}
+
+func firstResult(results []ipfs.Result) ipfs.Result {
+	if len(results) == 0 {
+		return ipfs.Result{}
 	}
-	return stringsToReturn, nil
+	return results[0]
 }
 
 func (publisher *MockPublisher) AssertWriteCalledWithBytes(inputs [][]byte) {
@@ -72,3 +133,7 @@ func (publisher *MockPublisher) AssertWriteCalledWithBodies(bodies []*types.Body
 	}
 	Expect(expected).To(Equal(bodies))
 }
+
+func (publisher *MockPublisher) AssertWriteCalledWithBlockNumbers(blockNumbers []int64) {
+	Expect(publisher.passedBlockNumbers).To(Equal(blockNumbers))
+}
@@ -0,0 +1,64 @@
+package transformers
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	. "github.com/onsi/gomega"
+)
+
+// MockBlockSource returns the blocks and receipts given to it by number
+type MockBlockSource struct {
+	blocks   map[int64]*types.Block
+	receipts map[int64]types.Receipts
+	err      error
+}
+
+func NewMockBlockSource() *MockBlockSource {
+	return &MockBlockSource{blocks: make(map[int64]*types.Block), receipts: make(map[int64]types.Receipts)}
+}
+
+func (mbs *MockBlockSource) SetBlock(block *types.Block, receipts types.Receipts) {
+	mbs.blocks[block.Number().Int64()] = block
+	mbs.receipts[block.Number().Int64()] = receipts
+}
+
+func (mbs *MockBlockSource) SetError(err error) {
+	mbs.err = err
+}
+
+func (mbs *MockBlockSource) GetBlock(ctx context.Context, blockNumber int64) (*types.Block, types.Receipts, error) {
+	if mbs.err != nil {
+		return nil, nil, mbs.err
+	}
+	return mbs.blocks[blockNumber], mbs.receipts[blockNumber], nil
+}
+
+// MockChainWriter records the blocks and receipts written to it
+type MockChainWriter struct {
+	blocks   []*types.Block
+	receipts []types.Receipts
+	err      error
+}
+
+func NewMockChainWriter() *MockChainWriter {
+	return &MockChainWriter{}
+}
+
+func (mcw *MockChainWriter) SetError(err error) {
+	mcw.err = err
+}
+
+func (mcw *MockChainWriter) WriteBlock(block *types.Block, receipts types.Receipts) error {
+	if mcw.err != nil {
+		return mcw.err
+	}
+	mcw.blocks = append(mcw.blocks, block)
+	mcw.receipts = append(mcw.receipts, receipts)
+	return nil
+}
+
+func (mcw *MockChainWriter) AssertWriteBlockCalledWith(blocks []*types.Block, receipts []types.Receipts) {
+	Expect(mcw.blocks).To(Equal(blocks))
+	Expect(mcw.receipts).To(Equal(receipts))
+}
@@ -8,19 +8,32 @@ import (
 )
 
 type MockAccessorsChain struct {
+	getAllHashesPassedNumber                          uint64
+	getAllHashesReturnHashes                          []common.Hash
+	getAllHashesReturnErr                             error
 	getBlockPassedHash                                common.Hash
 	getBlockPassedNumber                              uint64
 	getBlockReturnBlock                               *types.Block
 	getBlockReceiptsPassedHash                        common.Hash
 	getBlockReceiptsPassedNumber                      uint64
+	getBlockReceiptsReturnReceipts                    types.Receipts
 	getBodyRLPPassedHash                              common.Hash
 	getBodyRLPPassedNumber                            uint64
+	getBodyRLPReturnBytes                             rlp.RawValue
+	getBodyReturnBody                                 *types.Body
 	getCanonicalHashPassedNumber                      uint64
 	getCanonicalHashReturnHash                        common.Hash
+	getHeaderNumberPassedHash                         common.Hash
+	getHeaderNumberReturnNumber                       *uint64
 	getHeaderPassedHash                               common.Hash
 	getHeaderPassedNumber                             uint64
+	getHeaderReturnHeader                             *types.Header
 	getHeaderRLPPassedHash                            common.Hash
 	getHeaderRLPPassedNumber                          uint64
+	getHeaderRLPReturnBytes                           rlp.RawValue
+	getTxLookupEntryPassedHash                        common.Hash
+	getTxLookupEntryReturnHash                        common.Hash
+	getReceiptsRLPReturnBytes                         rlp.RawValue
 	getStateAndStorageTrieNodesPassedRoot             common.Hash
 	getStateAndStorageTrieNodesReturnErr              error
 	getStateAndStorageTrieNodesReturnStateTrieBytes   [][]byte
@@ -45,10 +58,50 @@ func NewMockAccessorsChain() *MockAccessorsChain {
 	}
 }
 
+func (accessor *MockAccessorsChain) SetGetAllHashesReturnHashes(hashes []common.Hash) {
+	accessor.getAllHashesReturnHashes = hashes
+}
+
+func (accessor *MockAccessorsChain) SetGetAllHashesReturnErr(err error) {
+	accessor.getAllHashesReturnErr = err
+}
+
 func (accessor *MockAccessorsChain) SetGetBlockReturnBlock(returnBlock *types.Block) {
 	accessor.getBlockReturnBlock = returnBlock
 }
 
+func (accessor *MockAccessorsChain) SetGetBlockReceiptsReturnReceipts(receipts types.Receipts) {
+	accessor.getBlockReceiptsReturnReceipts = receipts
+}
+
+func (accessor *MockAccessorsChain) SetGetBodyReturnBody(body *types.Body) {
+	accessor.getBodyReturnBody = body
+}
+
+func (accessor *MockAccessorsChain) SetGetBodyRLPReturnBytes(raw rlp.RawValue) {
+	accessor.getBodyRLPReturnBytes = raw
+}
+
+func (accessor *MockAccessorsChain) SetGetHeaderReturnHeader(header *types.Header) {
+	accessor.getHeaderReturnHeader = header
+}
+
+func (accessor *MockAccessorsChain) SetGetHeaderNumberReturnNumber(number uint64) {
+	accessor.getHeaderNumberReturnNumber = &number
+}
+
+func (accessor *MockAccessorsChain) SetGetTxLookupEntryReturnHash(hash common.Hash) {
+	accessor.getTxLookupEntryReturnHash = hash
+}
+
+func (accessor *MockAccessorsChain) SetGetHeaderRLPReturnBytes(raw rlp.RawValue) {
+	accessor.getHeaderRLPReturnBytes = raw
+}
+
+func (accessor *MockAccessorsChain) SetGetReceiptsRLPReturnBytes(raw rlp.RawValue) {
+	accessor.getReceiptsRLPReturnBytes = raw
+}
+
 func (accessor *MockAccessorsChain) SetGetCanonicalHashReturnHash(hash common.Hash) {
 	accessor.getCanonicalHashReturnHash = hash
 }
@@ -65,6 +118,11 @@ func (accessor *MockAccessorsChain) SetGetStateTrieNodesReturnErr(err error) {
 	accessor.getStateAndStorageTrieNodesReturnErr = err
 }
 
+func (accessor *MockAccessorsChain) GetAllHashes(number uint64) ([]common.Hash, error) {
+	accessor.getAllHashesPassedNumber = number
+	return accessor.getAllHashesReturnHashes, accessor.getAllHashesReturnErr
+}
+
 func (accessor *MockAccessorsChain) GetBlock(hash common.Hash, number uint64) *types.Block {
 	accessor.getBlockPassedHash = hash
 	accessor.getBlockPassedNumber = number
@@ -74,13 +132,17 @@ func (accessor *MockAccessorsChain) GetBlock(hash common.Hash, number uint64) *t
 func (accessor *MockAccessorsChain) GetBlockReceipts(hash common.Hash, number uint64) types.Receipts {
 	accessor.getBlockReceiptsPassedHash = hash
 	accessor.getBlockReceiptsPassedNumber = number
-	return nil
+	return accessor.getBlockReceiptsReturnReceipts
 }
 
 func (accessor *MockAccessorsChain) GetBody(hash common.Hash, number uint64) *types.Body {
 	accessor.getBodyRLPPassedHash = hash
 	accessor.getBodyRLPPassedNumber = number
-	return nil
+	return accessor.getBodyReturnBody
+}
+
+func (accessor *MockAccessorsChain) GetBodyRLP(hash common.Hash, number uint64) rlp.RawValue {
+	return accessor.getBodyRLPReturnBytes
 }
 
 func (accessor *MockAccessorsChain) GetCanonicalHash(number uint64) common.Hash {
@@ -91,13 +153,27 @@ func (accessor *MockAccessorsChain) GetCanonicalHash(number uint64) common.Hash
 func (accessor *MockAccessorsChain) GetHeader(hash common.Hash, number uint64) *types.Header {
 	accessor.getHeaderPassedHash = hash
 	accessor.getHeaderPassedNumber = number
-	return nil
+	return accessor.getHeaderReturnHeader
+}
+
+func (accessor *MockAccessorsChain) GetHeaderNumber(hash common.Hash) *uint64 {
+	accessor.getHeaderNumberPassedHash = hash
+	return accessor.getHeaderNumberReturnNumber
+}
+
+func (accessor *MockAccessorsChain) GetTxLookupEntry(txHash common.Hash) common.Hash {
+	accessor.getTxLookupEntryPassedHash = txHash
+	return accessor.getTxLookupEntryReturnHash
 }
 
 func (accessor *MockAccessorsChain) GetHeaderRLP(hash common.Hash, number uint64) rlp.RawValue {
 	accessor.getHeaderRLPPassedHash = hash
 	accessor.getHeaderRLPPassedNumber = number
-	return nil
+	return accessor.getHeaderRLPReturnBytes
+}
+
+func (accessor *MockAccessorsChain) GetReceiptsRLP(hash common.Hash, number uint64) rlp.RawValue {
+	return accessor.getReceiptsRLPReturnBytes
 }
 
 func (accessor *MockAccessorsChain) GetStateAndStorageTrieNodes(root common.Hash) ([][]byte, [][]byte, error) {
@@ -105,6 +181,10 @@ func (accessor *MockAccessorsChain) GetStateAndStorageTrieNodes(root common.Hash
 	return accessor.getStateAndStorageTrieNodesReturnStateTrieBytes, accessor.getStateAndStorageTrieNodesReturnStorageTrieBytes, accessor.getStateAndStorageTrieNodesReturnErr
 }
 
+func (accessor *MockAccessorsChain) AssertGetAllHashesCalledWith(number uint64) {
+	Expect(accessor.getAllHashesPassedNumber).To(Equal(number))
+}
+
 func (accessor *MockAccessorsChain) AssertGetBlockCalledWith(hash common.Hash, number uint64) {
 	Expect(accessor.getBlockPassedHash).To(Equal(hash))
 	Expect(accessor.getBlockPassedNumber).To(Equal(number))
@@ -129,6 +209,14 @@ func (accessor *MockAccessorsChain) AssertGetHeaderCalledWith(hash common.Hash,
 	Expect(accessor.getHeaderPassedNumber).To(Equal(number))
 }
 
+func (accessor *MockAccessorsChain) AssertGetHeaderNumberCalledWith(hash common.Hash) {
+	Expect(accessor.getHeaderNumberPassedHash).To(Equal(hash))
+}
+
+func (accessor *MockAccessorsChain) AssertGetTxLookupEntryCalledWith(txHash common.Hash) {
+	Expect(accessor.getTxLookupEntryPassedHash).To(Equal(txHash))
+}
+
 func (accessor *MockAccessorsChain) AssertGetHeaderRLPCalledWith(hash common.Hash, number uint64) {
 	Expect(accessor.getHeaderRLPPassedHash).To(Equal(hash))
 	Expect(accessor.getHeaderRLPPassedNumber).To(Equal(number))
@@ -11,8 +11,10 @@ import (
 )
 
 type MockStateDatabase struct {
-	ReturnDB   state.Database
-	ReturnTrie state_wrapper.GethTrie
+	ReturnDB          state.Database
+	ReturnOpenTrieErr error
+	ReturnTrie        state_wrapper.GethTrie
+	returnTriesByRoot map[common.Hash]state_wrapper.GethTrie
 }
 
 func NewMockStateDatabase() *MockStateDatabase {
@@ -23,11 +25,25 @@ func (msdb *MockStateDatabase) CreateFakeUnderlyingDatabase() state.Database {
 	return &mockStateDatabase{}
 }
 
+// SetReturnTrieForRoot overrides ReturnTrie for the passed root
+func (msdb *MockStateDatabase) SetReturnTrieForRoot(root common.Hash, stateTrie state_wrapper.GethTrie) {
+	if msdb.returnTriesByRoot == nil {
+		msdb.returnTriesByRoot = make(map[common.Hash]state_wrapper.GethTrie)
+	}
+	msdb.returnTriesByRoot[root] = stateTrie
+}
+
 func (msdb *MockStateDatabase) Database() state.Database {
 	return msdb.ReturnDB
 }
 
 func (msdb *MockStateDatabase) OpenTrie(root common.Hash) (state_wrapper.GethTrie, error) {
+	if msdb.ReturnOpenTrieErr != nil {
+		return nil, msdb.ReturnOpenTrieErr
+	}
+	if stateTrie, ok := msdb.returnTriesByRoot[root]; ok {
+		return stateTrie, nil
+	}
 	return msdb.ReturnTrie, nil
 }
 
@@ -41,3 +41,7 @@ func (mst *MockStateDB) StateDB() *state.StateDB {
 func (mst *MockStateDB) AssertCommitCalled() {
 	Expect(mst.commitCalled).To(BeTrue())
 }
+
+func (mst *MockStateDB) AssertCommitNotCalled() {
+	Expect(mst.commitCalled).To(BeFalse())
+}
@@ -41,3 +41,9 @@ func (mstf *MockStateDBFactory) AssertNewStateTrieCalledWith(root common.Hash, d
 	Expect(mstf.passedRoot).To(Equal(root))
 	Expect(mstf.passedDatabase).To(Equal(db))
 }
+
+func (mstf *MockStateDBFactory) AssertNewStateTrieCalledWithRecordingDatabase(root common.Hash, db state.Database) {
+	Expect(mstf.passedRoot).To(Equal(root))
+	Expect(mstf.passedDatabase).To(BeAssignableToTypeOf(&state_wrapper.RecordingDatabase{}))
+	Expect(mstf.passedDatabase.(*state_wrapper.RecordingDatabase).Database).To(Equal(db))
+}
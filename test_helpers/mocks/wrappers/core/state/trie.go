@@ -5,7 +5,10 @@ import (
 )
 
 type MockTrie struct {
-	iterator trie.GethTrieNodeIterator
+	iterator    trie.GethTrieNodeIterator
+	passedKeys  [][]byte
+	returnErr   error
+	returnProof [][]byte
 }
 
 func NewMockTrie() *MockTrie {
@@ -16,6 +19,20 @@ func (mt *MockTrie) SetReturnIterator(iterator trie.GethTrieNodeIterator) {
 	mt.iterator = iterator
 }
 
+func (mt *MockTrie) GetKey(hashedKey []byte) []byte {
+	return nil
+}
+
 func (mt *MockTrie) NodeIterator(startKey []byte) trie.GethTrieNodeIterator {
 	return mt.iterator
 }
+
+func (mt *MockTrie) SetReturnProof(proof [][]byte, err error) {
+	mt.returnProof = proof
+	mt.returnErr = err
+}
+
+func (mt *MockTrie) Prove(key []byte) ([][]byte, error) {
+	mt.passedKeys = append(mt.passedKeys, key)
+	return mt.returnProof, mt.returnErr
+}
@@ -1,17 +1,23 @@
 package core
 
 import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
 	. "github.com/onsi/gomega"
 )
 
 type MockProcessor struct {
 	passedBlock    *types.Block
 	passedStateDB  *state.StateDB
+	passedTracer   vm.Tracer
 	returnErr      error
 	returnReceipts types.Receipts
 	returnUsedGas  uint64
+	tracedCalls    int
 }
 
 func NewMockProcessor() *MockProcessor {
@@ -46,3 +52,23 @@ func (mp *MockProcessor) AssertProcessCalledWith(block *types.Block, stateDB *st
 	Expect(mp.passedBlock).To(Equal(block))
 	Expect(mp.passedStateDB).To(Equal(stateDB))
 }
+
+// SetTracedCalls sets the number of outermost calls reported to the tracer
+// passed to ProcessWithTracer
+func (mp *MockProcessor) SetTracedCalls(tracedCalls int) {
+	mp.tracedCalls = tracedCalls
+}
+
+func (mp *MockProcessor) ProcessWithTracer(block *types.Block, stateDB *state.StateDB, tracer vm.Tracer) (types.Receipts, []*types.Log, uint64, error) {
+	mp.passedTracer = tracer
+	for i := 0; i < mp.tracedCalls; i++ {
+		tracer.CaptureStart(common.Address{}, common.Address{}, false, nil, 0, big.NewInt(0))
+		tracer.CaptureEnd(nil, 0, 0, nil)
+	}
+	return mp.Process(block, stateDB)
+}
+
+func (mp *MockProcessor) AssertProcessWithTracerCalledWith(block *types.Block, stateDB *state.StateDB) {
+	mp.AssertProcessCalledWith(block, stateDB)
+	Expect(mp.passedTracer).NotTo(BeNil())
+}
@@ -7,6 +7,7 @@ import (
 
 type MockIterator struct {
 	includeLeaf    bool
+	returnErr      error
 	returnHash     common.Hash
 	timesToIterate int
 }
@@ -23,10 +24,18 @@ func (mi *MockIterator) SetReturnHash(hash common.Hash) {
 	mi.returnHash = hash
 }
 
+func (mi *MockIterator) SetReturnErr(err error) {
+	mi.returnErr = err
+}
+
 func (mi *MockIterator) SetIncludeLeaf() {
 	mi.includeLeaf = true
 }
 
+func (mi *MockIterator) Error() error {
+	return mi.returnErr
+}
+
 func (mi *MockIterator) Leaf() bool {
 	if mi.includeLeaf {
 		mi.includeLeaf = false
@@ -39,6 +48,14 @@ func (mi *MockIterator) LeafBlob() []byte {
 	return test_helpers.FakeTrieNode
 }
 
+func (mi *MockIterator) LeafKey() []byte {
+	return test_helpers.FakeHash.Bytes()
+}
+
+func (mi *MockIterator) Path() []byte {
+	return nil
+}
+
 func (mi *MockIterator) Next(bool) bool {
 	if mi.timesToIterate > 0 {
 		mi.timesToIterate--
@@ -0,0 +1,47 @@
+package trie
+
+import "github.com/ethereum/go-ethereum/common"
+
+// MockLeafIterator yields only leaves, with the passed keys and blobs in order
+type MockLeafIterator struct {
+	blobs [][]byte
+	index int
+	keys  [][]byte
+}
+
+func NewMockLeafIterator(keys, blobs [][]byte) *MockLeafIterator {
+	return &MockLeafIterator{
+		blobs: blobs,
+		index: -1,
+		keys:  keys,
+	}
+}
+
+func (mli *MockLeafIterator) Error() error {
+	return nil
+}
+
+func (mli *MockLeafIterator) Hash() common.Hash {
+	return common.Hash{}
+}
+
+func (mli *MockLeafIterator) Leaf() bool {
+	return true
+}
+
+func (mli *MockLeafIterator) LeafBlob() []byte {
+	return mli.blobs[mli.index]
+}
+
+func (mli *MockLeafIterator) LeafKey() []byte {
+	return mli.keys[mli.index]
+}
+
+func (mli *MockLeafIterator) Path() []byte {
+	return nil
+}
+
+func (mli *MockLeafIterator) Next(bool) bool {
+	mli.index++
+	return mli.index < len(mli.keys)
+}